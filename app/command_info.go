@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+// The COMMAND command describes the commands of the table. It lives next to the
+// table rather than with the other handlers, as it reads the table itself.
+
+var commandFlagNames = []struct {
+	flag CommandFlag
+	name string
+}{
+	{FlagWrite, "write"},
+	{FlagReadonly, "readonly"},
+	{FlagAdmin, "admin"},
+	{FlagNoScript, "noscript"},
+	{FlagBlocking, "blocking"},
+}
+
+// sortedCommands returns the commands of the table, ordered by name
+func sortedCommands() []*Command {
+	cmds := make([]*Command, 0, len(commandTable))
+	for _, cmd := range commandTable {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// info describes the command as COMMAND INFO does: its name, arity, flags and key
+// positions, followed by its ACL categories, tips, key specifications and
+// subcommands, none of which are tracked
+func (cmd *Command) info() resp.Value {
+	flags := []resp.Value{}
+	for _, f := range commandFlagNames {
+		if cmd.Flags&f.flag != 0 {
+			flags = append(flags, resp.SimpleString(f.name))
+		}
+	}
+	return resp.Array(
+		resp.BulkString(cmd.Name),
+		resp.Integer(int64(cmd.Arity)),
+		resp.Set(flags...),
+		resp.Integer(int64(cmd.FirstKey)),
+		resp.Integer(int64(cmd.LastKey)),
+		resp.Integer(int64(cmd.KeyStep)),
+		resp.Set(),
+		resp.Array(),
+		resp.Array(),
+		resp.Array(),
+	)
+}
+
+// CommandInfo handles COMMAND, COMMAND COUNT, COMMAND LIST, COMMAND INFO and
+// COMMAND GETKEYS. Commands that find their keys from their arguments, such as
+// those taking a number of keys, have no key positions, so GETKEYS reports that
+// they take no keys.
+func CommandInfo(client *types.Client, server *types.ServerState, args [][]byte) {
+	if len(args) == 0 {
+		infos := []resp.Value{}
+		for _, cmd := range sortedCommands() {
+			infos = append(infos, cmd.info())
+		}
+		writeReply(client, resp.Array(infos...))
+		return
+	}
+
+	switch sub := strings.ToUpper(string(args[0])); {
+	case sub == "COUNT" && len(args) == 1:
+		writeReply(client, resp.Integer(int64(len(commandTable))))
+
+	case sub == "LIST" && len(args) == 1:
+		names := []string{}
+		for _, cmd := range sortedCommands() {
+			names = append(names, cmd.Name)
+		}
+		writeReply(client, resp.BulkStrings(names...))
+
+	case sub == "INFO":
+		infos := []resp.Value{}
+		for _, name := range args[1:] {
+			if cmd, ok := lookupCommand(string(name)); ok {
+				infos = append(infos, cmd.info())
+			} else {
+				infos = append(infos, resp.NullArray())
+			}
+		}
+		writeReply(client, resp.Array(infos...))
+
+	case sub == "DOCS":
+		// Documentation is not tracked, which clients asking for it accept
+		writeReply(client, resp.Map())
+
+	case sub == "GETKEYS" && len(args) >= 2:
+		cmd, ok := lookupCommand(string(args[1]))
+		switch {
+		case !ok:
+			sendError(client, "ERR Invalid command specified")
+		case !cmd.CheckArity(len(args) - 1):
+			sendError(client, "ERR Invalid number of arguments specified for command")
+		default:
+			keys := cmd.Keys(args[1:])
+			if len(keys) == 0 {
+				sendError(client, "ERR The command has no key arguments")
+				return
+			}
+			writeReply(client, resp.BulkStrings(keys...))
+		}
+
+	default:
+		sendError(client, fmt.Sprintf("ERR unknown subcommand '%.128s'. Try COMMAND HELP.", args[0]))
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/handlers"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+type CommandFlag int
+
+const (
+	FlagWrite    CommandFlag = 1 << iota // May modify the keyspace
+	FlagReadonly                         // Only reads from the keyspace
+	FlagAdmin                            // Server administration and replication
+	FlagNoScript                         // Not allowed from scripts
+	FlagBlocking                         // May block the client
+)
+
+// CommandHandler runs a command. Its arguments, without the command name, are byte
+// slices owned by the command, which handlers may store as values without copying
+// them again.
+type CommandHandler func(client *types.Client, server *types.ServerState, args [][]byte)
+
+// Command describes how a command is dispatched. Arity and key positions follow
+// the Redis conventions, counting the command name itself as the first argument.
+type Command struct {
+	Name    string
+	Handler CommandHandler
+	Arity   int // Exact number of arguments, or the negated minimum when the command is variadic
+	Flags   CommandFlag
+
+	FirstKey int // Position of the first key, 0 if the command takes no keys
+	LastKey  int // Position of the last key, negative values count from the end
+	KeyStep  int // Step between the positions of consecutive keys
+}
+
+var commandTable = map[string]*Command{}
+
+func registerCommand(cmd Command) {
+	commandTable[strings.ToLower(cmd.Name)] = &cmd
+}
+
+func lookupCommand(name string) (*Command, bool) {
+	cmd, ok := commandTable[strings.ToLower(name)]
+	return cmd, ok
+}
+
+// CheckArity reports whether the command accepts argc arguments, including its name
+func (cmd *Command) CheckArity(argc int) bool {
+	if cmd.Arity < 0 {
+		return argc >= -cmd.Arity
+	}
+	return argc == cmd.Arity
+}
+
+// Keys returns the key arguments of a command invocation, including its name
+func (cmd *Command) Keys(argv [][]byte) []string {
+	if cmd.FirstKey == 0 || cmd.FirstKey >= len(argv) {
+		return nil
+	}
+
+	last := cmd.LastKey
+	if last < 0 {
+		last = len(argv) + last
+	}
+	last = min(last, len(argv)-1)
+
+	keys := []string{}
+	for i := cmd.FirstKey; i <= last; i += max(cmd.KeyStep, 1) {
+		keys = append(keys, string(argv[i]))
+	}
+	return keys
+}
+
+func init() {
+	registerCommand(Command{Name: "ping", Handler: handlers.Ping, Arity: -1})
+	registerCommand(Command{Name: "echo", Handler: handlers.Echo, Arity: 2})
+	registerCommand(Command{Name: "hello", Handler: handlers.Hello, Arity: -1, Flags: FlagNoScript})
+	registerCommand(Command{Name: "info", Handler: handlers.Info, Arity: -1})
+	registerCommand(Command{Name: "command", Handler: CommandInfo, Arity: -1})
+
+	registerCommand(Command{Name: "set", Handler: handlers.Set, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "get", Handler: handlers.Get, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "setnx", Handler: handlers.SetNX, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "setex", Handler: handlers.SetEX, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "psetex", Handler: handlers.PSetEX, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "getdel", Handler: handlers.GetDel, Arity: 2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "getex", Handler: handlers.GetEx, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "mget", Handler: handlers.MGet, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "mset", Handler: handlers.MSet, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: -1, KeyStep: 2})
+	registerCommand(Command{Name: "msetnx", Handler: handlers.MSetNX, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: -1, KeyStep: 2})
+	registerCommand(Command{Name: "incr", Handler: handlers.Incr, Arity: 2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "decr", Handler: handlers.Decr, Arity: 2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "incrby", Handler: handlers.IncrBy, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "decrby", Handler: handlers.DecrBy, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "incrbyfloat", Handler: handlers.IncrByFloat, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "append", Handler: handlers.Append, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "strlen", Handler: handlers.Strlen, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "getrange", Handler: handlers.GetRange, Arity: 4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "setrange", Handler: handlers.SetRange, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "setbit", Handler: handlers.SetBit, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "getbit", Handler: handlers.GetBit, Arity: 3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "bitcount", Handler: handlers.BitCount, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "bitpos", Handler: handlers.BitPos, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "bitop", Handler: handlers.BitOp, Arity: -4, Flags: FlagWrite, FirstKey: 2, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "bitfield", Handler: handlers.BitField, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "bitfield_ro", Handler: handlers.BitFieldRO, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "pfadd", Handler: handlers.PFAdd, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "pfcount", Handler: handlers.PFCount, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "pfmerge", Handler: handlers.PFMerge, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: -1, KeyStep: 1})
+
+	registerCommand(Command{Name: "lpush", Handler: handlers.LPush, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "rpush", Handler: handlers.RPush, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "lpushx", Handler: handlers.LPushX, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "rpushx", Handler: handlers.RPushX, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "lpop", Handler: handlers.LPop, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "rpop", Handler: handlers.RPop, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "llen", Handler: handlers.LLen, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "lindex", Handler: handlers.LIndex, Arity: 3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "lset", Handler: handlers.LSet, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "lrange", Handler: handlers.LRange, Arity: 4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "ltrim", Handler: handlers.LTrim, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "lrem", Handler: handlers.LRem, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "linsert", Handler: handlers.LInsert, Arity: 5, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "lpos", Handler: handlers.LPos, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "lmove", Handler: handlers.LMove, Arity: 5, Flags: FlagWrite, FirstKey: 1, LastKey: 2, KeyStep: 1})
+	registerCommand(Command{Name: "rpoplpush", Handler: handlers.RPopLPush, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 2, KeyStep: 1})
+	registerCommand(Command{Name: "lmpop", Handler: handlers.LMPop, Arity: -4, Flags: FlagWrite})
+	registerCommand(Command{Name: "blpop", Handler: handlers.BLPop, Arity: -3, Flags: FlagWrite | FlagBlocking, FirstKey: 1, LastKey: -2, KeyStep: 1})
+	registerCommand(Command{Name: "brpop", Handler: handlers.BRPop, Arity: -3, Flags: FlagWrite | FlagBlocking, FirstKey: 1, LastKey: -2, KeyStep: 1})
+	registerCommand(Command{Name: "blmove", Handler: handlers.BLMove, Arity: 6, Flags: FlagWrite | FlagBlocking, FirstKey: 1, LastKey: 2, KeyStep: 1})
+	registerCommand(Command{Name: "brpoplpush", Handler: handlers.BRPopLPush, Arity: 4, Flags: FlagWrite | FlagBlocking, FirstKey: 1, LastKey: 2, KeyStep: 1})
+	registerCommand(Command{Name: "blmpop", Handler: handlers.BLMPop, Arity: -5, Flags: FlagWrite | FlagBlocking})
+
+	registerCommand(Command{Name: "hset", Handler: handlers.HSet, Arity: -4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hsetnx", Handler: handlers.HSetNX, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hmset", Handler: handlers.HMSet, Arity: -4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hget", Handler: handlers.HGet, Arity: 3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hmget", Handler: handlers.HMGet, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hdel", Handler: handlers.HDel, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hlen", Handler: handlers.HLen, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hstrlen", Handler: handlers.HStrlen, Arity: 3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hexists", Handler: handlers.HExists, Arity: 3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hkeys", Handler: handlers.HKeys, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hvals", Handler: handlers.HVals, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hgetall", Handler: handlers.HGetAll, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hincrby", Handler: handlers.HIncrBy, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hincrbyfloat", Handler: handlers.HIncrByFloat, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hrandfield", Handler: handlers.HRandField, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hscan", Handler: handlers.HScan, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hexpire", Handler: handlers.HExpire, Arity: -6, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hpexpire", Handler: handlers.HPExpire, Arity: -6, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hexpireat", Handler: handlers.HExpireAt, Arity: -6, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hpexpireat", Handler: handlers.HPExpireAt, Arity: -6, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "httl", Handler: handlers.HTTL, Arity: -5, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hpttl", Handler: handlers.HPTTL, Arity: -5, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hexpiretime", Handler: handlers.HExpireTime, Arity: -5, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hpexpiretime", Handler: handlers.HPExpireTime, Arity: -5, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "hpersist", Handler: handlers.HPersist, Arity: -5, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+
+	registerCommand(Command{Name: "sadd", Handler: handlers.SAdd, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "srem", Handler: handlers.SRem, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "sismember", Handler: handlers.SIsMember, Arity: 3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "smismember", Handler: handlers.SMIsMember, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "smembers", Handler: handlers.SMembers, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "scard", Handler: handlers.SCard, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "spop", Handler: handlers.SPop, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "srandmember", Handler: handlers.SRandMember, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "smove", Handler: handlers.SMove, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 2, KeyStep: 1})
+	registerCommand(Command{Name: "sinter", Handler: handlers.SInter, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "sunion", Handler: handlers.SUnion, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "sdiff", Handler: handlers.SDiff, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "sinterstore", Handler: handlers.SInterStore, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "sunionstore", Handler: handlers.SUnionStore, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "sdiffstore", Handler: handlers.SDiffStore, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "sintercard", Handler: handlers.SInterCard, Arity: -3, Flags: FlagReadonly})
+	registerCommand(Command{Name: "sscan", Handler: handlers.SScan, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+
+	registerCommand(Command{Name: "zadd", Handler: handlers.ZAdd, Arity: -4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zincrby", Handler: handlers.ZIncrBy, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zrem", Handler: handlers.ZRem, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zcard", Handler: handlers.ZCard, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zscore", Handler: handlers.ZScore, Arity: 3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zmscore", Handler: handlers.ZMScore, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zrank", Handler: handlers.ZRank, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zrevrank", Handler: handlers.ZRevRank, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zrange", Handler: handlers.ZRange, Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zrevrange", Handler: handlers.ZRevRange, Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zrangebyscore", Handler: handlers.ZRangeByScore, Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zrevrangebyscore", Handler: handlers.ZRevRangeByScore, Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zrangebylex", Handler: handlers.ZRangeByLex, Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zrevrangebylex", Handler: handlers.ZRevRangeByLex, Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zrangestore", Handler: handlers.ZRangeStore, Arity: -5, Flags: FlagWrite, FirstKey: 1, LastKey: 2, KeyStep: 1})
+	registerCommand(Command{Name: "zcount", Handler: handlers.ZCount, Arity: 4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zlexcount", Handler: handlers.ZLexCount, Arity: 4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zremrangebyrank", Handler: handlers.ZRemRangeByRank, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zremrangebyscore", Handler: handlers.ZRemRangeByScore, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zremrangebylex", Handler: handlers.ZRemRangeByLex, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zpopmin", Handler: handlers.ZPopMin, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zpopmax", Handler: handlers.ZPopMax, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "bzpopmin", Handler: handlers.BZPopMin, Arity: -3, Flags: FlagWrite | FlagBlocking, FirstKey: 1, LastKey: -2, KeyStep: 1})
+	registerCommand(Command{Name: "bzpopmax", Handler: handlers.BZPopMax, Arity: -3, Flags: FlagWrite | FlagBlocking, FirstKey: 1, LastKey: -2, KeyStep: 1})
+	registerCommand(Command{Name: "zunion", Handler: handlers.ZUnion, Arity: -3, Flags: FlagReadonly})
+	registerCommand(Command{Name: "zinter", Handler: handlers.ZInter, Arity: -3, Flags: FlagReadonly})
+	registerCommand(Command{Name: "zunionstore", Handler: handlers.ZUnionStore, Arity: -4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zinterstore", Handler: handlers.ZInterStore, Arity: -4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "zscan", Handler: handlers.ZScan, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+
+	registerCommand(Command{Name: "geoadd", Handler: handlers.GeoAdd, Arity: -5, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "geodist", Handler: handlers.GeoDist, Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "geopos", Handler: handlers.GeoPos, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "geohash", Handler: handlers.GeoHash, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "geosearch", Handler: handlers.GeoSearch, Arity: -7, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "geosearchstore", Handler: handlers.GeoSearchStore, Arity: -8, Flags: FlagWrite, FirstKey: 1, LastKey: 2, KeyStep: 1})
+
+	registerCommand(Command{Name: "json.set", Handler: handlers.JSONSet, Arity: -4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "json.get", Handler: handlers.JSONGet, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "json.mget", Handler: handlers.JSONMGet, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: -2, KeyStep: 1})
+	registerCommand(Command{Name: "json.del", Handler: handlers.JSONDel, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "json.forget", Handler: handlers.JSONDel, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "json.numincrby", Handler: handlers.JSONNumIncrBy, Arity: 4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "json.arrappend", Handler: handlers.JSONArrAppend, Arity: -4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "json.objkeys", Handler: handlers.JSONObjKeys, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "json.type", Handler: handlers.JSONType, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+
+	registerCommand(Command{Name: "bf.reserve", Handler: handlers.BFReserve, Arity: -4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "bf.add", Handler: handlers.BFAdd, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "bf.madd", Handler: handlers.BFMAdd, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "bf.exists", Handler: handlers.BFExists, Arity: 3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "bf.mexists", Handler: handlers.BFMExists, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "cf.add", Handler: handlers.CFAdd, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "cf.del", Handler: handlers.CFDel, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "cf.exists", Handler: handlers.CFExists, Arity: 3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+
+	registerCommand(Command{Name: "xadd", Handler: handlers.XAdd, Arity: -5, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "xlen", Handler: handlers.XLen, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "xrange", Handler: handlers.XRange, Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "xrevrange", Handler: handlers.XRevRange, Arity: -4, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "xdel", Handler: handlers.XDel, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "xtrim", Handler: handlers.XTrim, Arity: -4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "xread", Handler: handlers.XRead, Arity: -4, Flags: FlagReadonly | FlagBlocking})
+	registerCommand(Command{Name: "xgroup", Handler: handlers.XGroup, Arity: -2, Flags: FlagWrite, FirstKey: 2, LastKey: 2, KeyStep: 1})
+	registerCommand(Command{Name: "xreadgroup", Handler: handlers.XReadGroup, Arity: -7, Flags: FlagWrite | FlagBlocking})
+	registerCommand(Command{Name: "xack", Handler: handlers.XAck, Arity: -4, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "xpending", Handler: handlers.XPending, Arity: -3, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "xclaim", Handler: handlers.XClaim, Arity: -6, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "xautoclaim", Handler: handlers.XAutoClaim, Arity: -6, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "xinfo", Handler: handlers.XInfo, Arity: -2, Flags: FlagReadonly, FirstKey: 2, LastKey: 2, KeyStep: 1})
+
+	registerCommand(Command{Name: "del", Handler: handlers.Del, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "unlink", Handler: handlers.Unlink, Arity: -2, Flags: FlagWrite, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "exists", Handler: handlers.Exists, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "touch", Handler: handlers.Touch, Arity: -2, Flags: FlagReadonly, FirstKey: 1, LastKey: -1, KeyStep: 1})
+	registerCommand(Command{Name: "type", Handler: handlers.Type, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "rename", Handler: handlers.Rename, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 2, KeyStep: 1})
+	registerCommand(Command{Name: "renamenx", Handler: handlers.RenameNX, Arity: 3, Flags: FlagWrite, FirstKey: 1, LastKey: 2, KeyStep: 1})
+	registerCommand(Command{Name: "copy", Handler: handlers.Copy, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 2, KeyStep: 1})
+
+	registerCommand(Command{Name: "keys", Handler: handlers.Keys, Arity: 2, Flags: FlagReadonly})
+	registerCommand(Command{Name: "scan", Handler: handlers.Scan, Arity: -2, Flags: FlagReadonly})
+
+	registerCommand(Command{Name: "expire", Handler: handlers.Expire, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "pexpire", Handler: handlers.PExpire, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "expireat", Handler: handlers.ExpireAt, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "pexpireat", Handler: handlers.PExpireAt, Arity: -3, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "persist", Handler: handlers.Persist, Arity: 2, Flags: FlagWrite, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "ttl", Handler: handlers.TTL, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "pttl", Handler: handlers.PTTL, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "expiretime", Handler: handlers.ExpireTime, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+	registerCommand(Command{Name: "pexpiretime", Handler: handlers.PExpireTime, Arity: 2, Flags: FlagReadonly, FirstKey: 1, LastKey: 1, KeyStep: 1})
+
+	registerCommand(Command{Name: "replconf", Handler: handlers.ReplConf, Arity: -1, Flags: FlagAdmin | FlagNoScript})
+	registerCommand(Command{Name: "psync", Handler: handlers.Psync, Arity: -3, Flags: FlagAdmin | FlagNoScript})
+}
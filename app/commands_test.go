@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// replyRecorder is a connection that records the replies written to it
+type replyRecorder struct {
+	net.Conn
+	replies bytes.Buffer
+}
+
+func (r *replyRecorder) Write(b []byte) (int, error) {
+	return r.replies.Write(b)
+}
+
+func newServerState(role string) *types.ServerState {
+	return &types.ServerState{
+		DB:           types.NewDict[types.DBItem](),
+		Expires:      map[string]struct{}{},
+		FieldExpires: map[string]struct{}{},
+		Blocked:      map[string][]*types.BlockedClient{},
+		Role:         role,
+	}
+}
+
+// dispatch runs a command through the dispatcher, as received from a client, and
+// returns the reply it wrote
+func dispatch(t *testing.T, server *types.ServerState, args ...string) string {
+	t.Helper()
+	conn := &replyRecorder{}
+	argv := make([][]byte, len(args))
+	for i, arg := range args {
+		argv[i] = []byte(arg)
+	}
+	handleCommand(argv, nil, types.NewClient(conn), server)
+	return conn.replies.String()
+}
+
+func TestCommandKeys(t *testing.T) {
+	tests := []struct {
+		argv     []string
+		expected []string
+	}{
+		{argv: []string{"GET", "k"}, expected: []string{"k"}},
+		{argv: []string{"MGET", "a", "b", "c"}, expected: []string{"a", "b", "c"}},
+		{argv: []string{"MSET", "a", "1", "b", "2"}, expected: []string{"a", "b"}},
+		{argv: []string{"BLPOP", "a", "b", "0"}, expected: []string{"a", "b"}},
+		{argv: []string{"BITOP", "AND", "dest", "a", "b"}, expected: []string{"dest", "a", "b"}},
+		{argv: []string{"PING"}, expected: nil},
+	}
+
+	for _, tc := range tests {
+		cmd, _ := lookupCommand(tc.argv[0])
+		argv := make([][]byte, len(tc.argv))
+		for i, arg := range tc.argv {
+			argv[i] = []byte(arg)
+		}
+		if got := cmd.Keys(argv); !reflect.DeepEqual(got, tc.expected) {
+			t.Fatalf("Expected the keys of %v to be %v, got %v", tc.argv, tc.expected, got)
+		}
+	}
+}
+
+func TestCommandGetKeys(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		args         []string
+		expected     string
+	}{
+		{testCaseName: "Single key", args: []string{"COMMAND", "GETKEYS", "SET", "k", "v"}, expected: "*1\r\n$1\r\nk\r\n"},
+		{testCaseName: "Keys with a step", args: []string{"COMMAND", "GETKEYS", "MSET", "a", "1", "b", "2"}, expected: "*2\r\n$1\r\na\r\n$1\r\nb\r\n"},
+		{testCaseName: "Keys before a timeout", args: []string{"command", "getkeys", "brpop", "a", "b", "1"}, expected: "*2\r\n$1\r\na\r\n$1\r\nb\r\n"},
+		{testCaseName: "No keys", args: []string{"COMMAND", "GETKEYS", "PING"}, expected: "-ERR The command has no key arguments\r\n"},
+		{testCaseName: "Unknown command", args: []string{"COMMAND", "GETKEYS", "NOPE", "k"}, expected: "-ERR Invalid command specified\r\n"},
+		{testCaseName: "Wrong arity", args: []string{"COMMAND", "GETKEYS", "GET", "a", "b"}, expected: "-ERR Invalid number of arguments specified for command\r\n"},
+	}
+
+	server := newServerState("master")
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			if reply := dispatch(t, server, tc.args...); reply != tc.expected {
+				t.Fatalf("Expected %q, got %q", tc.expected, reply)
+			}
+		})
+	}
+}
+
+func TestCommandInfo(t *testing.T) {
+	server := newServerState("master")
+	expected := "*2\r\n" +
+		"*10\r\n$5\r\nblpop\r\n:-3\r\n*2\r\n+write\r\n+blocking\r\n:1\r\n:-2\r\n:1\r\n*0\r\n*0\r\n*0\r\n*0\r\n" +
+		"*-1\r\n"
+	if reply := dispatch(t, server, "COMMAND", "INFO", "BLPOP", "nope"); reply != expected {
+		t.Fatalf("Expected %q, got %q", expected, reply)
+	}
+
+	expected = "*1\r\n*10\r\n$5\r\npsync\r\n:-3\r\n*2\r\n+admin\r\n+noscript\r\n:0\r\n:0\r\n:0\r\n*0\r\n*0\r\n*0\r\n*0\r\n"
+	if reply := dispatch(t, server, "COMMAND", "INFO", "psync"); reply != expected {
+		t.Fatalf("Expected %q, got %q", expected, reply)
+	}
+}
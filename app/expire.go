@@ -0,0 +1,108 @@
+package main
+
+import (
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// The active expire cycle follows the one in Redis: every cycle samples keys that
+// have an expiry and deletes the expired ones, and keeps sampling as long as a
+// large share of the sample turned out to be expired, within a time budget.
+const (
+	activeExpireCycleInterval   = 100 * time.Millisecond
+	activeExpireCycleBudget     = 25 * time.Millisecond // Longest a single cycle may hold DBMutex
+	activeExpireCycleSampleSize = 20                    // Keys looked at per iteration
+	activeExpireAcceptableStale = 10                    // Percentage of expired keys in a sample that ends the cycle
+)
+
+// startActiveExpire runs the active expire cycle in the background. Only the
+// master expires keys, replicas receive the deletions through replication.
+func startActiveExpire(state *types.ServerState) {
+	go func() {
+		ticker := time.NewTicker(activeExpireCycleInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if state.Role != "master" {
+				continue
+			}
+			activeExpireCycle(state)
+			activeExpireFields(state)
+		}
+	}()
+}
+
+// activeExpireCycle deletes expired keys and returns how many were deleted
+func activeExpireCycle(state *types.ServerState) int {
+	state.DBMutex.Lock()
+	defer state.DBMutex.Unlock()
+
+	return expireKeys(state, time.Now)
+}
+
+// expireKeys runs the body of an active expire cycle, reading the time from clock.
+// It must be called with DBMutex held.
+func expireKeys(state *types.ServerState, clock func() time.Time) int {
+	start := clock()
+	deleted := 0
+	for {
+		now := clock().UnixMilli()
+		sampled, expired := 0, 0
+
+		// Map iteration starts at a random position, which makes this a random sample
+		for key := range state.Expires {
+			if sampled == activeExpireCycleSampleSize {
+				break
+			}
+			sampled++
+
+			item, ok := state.DB.Get(key)
+			switch {
+			case !ok || item.Expiry == -1:
+				// The key was deleted or persisted since it was tracked
+				delete(state.Expires, key)
+			case item.IsExpired(now):
+				state.DeleteItem(key)
+				state.Propagate("DEL", key)
+				expired++
+			}
+		}
+		deleted += expired
+
+		if sampled == 0 || expired*100 <= sampled*activeExpireAcceptableStale {
+			break
+		}
+		if clock().Sub(start) > activeExpireCycleBudget {
+			break
+		}
+	}
+
+	return deleted
+}
+
+// activeExpireFields deletes the expired fields of a sample of the hashes that
+// have fields with an expiry, and returns how many hashes it looked at
+func activeExpireFields(state *types.ServerState) int {
+	state.DBMutex.Lock()
+	defer state.DBMutex.Unlock()
+
+	sampled := 0
+	for key := range state.FieldExpires {
+		if sampled == activeExpireCycleSampleSize {
+			break
+		}
+		sampled++
+
+		item, ok := state.DB.Get(key)
+		hash, isHash := item.Object.(*types.Hash)
+		if !ok || !isHash || !hash.HasExpiringFields() {
+			// The key was deleted or overwritten, or its fields persisted, since it was tracked
+			delete(state.FieldExpires, key)
+			continue
+		}
+		state.ExpireHashFields(key, hash)
+	}
+
+	return sampled
+}
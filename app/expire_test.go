@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+var expireTestNow = time.UnixMilli(1_700_000_000_000)
+
+// fixedClock always reads the same time
+func fixedClock() time.Time {
+	return expireTestNow
+}
+
+// addKeys stores n keys named prefix0, prefix1... expiring at expiry
+func addKeys(state *types.ServerState, prefix string, n int, expiry int64) {
+	for i := range n {
+		state.SetItem(prefix+strconv.Itoa(i), types.DBItem{Value: []byte("v"), Expiry: expiry})
+	}
+}
+
+func TestExpireKeysDeletesExpiredKeys(t *testing.T) {
+	state := newServerState("master")
+	addKeys(state, "expired", 5, expireTestNow.UnixMilli())
+	addKeys(state, "live", 5, expireTestNow.UnixMilli()+1)
+	addKeys(state, "persistent", 5, -1)
+	// A key that was persisted since it was tracked is no longer sampled
+	state.SetItem("persisted", types.DBItem{Value: []byte("v"), Expiry: -1})
+	state.Expires["persisted"] = struct{}{}
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	peer.SetReadDeadline(time.Now().Add(time.Second))
+	state.Replicas = append(state.Replicas, types.NewReplica(conn, state))
+
+	// Every key with an expiry fits in a single sample
+	if deleted := expireKeys(state, fixedClock); deleted != 5 {
+		t.Fatalf("Expected 5 keys to be deleted, got %d", deleted)
+	}
+	if state.DB.Len() != 11 || len(state.Expires) != 5 {
+		t.Fatalf("Expected 11 keys to be left, 5 of them with an expiry, got %d and %d", state.DB.Len(), len(state.Expires))
+	}
+	for i := range 5 {
+		if _, ok := state.DB.Get("expired" + strconv.Itoa(i)); ok {
+			t.Fatalf("Expected expired%d to be deleted", i)
+		}
+	}
+
+	// The deletions are propagated to replicas
+	reader := resp.NewReader(peer)
+	propagated := []string{}
+	for range 5 {
+		args, _, err := reader.ReadCommand()
+		if err != nil || len(args) != 2 || string(args[0]) != "DEL" {
+			t.Fatalf("Expected DEL to be propagated, got %q (%v)", args, err)
+		}
+		propagated = append(propagated, string(args[1]))
+	}
+	sort.Strings(propagated)
+	if expected := []string{"expired0", "expired1", "expired2", "expired3", "expired4"}; !reflect.DeepEqual(propagated, expected) {
+		t.Fatalf("Expected DEL of %v to be propagated, got %v", expected, propagated)
+	}
+}
+
+func TestExpireKeysRepeatsWhileStale(t *testing.T) {
+	// Each sample is entirely expired, so the cycle goes on until none is left
+	state := newServerState("master")
+	addKeys(state, "expired", 1000, expireTestNow.UnixMilli()-1)
+	if deleted := expireKeys(state, fixedClock); deleted != 1000 || state.DB.Len() != 0 {
+		t.Fatalf("Expected all 1000 keys to be deleted, got %d", deleted)
+	}
+
+	// With at most 10% of a sample expired, the cycle stops after the first one
+	state = newServerState("master")
+	addKeys(state, "expired", 1, expireTestNow.UnixMilli()-1)
+	addKeys(state, "live", 1000, expireTestNow.UnixMilli()+1000)
+	if deleted := expireKeys(state, fixedClock); deleted > 1 || state.DB.Len() < 1000 {
+		t.Fatalf("Expected at most one sample to be looked at, got %d keys deleted", deleted)
+	}
+}
+
+func TestExpireKeysBudget(t *testing.T) {
+	state := newServerState("master")
+	addKeys(state, "expired", 1000, expireTestNow.UnixMilli()-1)
+
+	// Each read of the clock moves it forward, so the budget runs out after a few samples
+	now := expireTestNow
+	clock := func() time.Time {
+		now = now.Add(10 * time.Millisecond)
+		return now
+	}
+	deleted := expireKeys(state, clock)
+	if deleted == 0 || deleted >= 1000 || deleted%activeExpireCycleSampleSize != 0 {
+		t.Fatalf("Expected the cycle to stop after a few whole samples, got %d keys deleted", deleted)
+	}
+	if now.Sub(expireTestNow) > 10*activeExpireCycleBudget {
+		t.Fatalf("Expected the cycle to stop soon after its budget, ran for %v", now.Sub(expireTestNow))
+	}
+}
@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+const errBitOffset = "ERR bit offset is not an integer or out of range"
+
+// maxBitOffset is the highest bit offset, the last bit of the largest string
+const maxBitOffset = maxStringLen*8 - 1
+
+// parseBitOffset parses the offset of a bit, which must be within the largest string
+func parseBitOffset(arg string) (uint64, string) {
+	offset, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || offset < 0 || offset > maxBitOffset {
+		return 0, errBitOffset
+	}
+	return uint64(offset), ""
+}
+
+// SetBit sets or clears the bit at offset, growing the string as needed, and replies
+// with the previous value of the bit
+func SetBit(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	offset, errMsg := parseBitOffset(string(args[1]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if string(args[2]) != "0" && string(args[2]) != "1" {
+		writeError(client, "ERR bit is not an integer or out of range")
+		return
+	}
+	bit := int(args[2][0] - '0')
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, exists, errMsg := lookupString(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		item.Expiry = -1
+	}
+
+	var old int
+	item.Value, old = types.SetBit(item.Value, offset, bit)
+	server.SetItem(key, item)
+
+	server.PropagateArgs("SETBIT", args)
+	writeInteger(client, int64(old))
+}
+
+func GetBit(client *types.Client, server *types.ServerState, args [][]byte) {
+	offset, errMsg := parseBitOffset(string(args[1]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, _, errMsg := lookupString(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	writeInteger(client, int64(types.GetBit(item.Value, offset)))
+}
+
+// bitRange is the range of a string BITCOUNT and BITPOS look into, in bytes or, with
+// the BIT option, in bits. Negative indexes count from the end.
+type bitRange struct {
+	start, end int64
+	hasEnd     bool
+	bits       bool // The range is in bits rather than bytes
+}
+
+// parseBitRange parses the optional [start [end [BYTE|BIT]]] arguments. BITCOUNT,
+// unlike BITPOS, requires an end along with a start.
+func parseBitRange(args [][]byte, endRequired bool) (bitRange, string) {
+	r := bitRange{start: 0, end: -1}
+	if len(args) == 0 {
+		return r, ""
+	}
+	if len(args) > 3 || (endRequired && len(args) == 1) {
+		return r, errSyntax
+	}
+
+	var err error
+	if r.start, err = strconv.ParseInt(string(args[0]), 10, 64); err != nil {
+		return r, errNotInt
+	}
+	if len(args) > 1 {
+		if r.end, err = strconv.ParseInt(string(args[1]), 10, 64); err != nil {
+			return r, errNotInt
+		}
+		r.hasEnd = true
+	}
+	if len(args) > 2 {
+		switch strings.ToUpper(string(args[2])) {
+		case "BYTE":
+		case "BIT":
+			r.bits = true
+		default:
+			return r, errSyntax
+		}
+	}
+	return r, ""
+}
+
+// bitOffsets returns the offsets of the first and last bits of the range within b.
+// It returns false if the range is empty.
+func (r bitRange) bitOffsets(b []byte) (uint64, uint64, bool) {
+	length := int64(len(b))
+	if r.bits {
+		length *= 8
+	}
+	start, end, ok := normalizeRange(r.start, r.end, length)
+	if !ok {
+		return 0, 0, false
+	}
+	if r.bits {
+		return uint64(start), uint64(end), true
+	}
+	return uint64(start) * 8, uint64(end)*8 + 7, true
+}
+
+// BitCount replies with the number of bits set in a string, or in a range of it
+func BitCount(client *types.Client, server *types.ServerState, args [][]byte) {
+	r, errMsg := parseBitRange(args[1:], true)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, _, errMsg := lookupString(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	start, end, ok := r.bitOffsets(item.Value)
+	if !ok {
+		writeInteger(client, 0)
+		return
+	}
+	writeInteger(client, types.CountBits(item.Value, start, end))
+}
+
+// BitPos replies with the offset of the first bit set or cleared in a string, or in
+// a range of it. Without an explicit end, the string is treated as padded with zero
+// bits, so looking for a cleared bit in a string of set bits finds the one past it.
+func BitPos(client *types.Client, server *types.ServerState, args [][]byte) {
+	if string(args[1]) != "0" && string(args[1]) != "1" {
+		writeError(client, "ERR The bit argument must be 1 or 0.")
+		return
+	}
+	bit := int(args[1][0] - '0')
+	r, errMsg := parseBitRange(args[2:], false)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, exists, errMsg := lookupString(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, int64(bit)-1)
+		return
+	}
+
+	start, end, ok := r.bitOffsets(item.Value)
+	if !ok {
+		writeInteger(client, -1)
+		return
+	}
+	pos := types.FindBit(item.Value, bit, start, end)
+	if pos == -1 && bit == 0 && !r.hasEnd {
+		pos = int64(end) + 1
+	}
+	writeInteger(client, pos)
+}
+
+// BitOp stores the result of AND, OR, XOR or NOT over strings at the destination,
+// and replies with its length. Missing keys count as empty strings, and an empty
+// result deletes the destination.
+func BitOp(client *types.Client, server *types.ServerState, args [][]byte) {
+	op, dest, keys := strings.ToUpper(string(args[0])), string(args[1]), args[2:]
+	switch op {
+	case "AND", "OR", "XOR":
+	case "NOT":
+		if len(keys) != 1 {
+			writeError(client, "ERR BITOP NOT must be called with a single source key.")
+			return
+		}
+	default:
+		writeError(client, errSyntax)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	srcs := make([][]byte, len(keys))
+	for i, key := range keys {
+		item, _, errMsg := lookupString(server, string(key))
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		srcs[i] = item.Value
+	}
+
+	res := types.BitOp(op, srcs)
+	if len(res) == 0 {
+		if checkIfKeyExists(dest, server) {
+			server.DeleteItem(dest)
+			server.Propagate("DEL", dest)
+		}
+		writeInteger(client, 0)
+		return
+	}
+	setKey(server, dest, res, -1)
+
+	server.PropagateArgs("BITOP", args)
+	writeInteger(client, int64(len(res)))
+}
+
+// bitfieldOp is an operation of BITFIELD
+type bitfieldOp struct {
+	name     string // GET, SET or INCRBY
+	typ      types.BitfieldType
+	offset   uint64
+	value    int64 // Value to set or increment to add
+	overflow types.Overflow
+}
+
+// parseBitfieldType parses a type such as i16 or u8
+func parseBitfieldType(arg string) (types.BitfieldType, bool) {
+	if len(arg) < 2 || (arg[0] != 'i' && arg[0] != 'I' && arg[0] != 'u' && arg[0] != 'U') {
+		return types.BitfieldType{}, false
+	}
+	t := types.BitfieldType{Signed: arg[0] == 'i' || arg[0] == 'I'}
+	n, err := strconv.ParseUint(arg[1:], 10, 8)
+	if err != nil || n < 1 || (t.Signed && n > 64) || (!t.Signed && n > 63) {
+		return types.BitfieldType{}, false
+	}
+	t.Bits = uint(n)
+	return t, true
+}
+
+// parseBitfieldOffset parses the offset of a field, either in bits or, prefixed with
+// #, in multiples of the width of the type
+func parseBitfieldOffset(arg string, t types.BitfieldType) (uint64, string) {
+	multiply := strings.HasPrefix(arg, "#")
+	offset, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, errBitOffset
+	}
+	if multiply {
+		if offset > maxBitOffset/int64(t.Bits) {
+			return 0, errBitOffset
+		}
+		offset *= int64(t.Bits)
+	}
+	// Compared without adding, which would overflow for offsets near the maximum
+	if offset > maxBitOffset-int64(t.Bits)+1 {
+		return 0, errBitOffset
+	}
+	return uint64(offset), ""
+}
+
+// parseBitfield parses the operations of BITFIELD, or of BITFIELD_RO if readOnly is
+// set. It also reports whether any operation writes.
+func parseBitfield(args [][]byte, readOnly bool) ([]bitfieldOp, bool, string) {
+	ops := []bitfieldOp{}
+	overflow, writes := types.OverflowWrap, false
+	for i := 0; i < len(args); i++ {
+		name := strings.ToUpper(string(args[i]))
+		if readOnly && name != "GET" {
+			return nil, false, "ERR BITFIELD_RO only supports the GET subcommand"
+		}
+
+		switch {
+		case name == "OVERFLOW" && i+1 < len(args):
+			i++
+			switch strings.ToUpper(string(args[i])) {
+			case "WRAP":
+				overflow = types.OverflowWrap
+			case "SAT":
+				overflow = types.OverflowSat
+			case "FAIL":
+				overflow = types.OverflowFail
+			default:
+				return nil, false, "ERR Invalid OVERFLOW type specified"
+			}
+			continue
+		case name == "GET" && i+2 < len(args):
+		case (name == "SET" || name == "INCRBY") && i+3 < len(args):
+			writes = true
+		default:
+			return nil, false, errSyntax
+		}
+
+		t, ok := parseBitfieldType(string(args[i+1]))
+		if !ok {
+			return nil, false, "ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is."
+		}
+		offset, errMsg := parseBitfieldOffset(string(args[i+2]), t)
+		if errMsg != "" {
+			return nil, false, errMsg
+		}
+		op := bitfieldOp{name: name, typ: t, offset: offset, overflow: overflow}
+		i += 2
+		if name != "GET" {
+			i++
+			v, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil {
+				return nil, false, errNotInt
+			}
+			op.value = v
+		}
+		ops = append(ops, op)
+	}
+	return ops, writes, ""
+}
+
+// BitField gets, sets and increments integers of arbitrary width at arbitrary bit
+// offsets of a string, and replies with an array holding the result of each operation
+func BitField(client *types.Client, server *types.ServerState, args [][]byte) {
+	bitfield(client, server, "BITFIELD", args)
+}
+
+// BitFieldRO is the read-only variant of BITFIELD, which only supports GET
+func BitFieldRO(client *types.Client, server *types.ServerState, args [][]byte) {
+	bitfield(client, server, "BITFIELD_RO", args)
+}
+
+func bitfield(client *types.Client, server *types.ServerState, command string, args [][]byte) {
+	key := string(args[0])
+	ops, writes, errMsg := parseBitfield(args[1:], command == "BITFIELD_RO")
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, exists, errMsg := lookupString(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		item.Expiry = -1
+	}
+
+	results := make([]resp.Value, len(ops))
+	changed := false
+	for i, op := range ops {
+		old := op.typ.Get(item.Value, op.offset)
+		if op.name == "GET" {
+			results[i] = resp.Integer(old)
+			continue
+		}
+
+		var v int64
+		var ok bool
+		if op.name == "SET" {
+			v, ok = op.typ.Fit(op.value, op.overflow)
+		} else {
+			v, ok = op.typ.Add(old, op.value, op.overflow)
+		}
+		if !ok {
+			results[i] = resp.NullBulkString()
+			continue
+		}
+		item.Value = op.typ.Set(item.Value, op.offset, v)
+		changed = true
+
+		// SET replies with the previous value, INCRBY with the new one
+		if op.name == "SET" {
+			results[i] = resp.Integer(old)
+		} else {
+			results[i] = resp.Integer(v)
+		}
+	}
+
+	if writes && changed {
+		server.SetItem(key, item)
+		server.PropagateArgs(command, args)
+	}
+	writeValue(client, resp.Array(results...))
+}
@@ -0,0 +1,32 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/handlers"
+)
+
+func TestBitFieldOffsets(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		args         []string
+		expected     string
+	}{
+		{testCaseName: "Last field of the largest string", args: []string{"k", "GET", "u8", "4294967288"}, expected: "*1\r\n:0\r\n"},
+		{testCaseName: "Field past the largest string", args: []string{"k", "GET", "u8", "4294967289"}, expected: "-ERR bit offset is not an integer or out of range\r\n"},
+		{testCaseName: "Last multiple of the width", args: []string{"k", "GET", "i64", "#67108863"}, expected: "*1\r\n:0\r\n"},
+		{testCaseName: "Multiple of the width past the largest string", args: []string{"k", "GET", "i64", "#67108864"}, expected: "-ERR bit offset is not an integer or out of range\r\n"},
+		{testCaseName: "Offset that would overflow", args: []string{"k", "SET", "i64", "9223372036854775807", "1"}, expected: "-ERR bit offset is not an integer or out of range\r\n"},
+		{testCaseName: "Multiple that would overflow", args: []string{"k", "SET", "i64", "#9223372036854775807", "1"}, expected: "-ERR bit offset is not an integer or out of range\r\n"},
+		{testCaseName: "Negative offset", args: []string{"k", "GET", "u8", "-1"}, expected: "-ERR bit offset is not an integer or out of range\r\n"},
+	}
+
+	server := newServerState()
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			if reply := run(t, server, handlers.BitField, tc.args...); reply != tc.expected {
+				t.Fatalf("Expected %q, got %q", tc.expected, reply)
+			}
+		})
+	}
+}
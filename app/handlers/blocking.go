@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+// parseTimeout parses the timeout of a blocking command, given in seconds. A
+// timeout of 0 blocks forever.
+func parseTimeout(arg string) (time.Duration, string) {
+	seconds, err := strconv.ParseFloat(arg, 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return 0, "ERR timeout is not a float or out of range"
+	}
+	if seconds < 0 {
+		return 0, "ERR timeout is negative"
+	}
+	return time.Duration(min(seconds, float64(math.MaxInt64/time.Second)) * float64(time.Second)), ""
+}
+
+// blockClient parks the client until serve completes the command, the timeout
+// expires or the client goes away. serve is called with DBMutex held whenever one
+// of keys may be ready, and returns the reply once it completed the command.
+// blockClient must be called with DBMutex held, which it releases while waiting.
+// It returns false if the command did not complete.
+func blockClient(client *types.Client, server *types.ServerState, keys []string, timeout time.Duration, serve func(key string) (resp.Value, bool)) (resp.Value, bool) {
+	var reply resp.Value
+	bc := types.NewBlockedClient(keys, func(key string) bool {
+		value, ok := serve(key)
+		if ok {
+			reply = value
+		}
+		return ok
+	})
+
+	server.Block(bc)
+	server.DBMutex.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case <-bc.Done:
+	case <-expired:
+	case <-client.Closed():
+	}
+
+	server.DBMutex.Lock()
+	select {
+	case <-bc.Done:
+		// Served, possibly while the timeout expired
+		return reply, true
+	default:
+		server.Unblock(bc)
+		return resp.Value{}, false
+	}
+}
+
+func BLPop(client *types.Client, server *types.ServerState, args [][]byte) {
+	blockingPop(client, server, args, true)
+}
+
+func BRPop(client *types.Client, server *types.ServerState, args [][]byte) {
+	blockingPop(client, server, args, false)
+}
+
+// blockingPop pops an element from the first non-empty list among the keys, or
+// blocks until one of them is pushed to. The pop is propagated as LPOP or RPOP.
+func blockingPop(client *types.Client, server *types.ServerState, args [][]byte, left bool) {
+	keys := argStrings(args[:len(args)-1])
+	timeout, errMsg := parseTimeout(string(args[len(args)-1]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	key, elems, errMsg := lmpop(server, keys, left, 1)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if elems != nil {
+		writeValue(client, resp.Array(resp.BulkString(key), resp.BulkBytes(elems[0])))
+		return
+	}
+
+	reply, ok := blockClient(client, server, keys, timeout, func(key string) (resp.Value, bool) {
+		_, elems, errMsg := lmpop(server, []string{key}, left, 1)
+		if errMsg != "" || elems == nil {
+			return resp.Value{}, false
+		}
+		return resp.Array(resp.BulkString(key), resp.BulkBytes(elems[0])), true
+	})
+	if !ok {
+		writeValue(client, resp.NullArray())
+		return
+	}
+	writeValue(client, reply)
+}
+
+// BLMove is LMOVE, blocking until the source list is pushed to if it is empty
+func BLMove(client *types.Client, server *types.ServerState, args [][]byte) {
+	from, ok1 := parseListDirection(string(args[2]))
+	to, ok2 := parseListDirection(string(args[3]))
+	if !ok1 || !ok2 {
+		writeError(client, errSyntax)
+		return
+	}
+	blockingMove(client, server, string(args[0]), string(args[1]), from, to, string(args[4]))
+}
+
+// BRPopLPush is BLMOVE source destination RIGHT LEFT timeout
+func BRPopLPush(client *types.Client, server *types.ServerState, args [][]byte) {
+	blockingMove(client, server, string(args[0]), string(args[1]), false, true, string(args[2]))
+}
+
+func blockingMove(client *types.Client, server *types.ServerState, source, destination string, from, to bool, timeoutArg string) {
+	timeout, errMsg := parseTimeout(timeoutArg)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	// tryMove moves an element if the source list is not empty, and is propagated as LMOVE
+	tryMove := func() (resp.Value, bool, string) {
+		src, exists, errMsg := lookupObject[*types.List](server, source)
+		if errMsg != "" {
+			return resp.Value{}, false, errMsg
+		}
+		if _, _, errMsg := lookupObject[*types.List](server, destination); errMsg != "" {
+			return resp.Value{}, false, errMsg
+		}
+		if !exists {
+			return resp.Value{}, false, ""
+		}
+
+		elem := moveElement(server, source, src, destination, from, to)
+		server.Propagate("LMOVE", source, destination, listDirectionName(from), listDirectionName(to))
+		return resp.BulkBytes(elem), true, ""
+	}
+
+	reply, ok, errMsg := tryMove()
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if ok {
+		writeValue(client, reply)
+		return
+	}
+
+	reply, ok = blockClient(client, server, []string{source}, timeout, func(string) (resp.Value, bool) {
+		reply, ok, _ := tryMove()
+		return reply, ok
+	})
+	if !ok {
+		writeValue(client, resp.NullArray())
+		return
+	}
+	writeValue(client, reply)
+}
+
+// BLMPop is LMPOP, blocking until one of the lists is pushed to if they are all empty
+func BLMPop(client *types.Client, server *types.ServerState, args [][]byte) {
+	timeout, errMsg := parseTimeout(string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	keys, left, count, errMsg := parseLMPopArgs(args[1:])
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	key, elems, errMsg := lmpop(server, keys, left, count)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if elems != nil {
+		writeValue(client, resp.Array(resp.BulkString(key), bulkArray(elems)))
+		return
+	}
+
+	reply, ok := blockClient(client, server, keys, timeout, func(key string) (resp.Value, bool) {
+		_, elems, errMsg := lmpop(server, []string{key}, left, count)
+		if errMsg != "" || elems == nil {
+			return resp.Value{}, false
+		}
+		return resp.Array(resp.BulkString(key), bulkArray(elems)), true
+	})
+	if !ok {
+		writeValue(client, resp.NullArray())
+		return
+	}
+	writeValue(client, reply)
+}
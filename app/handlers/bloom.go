@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+// Filters are deterministic, so commands that change them are propagated verbatim
+
+// BFReserve creates an empty Bloom filter for capacity items at the given error
+// rate, which grows EXPANSION times larger when full unless NONSCALING is given
+func BFReserve(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	errorRate, err := strconv.ParseFloat(string(args[1]), 64)
+	if err != nil {
+		writeError(client, "ERR bad error rate")
+		return
+	}
+	if errorRate <= 0 || errorRate >= 1 {
+		writeError(client, "ERR (0 < error rate range < 1)")
+		return
+	}
+	capacity, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		writeError(client, "ERR bad capacity")
+		return
+	}
+	if capacity <= 0 {
+		writeError(client, "ERR (capacity should be larger than 0)")
+		return
+	}
+
+	expansion, nonScaling, expansionSet := int64(types.BloomDefaultExpansion), false, false
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "NONSCALING":
+			nonScaling = true
+		case "EXPANSION":
+			if i+1 >= len(args) {
+				writeError(client, errSyntax)
+				return
+			}
+			i++
+			expansion, err = strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil || expansion < 1 {
+				writeError(client, "ERR bad expansion")
+				return
+			}
+			expansionSet = true
+		default:
+			writeError(client, errSyntax)
+			return
+		}
+	}
+	if nonScaling {
+		if expansionSet {
+			writeError(client, "ERR Nonscaling filters cannot expand")
+			return
+		}
+		expansion = 0
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	if checkIfKeyExists(key, server) {
+		writeError(client, "ERR item exists")
+		return
+	}
+	filter := types.NewBloomFilter(errorRate, uint64(capacity), uint64(expansion))
+	server.SetItem(key, types.DBItem{Object: filter, Expiry: -1})
+
+	server.PropagateArgs("BF.RESERVE", args)
+	writeOK(client)
+}
+
+// bloomAdd adds items to the Bloom filter at key, creating it with the default
+// settings if needed, and returns the reply for each of them
+func bloomAdd(client *types.Client, server *types.ServerState, command string, args [][]byte) ([]resp.Value, bool) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	filter, exists, errMsg := lookupObject[*types.BloomFilter](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return nil, false
+	}
+	if !exists {
+		filter = types.NewBloomFilter(types.BloomDefaultErrorRate, types.BloomDefaultCapacity, types.BloomDefaultExpansion)
+		server.SetItem(key, types.DBItem{Object: filter, Expiry: -1})
+	}
+
+	replies := []resp.Value{}
+	changed := !exists
+	for _, item := range args[1:] {
+		added, err := filter.Add(item)
+		switch {
+		case err != nil:
+			replies = append(replies, resp.Error("ERR "+err.Error()))
+		case added:
+			replies = append(replies, resp.Integer(1))
+			changed = true
+		default:
+			replies = append(replies, resp.Integer(0))
+		}
+	}
+	if changed {
+		server.PropagateArgs(command, args)
+	}
+	return replies, true
+}
+
+// BFAdd adds an item to a Bloom filter, and replies with 1 if it was not in it yet
+func BFAdd(client *types.Client, server *types.ServerState, args [][]byte) {
+	if replies, ok := bloomAdd(client, server, "BF.ADD", args); ok {
+		writeValue(client, replies[0])
+	}
+}
+
+// BFMAdd adds items to a Bloom filter, and replies with 1 for each that was not in
+// it yet
+func BFMAdd(client *types.Client, server *types.ServerState, args [][]byte) {
+	if replies, ok := bloomAdd(client, server, "BF.MADD", args); ok {
+		writeValue(client, resp.Array(replies...))
+	}
+}
+
+// bloomExists returns whether each item may be in the Bloom filter at key
+func bloomExists(client *types.Client, server *types.ServerState, args [][]byte) ([]resp.Value, bool) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	filter, exists, errMsg := lookupObject[*types.BloomFilter](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return nil, false
+	}
+
+	replies := []resp.Value{}
+	for _, item := range args[1:] {
+		if exists && filter.Exists(item) {
+			replies = append(replies, resp.Integer(1))
+		} else {
+			replies = append(replies, resp.Integer(0))
+		}
+	}
+	return replies, true
+}
+
+// BFExists replies with 1 if an item may be in a Bloom filter, and 0 if it is not
+func BFExists(client *types.Client, server *types.ServerState, args [][]byte) {
+	if replies, ok := bloomExists(client, server, args); ok {
+		writeValue(client, replies[0])
+	}
+}
+
+// BFMExists replies with 1 for each item that may be in a Bloom filter, and 0 for
+// those that are not
+func BFMExists(client *types.Client, server *types.ServerState, args [][]byte) {
+	if replies, ok := bloomExists(client, server, args); ok {
+		writeValue(client, resp.Array(replies...))
+	}
+}
+
+// CFAdd adds an item to a cuckoo filter, creating it with the default capacity if
+// needed. The item is added even if it may be in the filter already.
+func CFAdd(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	filter, exists, errMsg := lookupObject[*types.CuckooFilter](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		filter = types.NewCuckooFilter(types.CuckooDefaultCapacity)
+		server.SetItem(key, types.DBItem{Object: filter, Expiry: -1})
+	}
+	filter.Add(args[1])
+
+	server.PropagateArgs("CF.ADD", args)
+	writeInteger(client, 1)
+}
+
+// CFDel removes an item from a cuckoo filter, and replies with 1 if it was in it
+func CFDel(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	filter, exists, errMsg := lookupObject[*types.CuckooFilter](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeError(client, "ERR Not found")
+		return
+	}
+	if !filter.Delete(args[1]) {
+		writeInteger(client, 0)
+		return
+	}
+
+	server.PropagateArgs("CF.DEL", args)
+	writeInteger(client, 1)
+}
+
+// CFExists replies with 1 if an item may be in a cuckoo filter, and 0 if it is not
+func CFExists(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	filter, exists, errMsg := lookupObject[*types.CuckooFilter](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if exists && filter.Exists(args[1]) {
+		writeInteger(client, 1)
+		return
+	}
+	writeInteger(client, 0)
+}
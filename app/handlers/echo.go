@@ -1,17 +1,10 @@
 package handlers
 
 import (
-	"fmt"
-	"net"
-
+	"github.com/codecrafters-io/redis-starter-go/app/types"
 	"github.com/codecrafters-io/redis-starter-go/resp"
 )
 
-func Echo(con net.Conn, message string) {
-	res,err := resp.RESPHandler{}.BulkString.Encode(message)
-	if err != nil {
-		fmt.Println("Error encoding response: ", err)
-		return
-	}
-	con.Write(res)
-}
\ No newline at end of file
+func Echo(client *types.Client, server *types.ServerState, args [][]byte) {
+	writeValue(client, resp.BulkBytes(args[0]))
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func Expire(client *types.Client, server *types.ServerState, args [][]byte) {
+	expire(client, server, "expire", 1000, false, args)
+}
+
+func PExpire(client *types.Client, server *types.ServerState, args [][]byte) {
+	expire(client, server, "pexpire", 1, false, args)
+}
+
+func ExpireAt(client *types.Client, server *types.ServerState, args [][]byte) {
+	expire(client, server, "expireat", 1000, true, args)
+}
+
+func PExpireAt(client *types.Client, server *types.ServerState, args [][]byte) {
+	expire(client, server, "pexpireat", 1, true, args)
+}
+
+// expire handles the EXPIRE family: key time [NX | XX | GT | LT], where time is
+// multiplied by unit to get milliseconds and is relative to now unless absolute is set
+func expire(client *types.Client, server *types.ServerState, command string, unit int64, absolute bool, args [][]byte) {
+	key := string(args[0])
+	n, err := strconv.ParseInt(string(args[1]), 10, 64)
+	if err != nil {
+		writeError(client, errNotInt)
+		return
+	}
+
+	var nx, xx, gt, lt bool
+	for _, option := range args[2:] {
+		switch strings.ToUpper(string(option)) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GT":
+			gt = true
+		case "LT":
+			lt = true
+		default:
+			writeError(client, "ERR Unsupported option "+string(option))
+			return
+		}
+	}
+	if nx && (xx || gt || lt) {
+		writeError(client, "ERR NX and XX, GT or LT options at the same time are not compatible")
+		return
+	}
+	if gt && lt {
+		writeError(client, "ERR GT and LT options at the same time are not compatible")
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	if n > math.MaxInt64/unit || n < math.MinInt64/unit {
+		writeError(client, "ERR invalid expire time in '"+command+"' command")
+		return
+	}
+	expiry := n * unit
+	if !absolute {
+		if (expiry > 0 && expiry > math.MaxInt64-now) || (expiry < 0 && expiry < math.MinInt64+now) {
+			writeError(client, "ERR invalid expire time in '"+command+"' command")
+			return
+		}
+		expiry += now
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, ok := lookupItem(server, key)
+	if !ok {
+		writeInteger(client, 0)
+		return
+	}
+
+	// A key without an expiry counts as having an infinite TTL when comparing with GT and LT
+	hasExpiry := item.Expiry != -1
+	switch {
+	case nx && hasExpiry,
+		xx && !hasExpiry,
+		gt && (!hasExpiry || expiry <= item.Expiry),
+		lt && hasExpiry && expiry >= item.Expiry:
+		writeInteger(client, 0)
+		return
+	}
+
+	// An expiry in the past deletes the key right away. Replicas never expire keys on
+	// their own, they wait for the master to propagate the deletion.
+	if expiry <= now && server.Role == "master" {
+		server.DeleteItem(key)
+		server.Propagate("DEL", key)
+		writeInteger(client, 1)
+		return
+	}
+
+	item.Expiry = expiry
+	server.SetItem(key, item)
+
+	// Relative expiries are propagated as absolute ones, so that replicas do not drift
+	server.Propagate("PEXPIREAT", key, strconv.FormatInt(expiry, 10))
+	writeInteger(client, 1)
+}
+
+func TTL(client *types.Client, server *types.ServerState, args [][]byte) {
+	ttl(client, server, string(args[0]), func(expiry, now int64) int64 { return (expiry - now + 500) / 1000 })
+}
+
+func PTTL(client *types.Client, server *types.ServerState, args [][]byte) {
+	ttl(client, server, string(args[0]), func(expiry, now int64) int64 { return expiry - now })
+}
+
+func ExpireTime(client *types.Client, server *types.ServerState, args [][]byte) {
+	ttl(client, server, string(args[0]), func(expiry, now int64) int64 { return expiry / 1000 })
+}
+
+func PExpireTime(client *types.Client, server *types.ServerState, args [][]byte) {
+	ttl(client, server, string(args[0]), func(expiry, now int64) int64 { return expiry })
+}
+
+// ttl replies -2 if the key does not exist, -1 if it has no expiry, and otherwise
+// the expiry converted by the given function
+func ttl(client *types.Client, server *types.ServerState, key string, convert func(expiry, now int64) int64) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, ok := lookupItem(server, key)
+	switch {
+	case !ok:
+		writeInteger(client, -2)
+	case item.Expiry == -1:
+		writeInteger(client, -1)
+	default:
+		writeInteger(client, max(convert(item.Expiry, time.Now().UnixMilli()), 0))
+	}
+}
+
+func Persist(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, ok := lookupItem(server, key)
+	if !ok || item.Expiry == -1 {
+		writeInteger(client, 0)
+		return
+	}
+
+	item.Expiry = -1
+	server.SetItem(key, item)
+
+	server.Propagate("PERSIST", key)
+	writeInteger(client, 1)
+}
@@ -0,0 +1,109 @@
+package handlers_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/handlers"
+)
+
+func TestExpireConditions(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		ttl          string // TTL of k before the command, if any
+		args         []string
+		expected     string
+		expectedTTL  string
+	}{
+		{testCaseName: "NX without an expiry", args: []string{"k", "100", "NX"}, expected: ":1\r\n", expectedTTL: ":100\r\n"},
+		{testCaseName: "NX with an expiry", ttl: "50", args: []string{"k", "100", "NX"}, expected: ":0\r\n", expectedTTL: ":50\r\n"},
+		{testCaseName: "XX without an expiry", args: []string{"k", "100", "XX"}, expected: ":0\r\n", expectedTTL: ":-1\r\n"},
+		{testCaseName: "XX with an expiry", ttl: "50", args: []string{"k", "100", "xx"}, expected: ":1\r\n", expectedTTL: ":100\r\n"},
+		{testCaseName: "GT without an expiry", args: []string{"k", "100", "GT"}, expected: ":0\r\n", expectedTTL: ":-1\r\n"},
+		{testCaseName: "GT with a smaller expiry", ttl: "50", args: []string{"k", "100", "GT"}, expected: ":1\r\n", expectedTTL: ":100\r\n"},
+		{testCaseName: "GT with a larger expiry", ttl: "200", args: []string{"k", "100", "GT"}, expected: ":0\r\n", expectedTTL: ":200\r\n"},
+		{testCaseName: "LT without an expiry", args: []string{"k", "100", "LT"}, expected: ":1\r\n", expectedTTL: ":100\r\n"},
+		{testCaseName: "LT with a smaller expiry", ttl: "50", args: []string{"k", "100", "LT"}, expected: ":0\r\n", expectedTTL: ":50\r\n"},
+		{testCaseName: "LT with a larger expiry", ttl: "200", args: []string{"k", "100", "LT"}, expected: ":1\r\n", expectedTTL: ":100\r\n"},
+		{testCaseName: "XX and GT", ttl: "50", args: []string{"k", "100", "XX", "GT"}, expected: ":1\r\n", expectedTTL: ":100\r\n"},
+		{testCaseName: "Missing key", args: []string{"missing", "100"}, expected: ":0\r\n", expectedTTL: ":-1\r\n"},
+		{testCaseName: "NX and XX", args: []string{"k", "100", "NX", "XX"}, expected: "-ERR NX and XX, GT or LT options at the same time are not compatible\r\n", expectedTTL: ":-1\r\n"},
+		{testCaseName: "GT and LT", args: []string{"k", "100", "GT", "LT"}, expected: "-ERR GT and LT options at the same time are not compatible\r\n", expectedTTL: ":-1\r\n"},
+		{testCaseName: "Unknown option", args: []string{"k", "100", "FOO"}, expected: "-ERR Unsupported option FOO\r\n", expectedTTL: ":-1\r\n"},
+		{testCaseName: "Not an integer", args: []string{"k", "soon"}, expected: "-ERR value is not an integer or out of range\r\n", expectedTTL: ":-1\r\n"},
+		{testCaseName: "Overflowing expiry", args: []string{"k", "9223372036854775807"}, expected: "-ERR invalid expire time in 'expire' command\r\n", expectedTTL: ":-1\r\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			server := newServerState()
+			run(t, server, handlers.Set, "k", "v")
+			if tc.ttl != "" {
+				run(t, server, handlers.Expire, "k", tc.ttl)
+			}
+
+			if reply := run(t, server, handlers.Expire, tc.args...); reply != tc.expected {
+				t.Fatalf("Expected %q, got %q", tc.expected, reply)
+			}
+			if reply := run(t, server, handlers.TTL, "k"); reply != tc.expectedTTL {
+				t.Fatalf("Expected a TTL of %q, got %q", tc.expectedTTL, reply)
+			}
+		})
+	}
+}
+
+func TestExpireInThePast(t *testing.T) {
+	server := newServerState()
+	run(t, server, handlers.Set, "k", "v")
+	run(t, server, handlers.Set, "at", "v")
+	replica := addReplica(t, server)
+
+	if reply := run(t, server, handlers.Expire, "k", "-1"); reply != ":1\r\n" {
+		t.Fatalf("Expected %q, got %q", ":1\r\n", reply)
+	}
+	expectPropagated(t, replica, "DEL", "k")
+
+	past := strconv.FormatInt(time.Now().UnixMilli()-1000, 10)
+	if reply := run(t, server, handlers.PExpireAt, "at", past); reply != ":1\r\n" {
+		t.Fatalf("Expected %q, got %q", ":1\r\n", reply)
+	}
+	expectPropagated(t, replica, "DEL", "at")
+
+	for _, key := range []string{"k", "at"} {
+		if reply := run(t, server, handlers.Exists, key); reply != ":0\r\n" {
+			t.Fatalf("Expected %s to be deleted, got %q", key, reply)
+		}
+	}
+}
+
+func TestExpirePropagatesAbsoluteTime(t *testing.T) {
+	server := newServerState()
+	run(t, server, handlers.Set, "k", "v")
+	replica := addReplica(t, server)
+
+	before := time.Now().UnixMilli()
+	run(t, server, handlers.Expire, "k", "100")
+	after := time.Now().UnixMilli()
+	args, _, err := replica.ReadCommand()
+	if err != nil || len(args) != 3 || string(args[0]) != "PEXPIREAT" || string(args[1]) != "k" {
+		t.Fatalf("Expected PEXPIREAT to be propagated, got %q (%v)", args, err)
+	}
+	if expiry, _ := strconv.ParseInt(string(args[2]), 10, 64); expiry < before+100000 || expiry > after+100000 {
+		t.Fatalf("Expected an expiry between %d and %d, got %d", before+100000, after+100000, expiry)
+	}
+
+	run(t, server, handlers.ExpireAt, "k", "4102444800")
+	expectPropagated(t, replica, "PEXPIREAT", "k", "4102444800000")
+	run(t, server, handlers.PExpire, "k", "5000", "GT")
+	run(t, server, handlers.PExpireAt, "k", "4102444800001")
+	expectPropagated(t, replica, "PEXPIREAT", "k", "4102444800001")
+
+	// Commands that leave the expiry unchanged propagate nothing
+	sent := server.BytesSent
+	run(t, server, handlers.Expire, "k", "100", "NX")
+	run(t, server, handlers.Expire, "missing", "100")
+	if server.BytesSent != sent {
+		t.Fatalf("Expected nothing to be propagated")
+	}
+}
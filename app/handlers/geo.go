@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+// Geospatial indexes are sorted sets, so every Z* command works on them too
+
+// parseGeoCoords parses a longitude and a latitude, which must be within the range
+// a geospatial index can hold
+func parseGeoCoords(lonArg, latArg string) (float64, float64, string) {
+	lon, err1 := strconv.ParseFloat(lonArg, 64)
+	lat, err2 := strconv.ParseFloat(latArg, 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, errNotFloat
+	}
+	if !types.ValidGeoCoords(lon, lat) {
+		return 0, 0, fmt.Sprintf("ERR invalid longitude,latitude pair %f,%f", lon, lat)
+	}
+	return lon, lat, ""
+}
+
+// parseGeoUnit returns the number of meters in a unit of distance
+func parseGeoUnit(arg string) (float64, string) {
+	switch strings.ToLower(arg) {
+	case "m":
+		return 1, ""
+	case "km":
+		return 1000, ""
+	case "ft":
+		return 0.3048, ""
+	case "mi":
+		return 1609.34, ""
+	}
+	return 0, "ERR unsupported unit provided. please use M, KM, FT, MI"
+}
+
+// geoDistanceValue replies with a distance, which Redis rounds to 4 decimals
+func geoDistanceValue(dist float64) resp.Value {
+	return resp.BulkString(strconv.FormatFloat(dist, 'f', 4, 64))
+}
+
+func geoCoordsValue(lon, lat float64) resp.Value {
+	return resp.Array(resp.Double(lon), resp.Double(lat))
+}
+
+// GeoAdd adds members at the given positions to a geospatial index, with the NX, XX
+// and CH options of ZADD
+func GeoAdd(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	var nx, xx, ch bool
+
+	i := 1
+options:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "CH":
+			ch = true
+		default:
+			break options
+		}
+	}
+
+	triples := args[i:]
+	switch {
+	case len(triples) == 0 || len(triples)%3 != 0:
+		writeError(client, "ERR syntax error. Try GEOADD key [x1] [y1] [name1] [x2] [y2] [name2] ... ")
+		return
+	case nx && xx:
+		writeError(client, "ERR XX and NX options at the same time are not compatible")
+		return
+	}
+
+	// The positions are propagated as the scores they are stored with
+	propagated := append([]string{"ZADD"}, argStrings(args[:i])...)
+	scores := make([]float64, len(triples)/3)
+	for j := range scores {
+		lon, lat, errMsg := parseGeoCoords(string(triples[j*3]), string(triples[j*3+1]))
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		scores[j] = float64(types.GeoEncode(lon, lat))
+		propagated = append(propagated, formatScore(scores[j]), string(triples[j*3+2]))
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		zset = types.NewZSet()
+	}
+
+	var added, updated int64
+	for j, score := range scores {
+		member := triples[j*3+2]
+		cur, ok := zset.Score(string(member))
+		switch {
+		case ok && nx, !ok && xx:
+		case ok:
+			if score != cur {
+				zset.Add(string(member), score)
+				updated++
+			}
+		default:
+			zset.Add(string(member), score)
+			added++
+		}
+	}
+
+	if added+updated > 0 {
+		if !exists {
+			server.SetItem(key, types.DBItem{Object: zset, Expiry: -1})
+		}
+		server.Propagate(propagated...)
+	}
+
+	if ch {
+		writeInteger(client, added+updated)
+		return
+	}
+	writeInteger(client, added)
+}
+
+// GeoDist replies with the distance between two members, in meters or the given
+// unit, or nil if either is missing
+func GeoDist(client *types.Client, server *types.ServerState, args [][]byte) {
+	if len(args) > 4 {
+		writeError(client, errSyntax)
+		return
+	}
+	unit := 1.0
+	if len(args) == 4 {
+		var errMsg string
+		if unit, errMsg = parseGeoUnit(string(args[3])); errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+
+	score1, ok1 := zset.Score(string(args[1]))
+	score2, ok2 := zset.Score(string(args[2]))
+	if !ok1 || !ok2 {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+	lon1, lat1 := types.GeoDecode(uint64(score1))
+	lon2, lat2 := types.GeoDecode(uint64(score2))
+	writeValue(client, geoDistanceValue(types.GeoDistance(lon1, lat1, lon2, lat2)/unit))
+}
+
+// GeoPos replies with the positions of members, or nil for the missing ones.
+// Positions are those at the center of the area their geohash locates, so they
+// differ slightly from the positions given to GEOADD.
+func GeoPos(client *types.Client, server *types.ServerState, args [][]byte) {
+	geoMembers(client, server, args, func(hash uint64) resp.Value {
+		return geoCoordsValue(types.GeoDecode(hash))
+	}, resp.NullArray())
+}
+
+// GeoHash replies with the standard geohashes of the positions of members, or nil
+// for the missing ones
+func GeoHash(client *types.Client, server *types.ServerState, args [][]byte) {
+	geoMembers(client, server, args, func(hash uint64) resp.Value {
+		return resp.BulkString(types.GeoHashString(hash))
+	}, resp.NullBulkString())
+}
+
+// geoMembers replies with an array holding value for each member of the index at
+// args[0] listed in the rest of args, or missing for those that do not exist
+func geoMembers(client *types.Client, server *types.ServerState, args [][]byte, value func(hash uint64) resp.Value, missing resp.Value) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, _, errMsg := lookupObject[*types.ZSet](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	values := make([]resp.Value, len(args)-1)
+	for i, member := range args[1:] {
+		values[i] = missing
+		if zset == nil {
+			continue
+		}
+		if score, ok := zset.Score(string(member)); ok {
+			values[i] = value(uint64(score))
+		}
+	}
+	writeValue(client, resp.Array(values...))
+}
+
+// geoSearchOptions holds the options of GEOSEARCH and GEOSEARCHSTORE
+type geoSearchOptions struct {
+	fromMember string
+	hasMember  bool
+	shape      types.GeoShape
+	hasCenter  bool
+	hasShape   bool
+	unit       float64 // Meters in the unit of distances
+	sort       int     // 1 for ASC, -1 for DESC, 0 for no order
+	count      int     // Most members returned, 0 for all of them
+	any        bool    // Return the first count members found rather than the closest
+	withDist   bool
+	withHash   bool
+	withCoord  bool
+	storeDist  bool // Store distances rather than geohashes as scores
+}
+
+func parseGeoSearch(args [][]byte, command string) (geoSearchOptions, string) {
+	opts := geoSearchOptions{}
+	store := command == "GEOSEARCHSTORE"
+	for i := 0; i < len(args); i++ {
+		remaining := len(args) - i - 1
+		switch option := strings.ToUpper(string(args[i])); {
+		case option == "FROMMEMBER" && remaining >= 1 && !opts.hasCenter:
+			opts.fromMember, opts.hasMember = string(args[i+1]), true
+			i++
+		case option == "FROMLONLAT" && remaining >= 2 && !opts.hasMember:
+			lon, lat, errMsg := parseGeoCoords(string(args[i+1]), string(args[i+2]))
+			if errMsg != "" {
+				return opts, errMsg
+			}
+			opts.shape.Lon, opts.shape.Lat, opts.hasCenter = lon, lat, true
+			i += 2
+		case option == "FROMMEMBER" || option == "FROMLONLAT":
+			return opts, "ERR exactly one of FROMMEMBER or FROMLONLAT can be specified for " + command
+		case option == "BYRADIUS" && remaining >= 2 && !opts.hasShape:
+			radius, err := strconv.ParseFloat(string(args[i+1]), 64)
+			if err != nil {
+				return opts, "ERR need numeric radius"
+			}
+			if radius < 0 {
+				return opts, "ERR radius cannot be negative"
+			}
+			unit, errMsg := parseGeoUnit(string(args[i+2]))
+			if errMsg != "" {
+				return opts, errMsg
+			}
+			opts.shape.Radius, opts.unit, opts.hasShape = radius*unit, unit, true
+			i += 2
+		case option == "BYBOX" && remaining >= 3 && !opts.hasShape:
+			width, err1 := strconv.ParseFloat(string(args[i+1]), 64)
+			height, err2 := strconv.ParseFloat(string(args[i+2]), 64)
+			if err1 != nil || err2 != nil {
+				return opts, errNotFloat
+			}
+			if width < 0 || height < 0 {
+				return opts, "ERR height or width cannot be negative"
+			}
+			unit, errMsg := parseGeoUnit(string(args[i+3]))
+			if errMsg != "" {
+				return opts, errMsg
+			}
+			opts.shape.Box, opts.shape.Width, opts.shape.Height = true, width*unit, height*unit
+			opts.unit, opts.hasShape = unit, true
+			i += 3
+		case option == "BYRADIUS" || option == "BYBOX":
+			return opts, "ERR exactly one of BYRADIUS and BYBOX can be specified for " + command
+		case option == "ASC":
+			opts.sort = 1
+		case option == "DESC":
+			opts.sort = -1
+		case option == "COUNT" && remaining >= 1:
+			n, err := strconv.Atoi(string(args[i+1]))
+			if err != nil {
+				return opts, errNotInt
+			}
+			if n <= 0 {
+				return opts, "ERR COUNT must be > 0"
+			}
+			opts.count = n
+			i++
+			if i+1 < len(args) && strings.ToUpper(string(args[i+1])) == "ANY" {
+				opts.any = true
+				i++
+			}
+		case option == "ANY":
+			return opts, "ERR the ANY argument requires COUNT argument"
+		case option == "WITHDIST" && !store:
+			opts.withDist = true
+		case option == "WITHHASH" && !store:
+			opts.withHash = true
+		case option == "WITHCOORD" && !store:
+			opts.withCoord = true
+		case option == "STOREDIST" && store:
+			opts.storeDist = true
+		default:
+			return opts, errSyntax
+		}
+	}
+
+	if !opts.hasMember && !opts.hasCenter {
+		return opts, "ERR exactly one of FROMMEMBER or FROMLONLAT can be specified for " + command
+	}
+	if !opts.hasShape {
+		return opts, "ERR exactly one of BYRADIUS and BYBOX can be specified for " + command
+	}
+	return opts, ""
+}
+
+// geoSearch returns the members of the index within the shape of the search,
+// ordered and limited as the options say. It returns the error to reply with if the
+// member to search from does not exist.
+func geoSearch(zset *types.ZSet, opts geoSearchOptions) ([]types.GeoPoint, string) {
+	shape := opts.shape
+	if opts.hasMember {
+		score, ok := zset.Score(opts.fromMember)
+		if !ok {
+			return nil, "ERR could not decode requested zset member"
+		}
+		shape.Lon, shape.Lat = types.GeoDecode(uint64(score))
+	}
+
+	// Without ANY, the closest members are returned, so all of them are looked at
+	limit, order := 0, opts.sort
+	if opts.any {
+		limit = opts.count
+	} else if opts.count > 0 && order == 0 {
+		order = 1
+	}
+	points := zset.GeoSearch(shape, limit)
+
+	if order != 0 {
+		sort.SliceStable(points, func(i, j int) bool {
+			if order > 0 {
+				return points[i].Dist < points[j].Dist
+			}
+			return points[i].Dist > points[j].Dist
+		})
+	}
+	if opts.count > 0 && len(points) > opts.count {
+		points = points[:opts.count]
+	}
+	return points, ""
+}
+
+// GeoSearch replies with the members of a geospatial index within a circle or a box
+// centered on a member or a position, along with their distance, geohash and position
+// as the WITHDIST, WITHHASH and WITHCOORD options ask
+func GeoSearch(client *types.Client, server *types.ServerState, args [][]byte) {
+	opts, errMsg := parseGeoSearch(args[1:], "GEOSEARCH")
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.Array())
+		return
+	}
+
+	points, errMsg := geoSearch(zset, opts)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	values := make([]resp.Value, len(points))
+	for i, p := range points {
+		if !opts.withDist && !opts.withHash && !opts.withCoord {
+			values[i] = resp.BulkString(p.Member)
+			continue
+		}
+		item := []resp.Value{resp.BulkString(p.Member)}
+		if opts.withDist {
+			item = append(item, geoDistanceValue(p.Dist/opts.unit))
+		}
+		if opts.withHash {
+			item = append(item, resp.Integer(int64(p.Hash)))
+		}
+		if opts.withCoord {
+			item = append(item, geoCoordsValue(p.Lon, p.Lat))
+		}
+		values[i] = resp.Array(item...)
+	}
+	writeValue(client, resp.Array(values...))
+}
+
+// GeoSearchStore stores the members GEOSEARCH would find at the destination, as a
+// geospatial index, or with STOREDIST as a sorted set scored by distance
+func GeoSearchStore(client *types.Client, server *types.ServerState, args [][]byte) {
+	dest, src := string(args[0]), string(args[1])
+	opts, errMsg := parseGeoSearch(args[2:], "GEOSEARCHSTORE")
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, src)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	result := types.NewZSet()
+	if exists {
+		points, errMsg := geoSearch(zset, opts)
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		for _, p := range points {
+			if opts.storeDist {
+				result.Add(p.Member, p.Dist/opts.unit)
+			} else {
+				result.Add(p.Member, float64(p.Hash))
+			}
+		}
+	}
+
+	storeZSet(server, dest, result, "GEOSEARCHSTORE", args)
+	writeInteger(client, int64(result.Len()))
+}
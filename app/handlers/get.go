@@ -1,36 +1,23 @@
 package handlers
 
 import (
-	"net"
-	"sync"
-	"time"
-
 	"github.com/codecrafters-io/redis-starter-go/app/types"
 	"github.com/codecrafters-io/redis-starter-go/resp"
 )
 
-func Get(con net.Conn, db *map[string]types.DBItem, mutex *sync.Mutex, key string) {
-	respHandler := resp.RESPHandler{}
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	value, ok := (*db)[key]
+func Get(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
 
-	if !ok {
-		res := respHandler.Nil.Encode()
-		con.Write(res)
+	value, ok, errMsg := lookupString(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
 		return
 	}
-
-	if value.Expiry == -1 || time.Now().UnixMilli() < value.Expiry {
-		res,err := respHandler.BulkString.Encode(value.Value)
-		if err != nil {
-			res = respHandler.Nil.Encode()
-		}
-		con.Write(res)
+	if !ok {
+		writeValue(client, resp.Null())
 		return
 	}
 
-	delete(*db, key)
-	con.Write(respHandler.Nil.Encode())
-}
\ No newline at end of file
+	writeValue(client, resp.BulkBytes(value.Value))
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+func GetDel(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, ok, errMsg := lookupString(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !ok {
+		writeValue(client, resp.Null())
+		return
+	}
+
+	server.DeleteItem(key)
+	server.Propagate("GETDEL", key)
+	writeValue(client, resp.BulkBytes(item.Value))
+}
+
+// GetEx handles GETEX key [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds | PERSIST]
+func GetEx(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	expiry := int64(0) // 0 leaves the expiry untouched
+	persist := false
+	for i := 1; i < len(args); i++ {
+		option := strings.ToUpper(string(args[i]))
+		switch {
+		case option == "PERSIST" && expiry == 0 && !persist:
+			persist = true
+		case (option == "EX" || option == "PX" || option == "EXAT" || option == "PXAT") && expiry == 0 && !persist && i+1 < len(args):
+			var errMsg string
+			expiry, errMsg = parseExpiry(option, string(args[i+1]), time.Now().UnixMilli(), "getex")
+			if errMsg != "" {
+				writeError(client, errMsg)
+				return
+			}
+			i++
+		default:
+			writeError(client, errSyntax)
+			return
+		}
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, ok, errMsg := lookupString(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !ok {
+		writeValue(client, resp.Null())
+		return
+	}
+
+	switch {
+	case persist && item.Expiry != -1:
+		item.Expiry = -1
+		server.SetItem(key, item)
+		server.Propagate("PERSIST", key)
+	case expiry != 0:
+		item.Expiry = expiry
+		server.SetItem(key, item)
+		server.Propagate("PEXPIREAT", key, strconv.FormatInt(expiry, 10))
+	}
+
+	writeValue(client, resp.BulkBytes(item.Value))
+}
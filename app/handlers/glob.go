@@ -0,0 +1,87 @@
+package handlers
+
+// matchGlob reports whether str matches a glob-style pattern, with the same syntax
+// as in Redis: "*" matches any sequence of bytes, "?" any single byte, "[abc]" one of
+// the bytes in the brackets and "[^abc]" any other byte, "[a-z]" a byte in the range,
+// and "\x" matches x literally.
+//
+// Every token other than * consumes exactly one byte, which lets a mismatch simply
+// backtrack to the last * instead of recursing.
+func matchGlob(pattern, str string) bool {
+	p, s := 0, 0
+	starP, starS := -1, 0
+
+	for s < len(str) {
+		if p < len(pattern) {
+			if pattern[p] == '*' {
+				starP, starS = p, s
+				p++
+				continue
+			}
+			if width, ok := matchGlobToken(pattern[p:], str[s]); ok {
+				p += width
+				s++
+				continue
+			}
+		}
+
+		// Let the last * swallow one more byte and retry from there
+		if starP == -1 {
+			return false
+		}
+		starS++
+		p, s = starP+1, starS
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchGlobToken matches the token at the start of pattern, which is not *, against
+// c. It returns the width of the token and whether it matched.
+func matchGlobToken(pattern string, c byte) (int, bool) {
+	switch pattern[0] {
+	case '?':
+		return 1, true
+
+	case '\\':
+		if len(pattern) > 1 {
+			return 2, pattern[1] == c
+		}
+		return 1, c == '\\'
+
+	case '[':
+		i := 1
+		negate := i < len(pattern) && pattern[i] == '^'
+		if negate {
+			i++
+		}
+
+		matched := false
+		for ; i < len(pattern) && pattern[i] != ']'; i++ {
+			switch {
+			case pattern[i] == '\\' && i+1 < len(pattern):
+				i++
+				matched = matched || pattern[i] == c
+			case i+2 < len(pattern) && pattern[i+1] == '-':
+				lo, hi := pattern[i], pattern[i+2]
+				if lo > hi {
+					lo, hi = hi, lo
+				}
+				matched = matched || (c >= lo && c <= hi)
+				i += 2
+			default:
+				matched = matched || pattern[i] == c
+			}
+		}
+
+		// An unterminated class extends to the end of the pattern
+		width := min(i+1, len(pattern))
+		return width, matched != negate
+
+	default:
+		return 1, pattern[0] == c
+	}
+}
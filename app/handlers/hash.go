@@ -0,0 +1,704 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+// lookupHash returns the hash stored at key, after deleting its expired fields.
+// Unlike expired keys, expired fields are deleted on replicas as well, since the
+// HDEL that the master propagates for them is a no-op once they are gone.
+// It must be called with DBMutex held.
+func lookupHash(server *types.ServerState, key string) (*types.Hash, bool, string) {
+	hash, exists, errMsg := lookupObject[*types.Hash](server, key)
+	if !exists || !server.ExpireHashFields(key, hash) {
+		return nil, false, errMsg
+	}
+	return hash, true, ""
+}
+
+// lookupOrCreateHash returns the hash stored at key, creating an empty one if the key does not exist.
+// It must be called with DBMutex held.
+func lookupOrCreateHash(server *types.ServerState, key string) (*types.Hash, string) {
+	hash, exists, errMsg := lookupHash(server, key)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	if !exists {
+		hash = types.NewHash()
+		server.SetItem(key, types.DBItem{Object: hash, Expiry: -1})
+	}
+	return hash, ""
+}
+
+// HSet sets any number of fields and replies with how many of them were added
+func HSet(client *types.Client, server *types.ServerState, args [][]byte) {
+	hset(client, server, "HSET", args)
+}
+
+// HMSet is HSET replying OK, kept for compatibility
+func HMSet(client *types.Client, server *types.ServerState, args [][]byte) {
+	hset(client, server, "HMSET", args)
+}
+
+func hset(client *types.Client, server *types.ServerState, command string, args [][]byte) {
+	key := string(args[0])
+	if len(args)%2 == 0 {
+		writeError(client, "ERR wrong number of arguments for '"+strings.ToLower(command)+"' command")
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, errMsg := lookupOrCreateHash(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	var added int64
+	for i := 1; i < len(args); i += 2 {
+		if hash.Set(string(args[i]), args[i+1]) {
+			added++
+		}
+	}
+
+	server.PropagateArgs(command, args)
+	if command == "HMSET" {
+		writeOK(client)
+		return
+	}
+	writeInteger(client, added)
+}
+
+func HSetNX(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, field := string(args[0]), string(args[1])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, errMsg := lookupOrCreateHash(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if _, ok := hash.Get(field); ok {
+		writeInteger(client, 0)
+		return
+	}
+
+	hash.Set(field, args[2])
+	server.PropagateArgs("HSETNX", args)
+	writeInteger(client, 1)
+}
+
+func HGet(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	var value []byte
+	ok := false
+	if exists {
+		value, ok = hash.Get(string(args[1]))
+	}
+	if !ok {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+	writeValue(client, resp.BulkBytes(value))
+}
+
+func HMGet(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	values := make([]resp.Value, len(args)-1)
+	for i, field := range args[1:] {
+		values[i] = resp.NullBulkString()
+		if !exists {
+			continue
+		}
+		if value, ok := hash.Get(string(field)); ok {
+			values[i] = resp.BulkBytes(value)
+		}
+	}
+	writeValue(client, resp.Array(values...))
+}
+
+func HDel(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+
+	deleted := []string{}
+	for _, field := range args[1:] {
+		if hash.Delete(string(field)) {
+			deleted = append(deleted, string(field))
+		}
+	}
+	if hash.Len() == 0 {
+		server.DeleteItem(key)
+	}
+
+	if len(deleted) > 0 {
+		server.Propagate(append([]string{"HDEL", key}, deleted...)...)
+	}
+	writeInteger(client, int64(len(deleted)))
+}
+
+func HLen(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+	writeInteger(client, int64(hash.Len()))
+}
+
+func HStrlen(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	var value []byte
+	if exists {
+		value, _ = hash.Get(string(args[1]))
+	}
+	writeInteger(client, int64(len(value)))
+}
+
+func HExists(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	if exists {
+		if _, ok := hash.Get(string(args[1])); ok {
+			writeInteger(client, 1)
+			return
+		}
+	}
+	writeInteger(client, 0)
+}
+
+func HKeys(client *types.Client, server *types.ServerState, args [][]byte) {
+	hashContents(client, server, string(args[0]), true, false)
+}
+
+func HVals(client *types.Client, server *types.ServerState, args [][]byte) {
+	hashContents(client, server, string(args[0]), false, true)
+}
+
+// HGetAll replies with all fields and values, as a map in RESP3
+func HGetAll(client *types.Client, server *types.ServerState, args [][]byte) {
+	hashContents(client, server, string(args[0]), true, true)
+}
+
+func hashContents(client *types.Client, server *types.ServerState, key string, fields, values bool) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	elems := []resp.Value{}
+	if exists {
+		hash.Range(func(field string, value []byte) bool {
+			if fields {
+				elems = append(elems, resp.BulkString(field))
+			}
+			if values {
+				elems = append(elems, resp.BulkBytes(value))
+			}
+			return true
+		})
+	}
+
+	if fields && values {
+		writeValue(client, resp.Map(elems...))
+		return
+	}
+	writeValue(client, resp.Array(elems...))
+}
+
+func HIncrBy(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, field := string(args[0]), string(args[1])
+	delta, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		writeError(client, errNotInt)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, errMsg := lookupOrCreateHash(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	var current int64
+	if value, ok := hash.Get(field); ok {
+		current, err = strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			writeError(client, "ERR hash value is not an integer")
+			return
+		}
+	}
+
+	if (delta > 0 && current > math.MaxInt64-delta) || (delta < 0 && current < math.MinInt64-delta) {
+		writeError(client, "ERR increment or decrement would overflow")
+		return
+	}
+
+	current += delta
+	setHashFieldKeepTTL(hash, field, []byte(strconv.FormatInt(current, 10)))
+
+	server.PropagateArgs("HINCRBY", args)
+	writeInteger(client, current)
+}
+
+func HIncrByFloat(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, field := string(args[0]), string(args[1])
+	delta, err := strconv.ParseFloat(string(args[2]), 64)
+	if err != nil || math.IsNaN(delta) || math.IsInf(delta, 0) {
+		writeError(client, "ERR value is not a valid float")
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, errMsg := lookupOrCreateHash(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	var current float64
+	if value, ok := hash.Get(field); ok {
+		current, err = strconv.ParseFloat(string(value), 64)
+		if err != nil {
+			writeError(client, "ERR hash value is not a float")
+			return
+		}
+	}
+
+	current += delta
+	if math.IsNaN(current) || math.IsInf(current, 0) {
+		writeError(client, "ERR increment would produce NaN or Infinity")
+		return
+	}
+
+	value := strconv.FormatFloat(current, 'f', -1, 64)
+	setHashFieldKeepTTL(hash, field, []byte(value))
+
+	// The result is propagated rather than the increment, so that replicas do not
+	// depend on their own floating point rounding. HSET clears the expiry of the
+	// field, which is propagated again if it has one.
+	server.Propagate("HSET", key, field, value)
+	if expiry, _ := hash.Expiry(field); expiry != -1 {
+		server.Propagate("HPEXPIREAT", key, strconv.FormatInt(expiry, 10), "FIELDS", "1", field)
+	}
+	writeValue(client, resp.BulkString(value))
+}
+
+// setHashFieldKeepTTL sets a field like Hash.Set, but keeps its expiry, as HINCRBY does
+func setHashFieldKeepTTL(hash *types.Hash, field string, value []byte) {
+	expiry, ok := hash.Expiry(field)
+	hash.Set(field, value)
+	if ok && expiry != -1 {
+		hash.SetExpiry(field, expiry)
+	}
+}
+
+// HRandField replies with a random field, or with a count with up to count distinct
+// fields, or exactly -count fields that may repeat if count is negative
+func HRandField(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	count, hasCount, withValues := int64(1), len(args) > 1, false
+	if hasCount {
+		n, err := strconv.ParseInt(string(args[1]), 10, 64)
+		if err != nil {
+			writeError(client, errNotInt)
+			return
+		}
+		count = n
+	}
+	switch {
+	case len(args) == 3 && strings.ToUpper(string(args[2])) == "WITHVALUES":
+		withValues = true
+	case len(args) > 2:
+		writeError(client, errSyntax)
+		return
+	}
+	if count < -math.MaxInt32 || count > math.MaxInt32 {
+		writeError(client, "ERR value is out of range")
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		if hasCount {
+			writeValue(client, resp.Array())
+		} else {
+			writeValue(client, resp.NullBulkString())
+		}
+		return
+	}
+
+	if !hasCount {
+		field, _ := hash.Random()
+		writeValue(client, resp.BulkString(field))
+		return
+	}
+
+	fields, values := randomHashFields(hash, int(count))
+	elems := make([]resp.Value, 0, len(fields)*2)
+	for i, field := range fields {
+		switch {
+		case !withValues:
+			elems = append(elems, resp.BulkString(field))
+		case client.Protocol == 3:
+			// RESP3 replies with field-value pairs rather than a flat array
+			elems = append(elems, resp.Array(resp.BulkString(field), resp.BulkBytes(values[i])))
+		default:
+			elems = append(elems, resp.BulkString(field), resp.BulkBytes(values[i]))
+		}
+	}
+	writeValue(client, resp.Array(elems...))
+}
+
+// randomHashFields picks count distinct random fields, or -count fields that may
+// repeat if count is negative
+func randomHashFields(hash *types.Hash, count int) ([]string, [][]byte) {
+	return sampleRandom(hash.Len(), count, hash.Random, func() ([]string, [][]byte) {
+		fields, values := []string{}, [][]byte{}
+		hash.Range(func(field string, value []byte) bool {
+			fields, values = append(fields, field), append(values, value)
+			return true
+		})
+		return fields, values
+	})
+}
+
+func HScan(client *types.Client, server *types.ServerState, args [][]byte) {
+	opts, errMsg := parseScanOptions(args[1:], "HSCAN")
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeScanReply(client, 0, nil)
+		return
+	}
+
+	elems := []string{}
+	cursor := scanDict[[]byte](hash, opts.cursor, opts.count, func(field string, value []byte) {
+		if opts.pattern != "" && !matchGlob(opts.pattern, field) {
+			return
+		}
+		elems = append(elems, field)
+		if !opts.noValues {
+			elems = append(elems, string(value))
+		}
+	})
+	writeScanReply(client, cursor, elems)
+}
+
+// parseFields parses "FIELDS numfields field [field ...]", which ends the
+// arguments of the commands working on field expiries
+func parseFields(args [][]byte) ([]string, string) {
+	if len(args) < 2 || strings.ToUpper(string(args[0])) != "FIELDS" {
+		return nil, "ERR Mandatory argument FIELDS is missing or not at the right position"
+	}
+	n, err := strconv.ParseInt(string(args[1]), 10, 64)
+	if err != nil || n <= 0 {
+		return nil, "ERR Parameter `numFields` should be greater than 0"
+	}
+	if n != int64(len(args)-2) {
+		return nil, "ERR The `numfields` parameter must match the number of arguments"
+	}
+	return argStrings(args[2:]), ""
+}
+
+func HExpire(client *types.Client, server *types.ServerState, args [][]byte) {
+	hexpire(client, server, "hexpire", 1000, false, args)
+}
+
+func HPExpire(client *types.Client, server *types.ServerState, args [][]byte) {
+	hexpire(client, server, "hpexpire", 1, false, args)
+}
+
+func HExpireAt(client *types.Client, server *types.ServerState, args [][]byte) {
+	hexpire(client, server, "hexpireat", 1000, true, args)
+}
+
+func HPExpireAt(client *types.Client, server *types.ServerState, args [][]byte) {
+	hexpire(client, server, "hpexpireat", 1, true, args)
+}
+
+// hexpire handles the HEXPIRE family: key time [NX | XX | GT | LT] FIELDS numfields
+// field [field ...], with time converted like in expire. It replies for every field
+// with -2 if it does not exist, 0 if the condition was not met, 1 if the expiry was
+// set and 2 if the field was deleted because the time is in the past.
+func hexpire(client *types.Client, server *types.ServerState, command string, unit int64, absolute bool, args [][]byte) {
+	key := string(args[0])
+	n, err := strconv.ParseInt(string(args[1]), 10, 64)
+	if err != nil {
+		writeError(client, errNotInt)
+		return
+	}
+	if n < 0 {
+		writeError(client, "ERR invalid expire time, must be >= 0")
+		return
+	}
+
+	rest := args[2:]
+	condition := ""
+	if len(rest) > 0 {
+		switch option := strings.ToUpper(string(rest[0])); option {
+		case "NX", "XX", "GT", "LT":
+			condition = option
+			rest = rest[1:]
+		}
+	}
+	fields, errMsg := parseFields(rest)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	if n > math.MaxInt64/unit {
+		writeError(client, "ERR invalid expire time in '"+command+"' command")
+		return
+	}
+	expiry := n * unit
+	if !absolute {
+		if expiry > math.MaxInt64-now {
+			writeError(client, "ERR invalid expire time in '"+command+"' command")
+			return
+		}
+		expiry += now
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	results := make([]resp.Value, len(fields))
+	set, deleted := []string{}, []string{}
+	for i, field := range fields {
+		current, ok := int64(0), false
+		if exists {
+			current, ok = hash.Expiry(field)
+		}
+		if !ok {
+			results[i] = resp.Integer(-2)
+			continue
+		}
+
+		// A field without an expiry counts as having an infinite TTL when comparing with GT and LT
+		hasExpiry := current != -1
+		switch {
+		case condition == "NX" && hasExpiry,
+			condition == "XX" && !hasExpiry,
+			condition == "GT" && (!hasExpiry || expiry <= current),
+			condition == "LT" && hasExpiry && expiry >= current:
+			results[i] = resp.Integer(0)
+		case expiry <= now:
+			hash.Delete(field)
+			deleted = append(deleted, field)
+			results[i] = resp.Integer(2)
+		default:
+			hash.SetExpiry(field, expiry)
+			set = append(set, field)
+			results[i] = resp.Integer(1)
+		}
+	}
+
+	if len(deleted) > 0 {
+		server.Propagate(append([]string{"HDEL", key}, deleted...)...)
+		if hash.Len() == 0 {
+			server.DeleteItem(key)
+		}
+	}
+	if len(set) > 0 {
+		server.FieldExpires[key] = struct{}{}
+
+		// Relative expiries are propagated as absolute ones, so that replicas do not drift
+		propagated := []string{"HPEXPIREAT", key, strconv.FormatInt(expiry, 10), "FIELDS", strconv.Itoa(len(set))}
+		server.Propagate(append(propagated, set...)...)
+	}
+	writeValue(client, resp.Array(results...))
+}
+
+func HTTL(client *types.Client, server *types.ServerState, args [][]byte) {
+	httl(client, server, args, func(expiry, now int64) int64 { return (expiry - now + 500) / 1000 })
+}
+
+func HPTTL(client *types.Client, server *types.ServerState, args [][]byte) {
+	httl(client, server, args, func(expiry, now int64) int64 { return expiry - now })
+}
+
+func HExpireTime(client *types.Client, server *types.ServerState, args [][]byte) {
+	httl(client, server, args, func(expiry, now int64) int64 { return expiry / 1000 })
+}
+
+func HPExpireTime(client *types.Client, server *types.ServerState, args [][]byte) {
+	httl(client, server, args, func(expiry, now int64) int64 { return expiry })
+}
+
+// httl replies for every field with -2 if it does not exist, -1 if it has no
+// expiry, and otherwise the expiry converted by the given function
+func httl(client *types.Client, server *types.ServerState, args [][]byte, convert func(expiry, now int64) int64) {
+	fields, errMsg := parseFields(args[1:])
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	results := make([]resp.Value, len(fields))
+	for i, field := range fields {
+		expiry, ok := int64(0), false
+		if exists {
+			expiry, ok = hash.Expiry(field)
+		}
+		switch {
+		case !ok:
+			results[i] = resp.Integer(-2)
+		case expiry == -1:
+			results[i] = resp.Integer(-1)
+		default:
+			results[i] = resp.Integer(max(convert(expiry, now), 0))
+		}
+	}
+	writeValue(client, resp.Array(results...))
+}
+
+// HPersist removes the expiry of fields, replying for every field with -2 if it
+// does not exist, -1 if it has no expiry and 1 if its expiry was removed
+func HPersist(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	fields, errMsg := parseFields(args[1:])
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	hash, exists, errMsg := lookupHash(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	results := make([]resp.Value, len(fields))
+	persisted := []string{}
+	for i, field := range fields {
+		expiry, ok := int64(0), false
+		if exists {
+			expiry, ok = hash.Expiry(field)
+		}
+		switch {
+		case !ok:
+			results[i] = resp.Integer(-2)
+		case expiry == -1:
+			results[i] = resp.Integer(-1)
+		default:
+			hash.SetExpiry(field, -1)
+			persisted = append(persisted, field)
+			results[i] = resp.Integer(1)
+		}
+	}
+
+	if len(persisted) > 0 {
+		propagated := []string{"HPERSIST", key, "FIELDS", strconv.Itoa(len(persisted))}
+		server.Propagate(append(propagated, persisted...)...)
+	}
+	writeValue(client, resp.Array(results...))
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+const serverVersion = "7.2.0"
+
+// Hello handles HELLO [protover [AUTH username password] [SETNAME clientname]], which
+// switches the protocol of the connection and replies with information about the server
+func Hello(client *types.Client, server *types.ServerState, args [][]byte) {
+	protocol := client.Protocol
+	if len(args) > 0 {
+		version, err := strconv.Atoi(string(args[0]))
+		if err != nil {
+			writeError(client, "ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if version != 2 && version != 3 {
+			writeError(client, "NOPROTO unsupported protocol version")
+			return
+		}
+		protocol = version
+	}
+
+	name := client.Name
+	for i := 1; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "AUTH":
+			if i+2 >= len(args) {
+				writeError(client, "ERR Syntax error in HELLO option 'auth'")
+				return
+			}
+			// There is no ACL support, every client is the default user
+			i += 2
+		case "SETNAME":
+			if i+1 >= len(args) {
+				writeError(client, "ERR Syntax error in HELLO option 'setname'")
+				return
+			}
+			name = string(args[i+1])
+			i++
+		default:
+			writeError(client, fmt.Sprintf("ERR Syntax error in HELLO option '%s'", args[i]))
+			return
+		}
+	}
+
+	client.Protocol = protocol
+	client.Name = name
+
+	role := server.Role
+	if role == "slave" {
+		role = "replica"
+	}
+	writeValue(client, resp.Map(
+		resp.BulkString("server"), resp.BulkString("redis"),
+		resp.BulkString("version"), resp.BulkString(serverVersion),
+		resp.BulkString("proto"), resp.Integer(int64(protocol)),
+		resp.BulkString("id"), resp.Integer(client.ID),
+		resp.BulkString("mode"), resp.BulkString("standalone"),
+		resp.BulkString("role"), resp.BulkString(role),
+		resp.BulkString("modules"), resp.Array(),
+	))
+}
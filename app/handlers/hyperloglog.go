@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+const (
+	errNotHLL     = "WRONGTYPE Key is not a valid HyperLogLog string value."
+	errCorruptHLL = "INVALIDOBJ Corrupted HLL object detected"
+)
+
+// lookupHLL returns the HyperLogLog stored at key. If the key holds anything other
+// than a HyperLogLog, it returns the error to reply with.
+// It must be called with DBMutex held.
+func lookupHLL(server *types.ServerState, key string) (types.DBItem, bool, string) {
+	item, exists, errMsg := lookupString(server, key)
+	if errMsg != "" {
+		return item, false, errMsg
+	}
+	if exists && !types.IsHLL(item.Value) {
+		return item, false, errNotHLL
+	}
+	return item, exists, ""
+}
+
+// PFAdd adds elements to a HyperLogLog, creating it if needed, and replies with 1 if
+// its estimated cardinality may have changed
+func PFAdd(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	elems := make([][]byte, len(args)-1)
+	for i, arg := range args[1:] {
+		elems[i] = arg
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, exists, errMsg := lookupHLL(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		item = types.DBItem{Value: types.NewHLL(), Expiry: -1}
+	}
+
+	var changed, ok bool
+	item.Value, changed, ok = types.HLLAdd(item.Value, elems)
+	if !ok {
+		writeError(client, errCorruptHLL)
+		return
+	}
+	if !changed && exists {
+		writeInteger(client, 0)
+		return
+	}
+	server.SetItem(key, item)
+
+	server.PropagateArgs("PFADD", args)
+	writeInteger(client, 1)
+}
+
+// PFCount replies with the estimated cardinality of a HyperLogLog, or of the union
+// of several, which are merged on the fly
+func PFCount(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	if len(args) == 1 {
+		item, exists, errMsg := lookupHLL(server, string(args[0]))
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		if !exists {
+			writeInteger(client, 0)
+			return
+		}
+
+		n, updated, ok := types.HLLCount(item.Value)
+		if !ok {
+			writeError(client, errCorruptHLL)
+			return
+		}
+		// The cache is part of the value, updated in place, so replicas are sent the
+		// command to keep their copy identical, as Redis does
+		if updated {
+			server.Propagate("PFCOUNT", string(args[0]))
+		}
+		writeInteger(client, int64(n))
+		return
+	}
+
+	regs := types.NewHLLRegisters()
+	for _, key := range args {
+		item, exists, errMsg := lookupHLL(server, string(key))
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		if exists && !types.HLLMerge(regs, item.Value) {
+			writeError(client, errCorruptHLL)
+			return
+		}
+	}
+	writeInteger(client, int64(types.HLLEstimate(regs)))
+}
+
+// PFMerge stores the union of HyperLogLogs at the destination, which is part of the
+// union if it exists. The result is dense if any of the inputs is.
+func PFMerge(client *types.Client, server *types.ServerState, args [][]byte) {
+	dest := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	regs, dense := types.NewHLLRegisters(), false
+	expiry := int64(-1)
+	for i, key := range args {
+		item, exists, errMsg := lookupHLL(server, string(key))
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		if !exists {
+			continue
+		}
+		if i == 0 {
+			expiry = item.Expiry
+		}
+		if types.IsDenseHLL(item.Value) {
+			dense = true
+		}
+		if !types.HLLMerge(regs, item.Value) {
+			writeError(client, errCorruptHLL)
+			return
+		}
+	}
+	server.SetItem(dest, types.DBItem{Value: types.HLLFromRegisters(regs, dense), Expiry: expiry})
+
+	server.PropagateArgs("PFMERGE", args)
+	writeOK(client)
+}
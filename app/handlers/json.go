@@ -0,0 +1,523 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+// JSON documents are stored parsed, so commands update the nodes their paths match
+// in place. Paths in the legacy syntax reply with the first value they match, and
+// JSONPath expressions with all of them, as RedisJSON does.
+
+// parseJSONPath parses a path, returning the error to reply with if it is invalid
+func parseJSONPath(arg string) (*types.JSONPath, string) {
+	path, err := types.ParseJSONPath(arg)
+	if err != nil {
+		return nil, "ERR invalid JSONPath '" + arg + "': " + err.Error()
+	}
+	return path, ""
+}
+
+// parseJSONValue parses a JSON value, returning the error to reply with if it is
+// invalid
+func parseJSONValue(arg string) (any, string) {
+	v, err := types.ParseJSON([]byte(arg))
+	if err != nil {
+		return nil, "ERR invalid JSON value: " + err.Error()
+	}
+	return v, ""
+}
+
+func errJSONPathMissing(arg string) string {
+	return "ERR Path '" + arg + "' does not exist"
+}
+
+// jsonValues returns the values of matches, as a JSON array
+func jsonValues(matches []types.JSONMatch) *types.JSONArray {
+	arr := &types.JSONArray{Elems: []any{}}
+	for _, m := range matches {
+		arr.Elems = append(arr.Elems, m.Value)
+	}
+	return arr
+}
+
+// JSONSet sets the value at a path, or adds it if the path names a missing key of
+// an existing object. New documents must be set at the root. NX only adds values
+// and XX only replaces them.
+func JSONSet(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	var nx, xx bool
+	for _, arg := range args[3:] {
+		switch strings.ToUpper(string(arg)) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		default:
+			writeError(client, errSyntax)
+			return
+		}
+	}
+	if nx && xx {
+		writeError(client, errSyntax)
+		return
+	}
+
+	path, errMsg := parseJSONPath(string(args[1]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	value, errMsg := parseJSONValue(string(args[2]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	doc, exists, errMsg := lookupObject[*types.JSON](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	if !exists {
+		if !path.IsRoot() {
+			writeError(client, "ERR new objects must be created at the root")
+			return
+		}
+		if xx {
+			writeValue(client, resp.NullBulkString())
+			return
+		}
+		server.SetItem(key, types.DBItem{Object: types.NewJSON(value), Expiry: -1})
+	} else if !doc.Set(path, value, nx, xx) {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+
+	server.PropagateArgs("JSON.SET", args)
+	writeOK(client)
+}
+
+// JSONGet replies with the values at the given paths, serialized with the given
+// INDENT, NEWLINE and SPACE. A single path replies with its values, and several
+// with an object mapping each path to its values.
+func JSONGet(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	var format types.JSONFormat
+	pathArgs := []string{}
+	for i := 1; i < len(args); i++ {
+		var opt *string
+		switch strings.ToUpper(string(args[i])) {
+		case "INDENT":
+			opt = &format.Indent
+		case "NEWLINE":
+			opt = &format.Newline
+		case "SPACE":
+			opt = &format.Space
+		default:
+			pathArgs = append(pathArgs, string(args[i]))
+			continue
+		}
+		if i+1 >= len(args) {
+			writeError(client, errSyntax)
+			return
+		}
+		i++
+		*opt = string(args[i])
+	}
+	if len(pathArgs) == 0 {
+		pathArgs = []string{"."}
+	}
+
+	// Paths are all treated as JSONPath expressions if any of them is one
+	paths := make([]*types.JSONPath, len(pathArgs))
+	legacy := true
+	for i, arg := range pathArgs {
+		path, errMsg := parseJSONPath(arg)
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		paths[i] = path
+		legacy = legacy && path.Legacy()
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	doc, exists, errMsg := lookupObject[*types.JSON](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+
+	results := make([]any, len(paths))
+	for i, path := range paths {
+		matches := doc.Query(path)
+		if !legacy {
+			results[i] = jsonValues(matches)
+			continue
+		}
+		if len(matches) == 0 {
+			writeError(client, errJSONPathMissing(pathArgs[i]))
+			return
+		}
+		results[i] = matches[0].Value
+	}
+
+	if len(paths) == 1 {
+		writeValue(client, resp.BulkString(format.Marshal(results[0])))
+		return
+	}
+	obj := types.NewJSONObject()
+	for i, arg := range pathArgs {
+		obj.Set(arg, results[i])
+	}
+	writeValue(client, resp.BulkString(format.Marshal(obj)))
+}
+
+// JSONMGet replies with the values at a path in each of the given documents, or nil
+// for keys that do not hold a document
+func JSONMGet(client *types.Client, server *types.ServerState, args [][]byte) {
+	keys, pathArg := args[:len(args)-1], args[len(args)-1]
+	path, errMsg := parseJSONPath(string(pathArg))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	values := make([]resp.Value, len(keys))
+	for i, key := range keys {
+		values[i] = resp.NullBulkString()
+		doc, exists, _ := lookupObject[*types.JSON](server, string(key))
+		if !exists {
+			continue
+		}
+		matches := doc.Query(path)
+		switch {
+		case !path.Legacy():
+			values[i] = resp.BulkString(types.MarshalJSON(jsonValues(matches)))
+		case len(matches) > 0:
+			values[i] = resp.BulkString(types.MarshalJSON(matches[0].Value))
+		}
+	}
+	writeValue(client, resp.Array(values...))
+}
+
+// JSONDel deletes the values at a path, the root by default, and replies with how
+// many there were. Deleting the root deletes the key.
+func JSONDel(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, pathArg := string(args[0]), "$"
+	if len(args) > 2 {
+		writeError(client, errSyntax)
+		return
+	}
+	if len(args) == 2 {
+		pathArg = string(args[1])
+	}
+	path, errMsg := parseJSONPath(pathArg)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	doc, exists, errMsg := lookupObject[*types.JSON](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+
+	if path.IsRoot() {
+		server.DeleteItem(key)
+		server.Propagate("DEL", key)
+		writeInteger(client, 1)
+		return
+	}
+
+	deleted := doc.Delete(doc.Query(path))
+	if deleted > 0 {
+		server.PropagateArgs("JSON.DEL", args)
+	}
+	writeInteger(client, int64(deleted))
+}
+
+// lookupJSONMatches returns the values a path matches in the document at key. If
+// the key holds anything other than a document, or does not exist, it returns the
+// error to reply with.
+// It must be called with DBMutex held.
+func lookupJSONMatches(server *types.ServerState, key string, path *types.JSONPath) (*types.JSON, []types.JSONMatch, string) {
+	doc, exists, errMsg := lookupObject[*types.JSON](server, key)
+	if errMsg != "" {
+		return nil, nil, errMsg
+	}
+	if !exists {
+		return nil, nil, "ERR could not perform this operation on a key that doesn't exist"
+	}
+	return doc, doc.Query(path), ""
+}
+
+// JSONNumIncrBy increments the numbers at a path, and replies with their new values,
+// or null for the values that are not numbers. Integers stay integers unless the
+// increment is a float.
+func JSONNumIncrBy(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	path, errMsg := parseJSONPath(string(args[1]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	incr, errMsg := parseJSONValue(string(args[2]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if t := types.JSONTypeName(incr); t != "integer" && t != "number" {
+		writeError(client, "ERR expected a number but found "+t)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	doc, matches, errMsg := lookupJSONMatches(server, key, path)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	// Results are computed before anything is updated, so that the command fails
+	// as a whole if any of them overflows
+	results := make([]any, len(matches))
+	updated := 0
+	for i, m := range matches {
+		results[i] = nil
+		switch v := m.Value.(type) {
+		case int64:
+			if n, ok := incr.(int64); ok && (n >= 0 && v <= math.MaxInt64-n || n < 0 && v >= math.MinInt64-n) {
+				results[i] = v + n
+			} else {
+				results[i] = float64(v) + toFloat(incr)
+			}
+		case float64:
+			results[i] = v + toFloat(incr)
+		default:
+			continue
+		}
+		if f, ok := results[i].(float64); ok && (math.IsInf(f, 0) || math.IsNaN(f)) {
+			writeError(client, "ERR result "+strconv.FormatFloat(f, 'g', -1, 64)+" is not a number")
+			return
+		}
+		updated++
+	}
+
+	if path.Legacy() && updated == 0 {
+		if len(matches) == 0 {
+			writeError(client, errJSONPathMissing(string(args[1])))
+		} else {
+			writeError(client, "WRONGTYPE wrong type of path value - expected a number but found "+types.JSONTypeName(matches[0].Value))
+		}
+		return
+	}
+
+	for i, m := range matches {
+		if results[i] != nil {
+			doc.Replace(m, results[i])
+		}
+	}
+	if updated > 0 {
+		server.PropagateArgs("JSON.NUMINCRBY", args)
+	}
+
+	if path.Legacy() {
+		for _, result := range results {
+			if result != nil {
+				writeValue(client, resp.BulkString(types.MarshalJSON(result)))
+				return
+			}
+		}
+	}
+	writeValue(client, resp.BulkString(types.MarshalJSON(&types.JSONArray{Elems: results})))
+}
+
+func toFloat(n any) float64 {
+	if i, ok := n.(int64); ok {
+		return float64(i)
+	}
+	return n.(float64)
+}
+
+// JSONArrAppend appends values to the arrays at a path, and replies with their new
+// lengths, or nil for the values that are not arrays
+func JSONArrAppend(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	path, errMsg := parseJSONPath(string(args[1]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	values := make([]any, len(args)-2)
+	for i, arg := range args[2:] {
+		if values[i], errMsg = parseJSONValue(string(arg)); errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	_, matches, errMsg := lookupJSONMatches(server, key, path)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	lengths := []resp.Value{}
+	for _, m := range matches {
+		arr, ok := m.Value.(*types.JSONArray)
+		if !ok {
+			lengths = append(lengths, resp.NullBulkString())
+			continue
+		}
+		// Each array gets its own copy of the values
+		for _, v := range values {
+			arr.Elems = append(arr.Elems, types.CopyJSONValue(v))
+		}
+		lengths = append(lengths, resp.Integer(int64(len(arr.Elems))))
+		if path.Legacy() {
+			server.PropagateArgs("JSON.ARRAPPEND", args)
+			writeValue(client, lengths[len(lengths)-1])
+			return
+		}
+	}
+
+	if path.Legacy() {
+		writeError(client, errJSONPathMissing(string(args[1])))
+		return
+	}
+	for _, length := range lengths {
+		if length.Type == resp.TypeInteger {
+			server.PropagateArgs("JSON.ARRAPPEND", args)
+			break
+		}
+	}
+	writeValue(client, resp.Array(lengths...))
+}
+
+// JSONObjKeys replies with the keys of the objects at a path, the root by default,
+// or nil for the values that are not objects
+func JSONObjKeys(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, pathArg := string(args[0]), "."
+	if len(args) > 2 {
+		writeError(client, errSyntax)
+		return
+	}
+	if len(args) == 2 {
+		pathArg = string(args[1])
+	}
+	path, errMsg := parseJSONPath(pathArg)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	doc, exists, errMsg := lookupObject[*types.JSON](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+
+	replies := []resp.Value{}
+	for _, m := range doc.Query(path) {
+		obj, ok := m.Value.(*types.JSONObject)
+		if !ok {
+			replies = append(replies, resp.NullBulkString())
+			continue
+		}
+		replies = append(replies, resp.BulkStrings(obj.Keys()...))
+		if path.Legacy() {
+			writeValue(client, replies[len(replies)-1])
+			return
+		}
+	}
+
+	if path.Legacy() {
+		writeError(client, errJSONPathMissing(pathArg))
+		return
+	}
+	writeValue(client, resp.Array(replies...))
+}
+
+// JSONType replies with the types of the values at a path, the root by default
+func JSONType(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, pathArg := string(args[0]), "."
+	if len(args) > 2 {
+		writeError(client, errSyntax)
+		return
+	}
+	if len(args) == 2 {
+		pathArg = string(args[1])
+	}
+	path, errMsg := parseJSONPath(pathArg)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	doc, exists, errMsg := lookupObject[*types.JSON](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+
+	matches := doc.Query(path)
+	if path.Legacy() {
+		if len(matches) == 0 {
+			writeValue(client, resp.NullBulkString())
+			return
+		}
+		writeValue(client, resp.SimpleString(types.JSONTypeName(matches[0].Value)))
+		return
+	}
+	names := []resp.Value{}
+	for _, m := range matches {
+		names = append(names, resp.SimpleString(types.JSONTypeName(m.Value)))
+	}
+	writeValue(client, resp.Array(names...))
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+// Values removed by UNLINK are handed to a background goroutine, like the lazyfree
+// thread of Redis, which drops the last reference to them. The memory is then
+// reclaimed by the garbage collector concurrently, rather than on the dispatcher's
+// path. If the goroutine falls behind, values are dropped in place.
+var (
+	unlinked        = make(chan types.DBItem, 1024)
+	startUnlinkOnce sync.Once
+)
+
+// releaseUnlinked receives unlinked values, dropping each as the next one is received
+func releaseUnlinked() {
+	for range unlinked {
+	}
+}
+
+func Del(client *types.Client, server *types.ServerState, args [][]byte) {
+	deleteKeys(client, server, "DEL", argStrings(args), false)
+}
+
+// Unlink removes keys like DEL, but releases their values in the background
+func Unlink(client *types.Client, server *types.ServerState, args [][]byte) {
+	startUnlinkOnce.Do(func() { go releaseUnlinked() })
+	deleteKeys(client, server, "UNLINK", argStrings(args), true)
+}
+
+func deleteKeys(client *types.Client, server *types.ServerState, command string, keys []string, lazy bool) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	deleted := []string{}
+	for _, key := range keys {
+		item, ok := lookupItem(server, key)
+		if !ok {
+			continue
+		}
+		server.DeleteItem(key)
+		deleted = append(deleted, key)
+
+		if lazy {
+			select {
+			case unlinked <- item:
+			default:
+			}
+		}
+	}
+
+	if len(deleted) > 0 {
+		server.Propagate(append([]string{command}, deleted...)...)
+	}
+	writeInteger(client, int64(len(deleted)))
+}
+
+// Exists counts how many of the given keys exist. A key given several times is counted each time.
+func Exists(client *types.Client, server *types.ServerState, args [][]byte) {
+	countExisting(client, server, argStrings(args))
+}
+
+// Touch counts how many of the given keys exist. Since there is no eviction,
+// touching has no other effect.
+func Touch(client *types.Client, server *types.ServerState, args [][]byte) {
+	countExisting(client, server, argStrings(args))
+}
+
+func countExisting(client *types.Client, server *types.ServerState, keys []string) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	var count int64
+	for _, key := range keys {
+		if checkIfKeyExists(key, server) {
+			count++
+		}
+	}
+
+	writeInteger(client, count)
+}
+
+func Type(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, ok := lookupItem(server, string(args[0]))
+	if !ok {
+		writeValue(client, resp.SimpleString("none"))
+		return
+	}
+
+	writeValue(client, resp.SimpleString(item.Type()))
+}
+
+func Rename(client *types.Client, server *types.ServerState, args [][]byte) {
+	renameKey(client, server, string(args[0]), string(args[1]), false)
+}
+
+func RenameNX(client *types.Client, server *types.ServerState, args [][]byte) {
+	renameKey(client, server, string(args[0]), string(args[1]), true)
+}
+
+func renameKey(client *types.Client, server *types.ServerState, source string, destination string, nx bool) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, ok := lookupItem(server, source)
+	if !ok {
+		writeError(client, "ERR no such key")
+		return
+	}
+
+	if nx {
+		if checkIfKeyExists(destination, server) {
+			writeInteger(client, 0)
+			return
+		}
+	}
+
+	// The value keeps its expiry when it is moved to the new key
+	server.DeleteItem(source)
+	server.SetItem(destination, item)
+
+	if nx {
+		server.Propagate("RENAMENX", source, destination)
+		writeInteger(client, 1)
+		return
+	}
+	server.Propagate("RENAME", source, destination)
+	writeOK(client)
+}
+
+// Copy handles COPY source destination [DB destination-db] [REPLACE]. There is only
+// the default database, so the only accepted destination database is 0.
+func Copy(client *types.Client, server *types.ServerState, args [][]byte) {
+	source, destination := string(args[0]), string(args[1])
+
+	replace := false
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "REPLACE":
+			replace = true
+		case "DB":
+			if i+1 >= len(args) {
+				writeError(client, errSyntax)
+				return
+			}
+			if string(args[i+1]) != "0" {
+				writeError(client, "ERR DB index is out of range")
+				return
+			}
+			i++
+		default:
+			writeError(client, errSyntax)
+			return
+		}
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, ok := lookupItem(server, source)
+	if !ok {
+		writeInteger(client, 0)
+		return
+	}
+	if source == destination {
+		writeError(client, "ERR source and destination objects are the same")
+		return
+	}
+	if !replace && checkIfKeyExists(destination, server) {
+		writeInteger(client, 0)
+		return
+	}
+
+	server.SetItem(destination, item.Copy())
+
+	if replace {
+		server.Propagate("COPY", source, destination, "REPLACE")
+	} else {
+		server.Propagate("COPY", source, destination)
+	}
+	writeInteger(client, 1)
+}
@@ -0,0 +1,181 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/handlers"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// newKeyspace returns a server holding a key of every type
+func newKeyspace(t *testing.T) *types.ServerState {
+	t.Helper()
+	server := newServerState()
+	run(t, server, handlers.Set, "string", "v")
+	run(t, server, handlers.RPush, "list", "a", "b")
+	run(t, server, handlers.SAdd, "set", "m")
+	run(t, server, handlers.HSet, "hash", "f", "v")
+	run(t, server, handlers.ZAdd, "zset", "1", "m")
+	run(t, server, handlers.XAdd, "stream", "1-1", "f", "v")
+	return server
+}
+
+var keyspaceTypes = map[string]string{
+	"string": "+string\r\n",
+	"list":   "+list\r\n",
+	"set":    "+set\r\n",
+	"hash":   "+hash\r\n",
+	"zset":   "+zset\r\n",
+	"stream": "+stream\r\n",
+}
+
+func TestType(t *testing.T) {
+	server := newKeyspace(t)
+	for key, expected := range keyspaceTypes {
+		if reply := run(t, server, handlers.Type, key); reply != expected {
+			t.Fatalf("Expected the type of %s to be %q, got %q", key, expected, reply)
+		}
+	}
+	if reply := run(t, server, handlers.Type, "missing"); reply != "+none\r\n" {
+		t.Fatalf("Expected %q, got %q", "+none\r\n", reply)
+	}
+}
+
+func TestExistsAndDelete(t *testing.T) {
+	server := newKeyspace(t)
+	replica := addReplica(t, server)
+
+	// A key given several times is counted each time
+	args := []string{"string", "list", "set", "hash", "zset", "stream", "missing", "string"}
+	for _, h := range []handler{handlers.Exists, handlers.Touch} {
+		if reply := run(t, server, h, args...); reply != ":7\r\n" {
+			t.Fatalf("Expected %q, got %q", ":7\r\n", reply)
+		}
+	}
+
+	if reply := run(t, server, handlers.Del, "string", "list", "missing", "string"); reply != ":2\r\n" {
+		t.Fatalf("Expected %q, got %q", ":2\r\n", reply)
+	}
+	expectPropagated(t, replica, "DEL", "string", "list")
+
+	if reply := run(t, server, handlers.Unlink, "set", "hash", "zset", "stream"); reply != ":4\r\n" {
+		t.Fatalf("Expected %q, got %q", ":4\r\n", reply)
+	}
+	expectPropagated(t, replica, "UNLINK", "set", "hash", "zset", "stream")
+
+	if reply := run(t, server, handlers.Exists, args...); reply != ":0\r\n" {
+		t.Fatalf("Expected no key to be left, got %q", reply)
+	}
+
+	// Deleting nothing propagates nothing
+	sent := server.BytesSent
+	if reply := run(t, server, handlers.Del, "missing"); reply != ":0\r\n" || server.BytesSent != sent {
+		t.Fatalf("Expected nothing to be deleted or propagated, got %q", reply)
+	}
+}
+
+func TestRename(t *testing.T) {
+	server := newKeyspace(t)
+	run(t, server, handlers.Expire, "string", "100")
+	replica := addReplica(t, server)
+
+	if reply := run(t, server, handlers.Rename, "string", "renamed"); reply != "+OK\r\n" {
+		t.Fatalf("Expected %q, got %q", "+OK\r\n", reply)
+	}
+	expectPropagated(t, replica, "RENAME", "string", "renamed")
+	if reply := run(t, server, handlers.Get, "renamed"); reply != "$1\r\nv\r\n" {
+		t.Fatalf("Expected the value to be moved, got %q", reply)
+	}
+	if ttl := pttl(t, server, "renamed"); ttl < 99000 || ttl > 100000 {
+		t.Fatalf("Expected the TTL to be kept, got %d", ttl)
+	}
+	if reply := run(t, server, handlers.Exists, "string"); reply != ":0\r\n" {
+		t.Fatalf("Expected the source to be removed, got %q", reply)
+	}
+
+	// The destination is overwritten, whatever its type
+	if reply := run(t, server, handlers.Rename, "list", "renamed"); reply != "+OK\r\n" {
+		t.Fatalf("Expected %q, got %q", "+OK\r\n", reply)
+	}
+	expectPropagated(t, replica, "RENAME", "list", "renamed")
+	if reply := run(t, server, handlers.Type, "renamed"); reply != "+list\r\n" {
+		t.Fatalf("Expected the list to be moved, got %q", reply)
+	}
+	if ttl := pttl(t, server, "renamed"); ttl != -1 {
+		t.Fatalf("Expected the TTL of the overwritten key to be dropped, got %d", ttl)
+	}
+
+	if reply := run(t, server, handlers.Rename, "missing", "other"); reply != "-ERR no such key\r\n" {
+		t.Fatalf("Expected %q, got %q", "-ERR no such key\r\n", reply)
+	}
+
+	if reply := run(t, server, handlers.RenameNX, "hash", "zset"); reply != ":0\r\n" {
+		t.Fatalf("Expected %q, got %q", ":0\r\n", reply)
+	}
+	if reply := run(t, server, handlers.RenameNX, "hash", "hash2"); reply != ":1\r\n" {
+		t.Fatalf("Expected %q, got %q", ":1\r\n", reply)
+	}
+	expectPropagated(t, replica, "RENAMENX", "hash", "hash2")
+	if reply := run(t, server, handlers.HGet, "hash2", "f"); reply != "$1\r\nv\r\n" {
+		t.Fatalf("Expected the hash to be moved, got %q", reply)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	server := newKeyspace(t)
+	run(t, server, handlers.Expire, "string", "100")
+
+	for key, expected := range keyspaceTypes {
+		if reply := run(t, server, handlers.Copy, key, key+"-copy"); reply != ":1\r\n" {
+			t.Fatalf("Expected %s to be copied, got %q", key, reply)
+		}
+		if reply := run(t, server, handlers.Type, key+"-copy"); reply != expected {
+			t.Fatalf("Expected the copy of %s to be a %q, got %q", key, expected, reply)
+		}
+	}
+	if ttl := pttl(t, server, "string-copy"); ttl < 99000 || ttl > 100000 {
+		t.Fatalf("Expected the TTL to be copied, got %d", ttl)
+	}
+
+	// The copy does not share its value with the source
+	run(t, server, handlers.RPush, "list-copy", "c")
+	if reply := run(t, server, handlers.LRange, "list", "0", "-1"); reply != "*2\r\n$1\r\na\r\n$1\r\nb\r\n" {
+		t.Fatalf("Expected the source list to be left unchanged, got %q", reply)
+	}
+	run(t, server, handlers.HSet, "hash-copy", "f", "changed")
+	if reply := run(t, server, handlers.HGet, "hash", "f"); reply != "$1\r\nv\r\n" {
+		t.Fatalf("Expected the source hash to be left unchanged, got %q", reply)
+	}
+
+	replica := addReplica(t, server)
+	if reply := run(t, server, handlers.Copy, "list", "string"); reply != ":0\r\n" {
+		t.Fatalf("Expected an existing destination to be kept, got %q", reply)
+	}
+	if reply := run(t, server, handlers.Copy, "list", "string", "replace"); reply != ":1\r\n" {
+		t.Fatalf("Expected %q, got %q", ":1\r\n", reply)
+	}
+	expectPropagated(t, replica, "COPY", "list", "string", "REPLACE")
+	if reply := run(t, server, handlers.Type, "string"); reply != "+list\r\n" {
+		t.Fatalf("Expected the destination to be replaced, got %q", reply)
+	}
+
+	tests := []struct {
+		testCaseName string
+		args         []string
+		expected     string
+	}{
+		{testCaseName: "Missing source", args: []string{"missing", "other"}, expected: ":0\r\n"},
+		{testCaseName: "Same key", args: []string{"list", "list"}, expected: "-ERR source and destination objects are the same\r\n"},
+		{testCaseName: "Default database", args: []string{"list", "other", "DB", "0"}, expected: ":1\r\n"},
+		{testCaseName: "Other database", args: []string{"list", "another", "DB", "1"}, expected: "-ERR DB index is out of range\r\n"},
+		{testCaseName: "Database without an index", args: []string{"list", "another", "DB"}, expected: "-ERR syntax error\r\n"},
+		{testCaseName: "Unknown option", args: []string{"list", "another", "FOO"}, expected: "-ERR syntax error\r\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			if reply := run(t, server, handlers.Copy, tc.args...); reply != tc.expected {
+				t.Fatalf("Expected %q, got %q", tc.expected, reply)
+			}
+		})
+	}
+}
@@ -0,0 +1,636 @@
+package handlers
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+func LPush(client *types.Client, server *types.ServerState, args [][]byte) {
+	push(client, server, "LPUSH", args, true, false)
+}
+
+func RPush(client *types.Client, server *types.ServerState, args [][]byte) {
+	push(client, server, "RPUSH", args, false, false)
+}
+
+// LPushX pushes like LPUSH, but only if the list already exists
+func LPushX(client *types.Client, server *types.ServerState, args [][]byte) {
+	push(client, server, "LPUSHX", args, true, true)
+}
+
+// RPushX pushes like RPUSH, but only if the list already exists
+func RPushX(client *types.Client, server *types.ServerState, args [][]byte) {
+	push(client, server, "RPUSHX", args, false, true)
+}
+
+func push(client *types.Client, server *types.ServerState, command string, args [][]byte, left bool, onlyExisting bool) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	list, exists, errMsg := lookupObject[*types.List](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		if onlyExisting {
+			writeInteger(client, 0)
+			return
+		}
+		list = types.NewList()
+		server.SetItem(key, types.DBItem{Object: list, Expiry: -1})
+	}
+
+	for _, elem := range args[1:] {
+		if left {
+			list.PushFront(elem)
+		} else {
+			list.PushBack(elem)
+		}
+	}
+
+	server.SignalKeyReady(key)
+	server.PropagateArgs(command, args)
+	writeInteger(client, int64(list.Len()))
+}
+
+func LPop(client *types.Client, server *types.ServerState, args [][]byte) {
+	pop(client, server, "LPOP", args, true)
+}
+
+func RPop(client *types.Client, server *types.ServerState, args [][]byte) {
+	pop(client, server, "RPOP", args, false)
+}
+
+// pop pops one element, or with a count argument an array of up to count elements
+func pop(client *types.Client, server *types.ServerState, command string, args [][]byte, left bool) {
+	key := string(args[0])
+	if len(args) > 2 {
+		writeError(client, errSyntax)
+		return
+	}
+
+	count, hasCount := int64(1), len(args) == 2
+	if hasCount {
+		n, err := strconv.ParseInt(string(args[1]), 10, 64)
+		if err != nil || n < 0 {
+			writeError(client, "ERR value is out of range, must be positive")
+			return
+		}
+		count = n
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	list, exists, errMsg := lookupObject[*types.List](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		if hasCount {
+			writeValue(client, resp.NullArray())
+		} else {
+			writeValue(client, resp.NullBulkString())
+		}
+		return
+	}
+
+	elems := popElems(server, key, list, left, int(min(count, int64(list.Len()))))
+	if len(elems) > 0 {
+		server.PropagateArgs(command, args)
+	}
+
+	if !hasCount {
+		writeValue(client, resp.BulkBytes(elems[0]))
+		return
+	}
+	writeValue(client, bulkArray(elems))
+}
+
+// popElems pops count elements from one end of the list stored at key, deleting
+// the key once the list is empty. It must be called with DBMutex held.
+func popElems(server *types.ServerState, key string, list *types.List, left bool, count int) [][]byte {
+	elems := make([][]byte, 0, count)
+	for len(elems) < count {
+		var elem []byte
+		var ok bool
+		if left {
+			elem, ok = list.PopFront()
+		} else {
+			elem, ok = list.PopBack()
+		}
+		if !ok {
+			break
+		}
+		elems = append(elems, elem)
+	}
+
+	if list.Len() == 0 {
+		server.DeleteItem(key)
+	}
+	return elems
+}
+
+func LLen(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	list, exists, errMsg := lookupObject[*types.List](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+	writeInteger(client, int64(list.Len()))
+}
+
+func LIndex(client *types.Client, server *types.ServerState, args [][]byte) {
+	index, err := strconv.Atoi(string(args[1]))
+	if err != nil {
+		writeError(client, errNotInt)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	list, exists, errMsg := lookupObject[*types.List](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+
+	elem, ok := list.Index(index)
+	if !ok {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+	writeValue(client, resp.BulkBytes(elem))
+}
+
+func LSet(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	index, err := strconv.Atoi(string(args[1]))
+	if err != nil {
+		writeError(client, errNotInt)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	list, exists, errMsg := lookupObject[*types.List](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeError(client, "ERR no such key")
+		return
+	}
+
+	if !list.Set(index, args[2]) {
+		writeError(client, "ERR index out of range")
+		return
+	}
+
+	server.PropagateArgs("LSET", args)
+	writeOK(client)
+}
+
+func LRange(client *types.Client, server *types.ServerState, args [][]byte) {
+	start, err1 := strconv.Atoi(string(args[1]))
+	end, err2 := strconv.Atoi(string(args[2]))
+	if err1 != nil || err2 != nil {
+		writeError(client, errNotInt)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	list, exists, errMsg := lookupObject[*types.List](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.Array())
+		return
+	}
+
+	from, to, ok := normalizeIndexRange(start, end, list.Len())
+	if !ok {
+		writeValue(client, resp.Array())
+		return
+	}
+	writeValue(client, bulkArray(list.Range(from, to)))
+}
+
+// normalizeIndexRange converts an inclusive range, where negative indexes count
+// from the end, to valid indexes into a sequence of the given length, as done by
+// LRANGE and LTRIM. It returns false if the range is empty.
+func normalizeIndexRange(start, end, length int) (int, int, bool) {
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if end < 0 {
+		end = length + end
+	}
+	if start > end || start >= length {
+		return 0, 0, false
+	}
+	return start, min(end, length-1), true
+}
+
+func LTrim(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	start, err1 := strconv.Atoi(string(args[1]))
+	end, err2 := strconv.Atoi(string(args[2]))
+	if err1 != nil || err2 != nil {
+		writeError(client, errNotInt)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	list, exists, errMsg := lookupObject[*types.List](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeOK(client)
+		return
+	}
+
+	from, to, ok := normalizeIndexRange(start, end, list.Len())
+	if ok {
+		list.Trim(from, to)
+	} else {
+		server.DeleteItem(key)
+	}
+
+	server.PropagateArgs("LTRIM", args)
+	writeOK(client)
+}
+
+func LRem(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	count, err := strconv.Atoi(string(args[1]))
+	if err != nil {
+		writeError(client, errNotInt)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	list, exists, errMsg := lookupObject[*types.List](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+
+	removed := list.Remove(count, args[2])
+	if list.Len() == 0 {
+		server.DeleteItem(key)
+	}
+
+	if removed > 0 {
+		server.PropagateArgs("LREM", args)
+	}
+	writeInteger(client, int64(removed))
+}
+
+func LInsert(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	var before bool
+	switch strings.ToUpper(string(args[1])) {
+	case "BEFORE":
+		before = true
+	case "AFTER":
+		before = false
+	default:
+		writeError(client, errSyntax)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	list, exists, errMsg := lookupObject[*types.List](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+
+	if !list.Insert(args[2], args[3], before) {
+		writeInteger(client, -1)
+		return
+	}
+
+	server.PropagateArgs("LINSERT", args)
+	writeInteger(client, int64(list.Len()))
+}
+
+// LPos returns the index of matching elements. RANK picks the n-th match, counting
+// from the end if negative, COUNT returns up to that many matches (0 for all) as
+// an array, and MAXLEN limits how many elements are compared.
+func LPos(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, elem := string(args[0]), args[1]
+	rank, count, maxLen := 1, 0, 0
+	hasCount := false
+
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			writeError(client, errSyntax)
+			return
+		}
+		n, err := strconv.Atoi(string(args[i+1]))
+		if err != nil {
+			writeError(client, errNotInt)
+			return
+		}
+
+		switch strings.ToUpper(string(args[i])) {
+		case "RANK":
+			if n == 0 {
+				writeError(client, "ERR RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the end of the list")
+				return
+			}
+			// Like Redis, the rank is limited to -LONG_MAX, so that it can be negated
+			if n == math.MinInt {
+				writeError(client, "ERR value is out of range, value must between -9223372036854775807 and 9223372036854775807")
+				return
+			}
+			rank = n
+		case "COUNT":
+			if n < 0 {
+				writeError(client, "ERR COUNT can't be negative")
+				return
+			}
+			count, hasCount = n, true
+		case "MAXLEN":
+			if n < 0 {
+				writeError(client, "ERR MAXLEN can't be negative")
+				return
+			}
+			maxLen = n
+		default:
+			writeError(client, errSyntax)
+			return
+		}
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	list, exists, errMsg := lookupObject[*types.List](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	matches := []resp.Value{}
+	if exists {
+		skip := max(rank, -rank) - 1
+		compared := 0
+		list.Iterate(rank < 0, func(i int, e []byte) bool {
+			if maxLen != 0 && compared == maxLen {
+				return false
+			}
+			compared++
+
+			if !bytes.Equal(e, elem) {
+				return true
+			}
+			if skip > 0 {
+				skip--
+				return true
+			}
+			matches = append(matches, resp.Integer(int64(i)))
+			return (hasCount && count == 0) || len(matches) < count
+		})
+	}
+
+	if hasCount {
+		writeValue(client, resp.Array(matches...))
+		return
+	}
+	if len(matches) == 0 {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+	writeValue(client, matches[0])
+}
+
+// parseListDirection parses the LEFT | RIGHT argument of LMOVE and LMPOP
+func parseListDirection(arg string) (left bool, ok bool) {
+	switch strings.ToUpper(arg) {
+	case "LEFT":
+		return true, true
+	case "RIGHT":
+		return false, true
+	}
+	return false, false
+}
+
+func listDirectionName(left bool) string {
+	if left {
+		return "LEFT"
+	}
+	return "RIGHT"
+}
+
+// LMove atomically pops an element from one end of the source list and pushes it
+// to one end of the destination list
+func LMove(client *types.Client, server *types.ServerState, args [][]byte) {
+	from, ok1 := parseListDirection(string(args[2]))
+	to, ok2 := parseListDirection(string(args[3]))
+	if !ok1 || !ok2 {
+		writeError(client, errSyntax)
+		return
+	}
+	lmove(client, server, string(args[0]), string(args[1]), from, to, "LMOVE", args)
+}
+
+// RPopLPush is LMOVE source destination RIGHT LEFT
+func RPopLPush(client *types.Client, server *types.ServerState, args [][]byte) {
+	lmove(client, server, string(args[0]), string(args[1]), false, true, "RPOPLPUSH", args)
+}
+
+func lmove(client *types.Client, server *types.ServerState, source, destination string, from, to bool, command string, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	src, exists, errMsg := lookupObject[*types.List](server, source)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+	if _, _, errMsg := lookupObject[*types.List](server, destination); errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	elem := moveElement(server, source, src, destination, from, to)
+	server.PropagateArgs(command, args)
+	writeValue(client, resp.BulkBytes(elem))
+}
+
+// moveElement pops an element from the non-empty list at source and pushes it to
+// the list at destination, creating it if needed. The destination must have been
+// checked to not hold a value of another type.
+// It must be called with DBMutex held.
+func moveElement(server *types.ServerState, source string, src *types.List, destination string, from, to bool) []byte {
+	elem := popElems(server, source, src, from, 1)[0]
+
+	// Looked up after popping, as the source may have been the destination and been emptied
+	dst, exists, _ := lookupObject[*types.List](server, destination)
+	if !exists {
+		dst = types.NewList()
+		server.SetItem(destination, types.DBItem{Object: dst, Expiry: -1})
+	}
+
+	if to {
+		dst.PushFront(elem)
+	} else {
+		dst.PushBack(elem)
+	}
+	server.SignalKeyReady(destination)
+	return elem
+}
+
+// LMPop pops up to COUNT elements from the first non-empty list among the given keys
+func LMPop(client *types.Client, server *types.ServerState, args [][]byte) {
+	keys, left, count, errMsg := parseLMPopArgs(args)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	key, elems, errMsg := lmpop(server, keys, left, count)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if elems == nil {
+		writeValue(client, resp.NullArray())
+		return
+	}
+	writeValue(client, resp.Array(resp.BulkString(key), bulkArray(elems)))
+}
+
+// parseLMPopArgs parses "numkeys key [key ...] LEFT|RIGHT [COUNT count]"
+func parseLMPopArgs(args [][]byte) (keys []string, left bool, count int, errMsg string) {
+	keys, rest, errMsg := parseNumKeys(args)
+	if errMsg != "" {
+		return nil, false, 0, errMsg
+	}
+	if len(rest) == 0 {
+		return nil, false, 0, errSyntax
+	}
+
+	left, ok := parseListDirection(string(rest[0]))
+	if !ok {
+		return nil, false, 0, errSyntax
+	}
+
+	count = 1
+	switch {
+	case len(rest) == 1:
+	case len(rest) == 3 && strings.ToUpper(string(rest[1])) == "COUNT":
+		n, err := strconv.Atoi(string(rest[2]))
+		if err != nil || n <= 0 {
+			return nil, false, 0, "ERR count should be greater than 0"
+		}
+		count = n
+	default:
+		return nil, false, 0, errSyntax
+	}
+
+	return keys, left, count, ""
+}
+
+// parseNumKeys splits "numkeys key [key ...] ..." into the keys and the arguments following them
+func parseNumKeys(args [][]byte) (keys []string, rest [][]byte, errMsg string) {
+	n, err := strconv.Atoi(string(args[0]))
+	if err != nil || n <= 0 {
+		return nil, nil, "ERR numkeys should be greater than 0"
+	}
+	if n > len(args)-1 {
+		return nil, nil, "ERR Number of keys can't be greater than number of args"
+	}
+	return argStrings(args[1 : n+1]), args[n+1:], ""
+}
+
+// lmpop pops up to count elements from the first non-empty list among keys and
+// propagates the pop, as a plain LPOP or RPOP if a single element was asked for.
+// It returns nil elements if all lists are empty.
+// It must be called with DBMutex held.
+func lmpop(server *types.ServerState, keys []string, left bool, count int) (string, [][]byte, string) {
+	for _, key := range keys {
+		list, exists, errMsg := lookupObject[*types.List](server, key)
+		if errMsg != "" {
+			return "", nil, errMsg
+		}
+		if !exists {
+			continue
+		}
+
+		elems := popElems(server, key, list, left, min(count, list.Len()))
+		command := "RPOP"
+		if left {
+			command = "LPOP"
+		}
+		if count == 1 {
+			server.Propagate(command, key)
+		} else {
+			server.Propagate(command, key, strconv.Itoa(len(elems)))
+		}
+		return key, elems, ""
+	}
+	return "", nil, ""
+}
+
+func bulkArray(elems [][]byte) resp.Value {
+	values := make([]resp.Value, len(elems))
+	for i, elem := range elems {
+		values[i] = resp.BulkBytes(elem)
+	}
+	return resp.Array(values...)
+}
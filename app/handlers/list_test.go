@@ -0,0 +1,32 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/handlers"
+)
+
+func TestLPosRank(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		args         []string
+		expected     string
+	}{
+		{testCaseName: "First match", args: []string{"l", "a", "RANK", "1"}, expected: ":0\r\n"},
+		{testCaseName: "Last match", args: []string{"l", "a", "RANK", "-1"}, expected: ":2\r\n"},
+		{testCaseName: "Largest rank", args: []string{"l", "a", "RANK", "9223372036854775807"}, expected: "$-1\r\n"},
+		{testCaseName: "Smallest rank", args: []string{"l", "a", "RANK", "-9223372036854775807"}, expected: "$-1\r\n"},
+		{testCaseName: "Rank that cannot be negated", args: []string{"l", "a", "RANK", "-9223372036854775808"}, expected: "-ERR value is out of range, value must between -9223372036854775807 and 9223372036854775807\r\n"},
+		{testCaseName: "Zero rank", args: []string{"l", "a", "RANK", "0"}, expected: "-ERR RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the end of the list\r\n"},
+	}
+
+	server := newServerState()
+	run(t, server, handlers.RPush, "l", "a", "b", "a")
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			if reply := run(t, server, handlers.LPos, tc.args...); reply != tc.expected {
+				t.Fatalf("Expected %q, got %q", tc.expected, reply)
+			}
+		})
+	}
+}
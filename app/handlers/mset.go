@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+func MGet(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	values := make([]resp.Value, 0, len(args))
+	for _, key := range args {
+		// Keys holding other types are reported as missing rather than as an error
+		item, ok, errMsg := lookupString(server, string(key))
+		if !ok || errMsg != "" {
+			values = append(values, resp.Null())
+			continue
+		}
+		values = append(values, resp.BulkBytes(item.Value))
+	}
+
+	writeValue(client, resp.Array(values...))
+}
+
+func MSet(client *types.Client, server *types.ServerState, args [][]byte) {
+	if len(args)%2 != 0 {
+		writeError(client, "ERR wrong number of arguments for 'mset' command")
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	msetLocked(server, args)
+	server.PropagateArgs("MSET", args)
+	writeOK(client)
+}
+
+func MSetNX(client *types.Client, server *types.ServerState, args [][]byte) {
+	if len(args)%2 != 0 {
+		writeError(client, "ERR wrong number of arguments for 'msetnx' command")
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	for i := 0; i < len(args); i += 2 {
+		if checkIfKeyExists(string(args[i]), server) {
+			writeInteger(client, 0)
+			return
+		}
+	}
+
+	msetLocked(server, args)
+	server.PropagateArgs("MSETNX", args)
+	writeInteger(client, 1)
+}
+
+// msetLocked stores alternating keys and values. Since DBMutex is held for the whole
+// batch, no client can observe it partially applied.
+func msetLocked(server *types.ServerState, kvs [][]byte) {
+	for i := 0; i < len(kvs); i += 2 {
+		setKey(server, string(kvs[i]), kvs[i+1], -1)
+	}
+}
@@ -1,24 +1,21 @@
 package handlers
 
 import (
-	"fmt"
-	"net"
-
+	"github.com/codecrafters-io/redis-starter-go/app/types"
 	"github.com/codecrafters-io/redis-starter-go/resp"
 )
 
-func Ping(con net.Conn, isMasterCommand bool) {
-	if isMasterCommand {
-		// If the PING command is from the master, we should not reply
-		// The master is only sending PING to check if the replica is alive
+func Ping(client *types.Client, server *types.ServerState, args [][]byte) {
+	// Replies to the master are discarded by the client, as the master
+	// is only sending PING to check if the replica is alive
+	if len(args) > 1 {
+		writeError(client, "ERR wrong number of arguments for 'ping' command")
 		return
 	}
-
-	res, err := resp.RESPHandler{}.String.Encode("PONG")
-	if err != nil {
-		fmt.Printf("Error encoding response: %s\n", err)
+	if len(args) == 1 {
+		writeValue(client, resp.BulkString(string(args[0])))
 		return
 	}
 
-	con.Write(res)
+	writeValue(client, resp.SimpleString("PONG"))
 }
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+const (
+	scanDefaultCount = 10
+	scanMaxEmptyRate = 10 // Buckets visited per requested element, bounding the work done on sparse tables
+)
+
+// scanOptions holds the arguments shared by SCAN and the commands scanning a single value
+type scanOptions struct {
+	cursor   uint64
+	pattern  string // Glob pattern elements must match, empty to match all
+	count    int    // Number of elements to aim for
+	typ      string // Type keys must have, empty to match all (SCAN only)
+	noValues bool   // Only return the fields of a hash (HSCAN only)
+}
+
+// parseScanOptions parses "cursor [MATCH pattern] [COUNT count]", along with
+// "[TYPE type]" for SCAN and "[NOVALUES]" for HSCAN.
+// It returns the error to reply with if the arguments are invalid.
+func parseScanOptions(args [][]byte, command string) (scanOptions, string) {
+	cursor, err := strconv.ParseUint(string(args[0]), 10, 64)
+	if err != nil {
+		return scanOptions{}, "ERR invalid cursor"
+	}
+
+	opts := scanOptions{cursor: cursor, count: scanDefaultCount}
+	for i := 1; i < len(args); i++ {
+		option := strings.ToUpper(string(args[i]))
+		if option == "NOVALUES" && command == "HSCAN" {
+			opts.noValues = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return scanOptions{}, errSyntax
+		}
+		i++
+
+		switch option {
+		case "MATCH":
+			opts.pattern = string(args[i])
+			if opts.pattern == "*" {
+				opts.pattern = ""
+			}
+		case "COUNT":
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil {
+				return scanOptions{}, errNotInt
+			}
+			if n < 1 {
+				return scanOptions{}, errSyntax
+			}
+			opts.count = int(min(n, int64(1<<31)))
+		case "TYPE":
+			if command != "SCAN" {
+				return scanOptions{}, errSyntax
+			}
+			opts.typ = strings.ToLower(string(args[i]))
+		default:
+			return scanOptions{}, errSyntax
+		}
+	}
+
+	return opts, ""
+}
+
+// scannable is implemented by Dict and by the values that can be walked with a cursor
+type scannable[V any] interface {
+	Scan(cursor uint64, fn func(key string, value V)) uint64
+}
+
+// scanDict walks d from cursor, calling fn for every entry it visits, until it has
+// visited about count entries or the walk is complete. It returns the cursor to
+// continue from. This is the cursor engine behind SCAN, HSCAN, SSCAN and ZSCAN.
+func scanDict[V any](d scannable[V], cursor uint64, count int, fn func(key string, value V)) uint64 {
+	visited := 0
+	maxBuckets := count * scanMaxEmptyRate
+	for {
+		cursor = d.Scan(cursor, func(key string, value V) {
+			fn(key, value)
+			visited++
+		})
+		maxBuckets--
+		if cursor == 0 || visited >= count || maxBuckets <= 0 {
+			return cursor
+		}
+	}
+}
+
+func writeScanReply(client *types.Client, cursor uint64, elems []string) {
+	writeValue(client, resp.Array(
+		resp.BulkString(strconv.FormatUint(cursor, 10)),
+		resp.BulkStrings(elems...),
+	))
+}
+
+// Scan iterates the keyspace with a cursor. Every key that exists for the whole
+// iteration is returned at least once, but keys may be returned several times.
+func Scan(client *types.Client, server *types.ServerState, args [][]byte) {
+	opts, errMsg := parseScanOptions(args, "SCAN")
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	// Collect first and filter afterwards, as filtering may delete expired keys
+	keys := []string{}
+	cursor := scanDict(server.DB, opts.cursor, opts.count, func(key string, _ types.DBItem) {
+		if opts.pattern == "" || matchGlob(opts.pattern, key) {
+			keys = append(keys, key)
+		}
+	})
+
+	matched := keys[:0]
+	for _, key := range keys {
+		item, ok := lookupItem(server, key)
+		if !ok || (opts.typ != "" && item.Type() != opts.typ) {
+			continue
+		}
+		matched = append(matched, key)
+	}
+
+	writeScanReply(client, cursor, matched)
+}
+
+// Keys returns all keys matching a glob pattern in a single reply. It walks the
+// whole keyspace while holding DBMutex, so SCAN should be preferred on large ones.
+func Keys(client *types.Client, server *types.ServerState, args [][]byte) {
+	pattern := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	keys := []string{}
+	server.DB.Range(func(key string, _ types.DBItem) bool {
+		if pattern == "*" || matchGlob(pattern, key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+
+	matched := keys[:0]
+	for _, key := range keys {
+		if checkIfKeyExists(key, server) {
+			matched = append(matched, key)
+		}
+	}
+
+	writeValue(client, resp.BulkStrings(matched...))
+}
@@ -1,54 +1,196 @@
 package handlers
 
 import (
-	"fmt"
-	"net"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/codecrafters-io/redis-starter-go/app/types"
 	"github.com/codecrafters-io/redis-starter-go/resp"
 )
 
-func Set(con net.Conn, server *types.ServerState, shouldReply bool, arr ...string) {
-	if len(arr) < 2 {
-		fmt.Println("Error: SET requires at least 2 arguments, which are the KEY and the VALUE")
-		return
+// setOptions holds the parsed options of SET key value [NX | XX] [GET] [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds | KEEPTTL]
+type setOptions struct {
+	nx, xx  bool
+	get     bool
+	keepTTL bool
+	expiry  int64 // Absolute expiry in Unix milliseconds, -1 if none was given
+}
+
+func parseSetOptions(args [][]byte, now int64) (setOptions, string) {
+	opts := setOptions{expiry: -1}
+	hasExpiry := false
+
+	for i := 0; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "NX":
+			if opts.xx {
+				return opts, errSyntax
+			}
+			opts.nx = true
+		case "XX":
+			if opts.nx {
+				return opts, errSyntax
+			}
+			opts.xx = true
+		case "GET":
+			opts.get = true
+		case "KEEPTTL":
+			if hasExpiry {
+				return opts, errSyntax
+			}
+			opts.keepTTL = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if hasExpiry || opts.keepTTL || i+1 >= len(args) {
+				return opts, errSyntax
+			}
+			expiry, errMsg := parseExpiry(strings.ToUpper(string(args[i])), string(args[i+1]), now, "set")
+			if errMsg != "" {
+				return opts, errMsg
+			}
+			opts.expiry = expiry
+			hasExpiry = true
+			i++
+		default:
+			return opts, errSyntax
+		}
 	}
 
-	expiry := int64(-1)
-	if len(arr) > 2 {
-		if arr[2] != "px" {
-			fmt.Println("Error: SET only supports px as a third argument")
-			return
+	return opts, ""
+}
+
+// parseExpiry converts the argument of an EX, PX, EXAT or PXAT option to an absolute Unix time in milliseconds
+func parseExpiry(unit string, arg string, now int64, command string) (int64, string) {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, errNotInt
+	}
+	if n <= 0 {
+		return 0, "ERR invalid expire time in '" + command + "' command"
+	}
+
+	switch unit {
+	case "EX", "EXAT":
+		if n > math.MaxInt64/1000 {
+			return 0, "ERR invalid expire time in '" + command + "' command"
 		}
-		n, err := strconv.ParseInt(arr[3], 10, 64)
-		if err != nil {
-			fmt.Println("Error: EX argument must be an integer")
-			return
+		n *= 1000
+	}
+	switch unit {
+	case "EX", "PX":
+		if n > math.MaxInt64-now {
+			return 0, "ERR invalid expire time in '" + command + "' command"
 		}
-		expiry = time.Now().UnixMilli() + n
+		n += now
+	}
+
+	return n, ""
+}
+
+func Set(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	value := args[1]
+
+	opts, errMsg := parseSetOptions(args[2:], time.Now().UnixMilli())
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
 	}
 
 	server.DBMutex.Lock()
 	defer server.DBMutex.Unlock()
 
-	key := arr[0]
-	value := arr[1]
+	old, exists := lookupItem(server, key)
+	if opts.get && exists && old.Object != nil {
+		writeError(client, errWrongType)
+		return
+	}
+
+	if (opts.nx && exists) || (opts.xx && !exists) {
+		if opts.get {
+			writeOldValue(client, old, exists)
+			return
+		}
+		writeValue(client, resp.Null())
+		return
+	}
+
+	expiry := opts.expiry
+	if opts.keepTTL && exists {
+		expiry = old.Expiry
+	}
+	setKey(server, key, value, expiry)
+	propagateSet(server, key, value, expiry)
+
+	if opts.get {
+		writeOldValue(client, old, exists)
+		return
+	}
+	writeOK(client)
+}
+
+func writeOldValue(client *types.Client, old types.DBItem, exists bool) {
+	if !exists {
+		writeValue(client, resp.Null())
+		return
+	}
+	writeValue(client, resp.BulkBytes(old.Value))
+}
+
+// setKey stores a string at key, replacing whatever value of any type was stored there.
+// It must be called with DBMutex held.
+func setKey(server *types.ServerState, key string, value []byte, expiry int64) {
+	server.SetItem(key, types.DBItem{Value: value, Expiry: expiry})
+}
+
+// propagateSet propagates the write of a string. Replicas get the expiry as an absolute
+// time, so that it does not depend on when they apply the command.
+func propagateSet(server *types.ServerState, key string, value []byte, expiry int64) {
+	if expiry != -1 {
+		server.PropagateArgs("SET", [][]byte{[]byte(key), value, []byte("PXAT"), strconv.AppendInt(nil, expiry, 10)})
+		return
+	}
+	server.PropagateArgs("SET", [][]byte{[]byte(key), value})
+}
+
+func SetNX(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
 
 	if checkIfKeyExists(key, server) {
-		fmt.Printf("Key %s already exists for a key-value pair or a stream\n", key)
+		writeInteger(client, 0)
 		return
 	}
 
-	server.DB[key] = types.DBItem{Value: value, Expiry: expiry}
+	setKey(server, key, args[1], -1)
+	propagateSet(server, key, args[1], -1)
+	writeInteger(client, 1)
+}
 
-	if shouldReply {
-		res, err := resp.RESPHandler{}.String.Encode("OK")
-		if err != nil {
-			fmt.Printf("Error encoding response: %s\n", err)
-			return
-		}
-		con.Write(res)
+func SetEX(client *types.Client, server *types.ServerState, args [][]byte) {
+	setWithExpiry(client, server, "EX", "setex", args)
+}
+
+func PSetEX(client *types.Client, server *types.ServerState, args [][]byte) {
+	setWithExpiry(client, server, "PX", "psetex", args)
+}
+
+// setWithExpiry handles SETEX and PSETEX, which take key, expire time and value
+func setWithExpiry(client *types.Client, server *types.ServerState, unit string, command string, args [][]byte) {
+	key, value := string(args[0]), args[2]
+	expiry, errMsg := parseExpiry(unit, string(args[1]), time.Now().UnixMilli(), command)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
 	}
-}
\ No newline at end of file
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	setKey(server, key, value, expiry)
+	propagateSet(server, key, value, expiry)
+	writeOK(client)
+}
@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/handlers"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// pttl returns the PTTL of key, in milliseconds
+func pttl(t *testing.T, server *types.ServerState, key string) int64 {
+	t.Helper()
+	reply := run(t, server, handlers.PTTL, key)
+	n, err := strconv.ParseInt(reply[1:len(reply)-2], 10, 64)
+	if err != nil {
+		t.Fatalf("Expected an integer reply, got %q", reply)
+	}
+	return n
+}
+
+func TestSetOptions(t *testing.T) {
+	exat := strconv.FormatInt(time.Now().Unix()+100, 10)
+	pxat := strconv.FormatInt(time.Now().UnixMilli()+100000, 10)
+
+	tests := []struct {
+		testCaseName  string
+		existing      bool // Whether k holds "old", expiring in 50 seconds, beforehand
+		args          []string
+		expected      string
+		expectedValue string
+		minTTL        int64 // Bounds of the PTTL of k afterwards
+		maxTTL        int64
+	}{
+		{testCaseName: "New key", args: []string{"new"}, expected: "+OK\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: -1, maxTTL: -1},
+		{testCaseName: "Overwrite clears the TTL", existing: true, args: []string{"new"}, expected: "+OK\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: -1, maxTTL: -1},
+		{testCaseName: "NX on a new key", args: []string{"new", "NX"}, expected: "+OK\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: -1, maxTTL: -1},
+		{testCaseName: "NX on an existing key", existing: true, args: []string{"new", "NX"}, expected: "$-1\r\n", expectedValue: "$3\r\nold\r\n", minTTL: 49000, maxTTL: 50000},
+		{testCaseName: "XX on a new key", args: []string{"new", "XX"}, expected: "$-1\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "XX on an existing key", existing: true, args: []string{"new", "xx"}, expected: "+OK\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: -1, maxTTL: -1},
+		{testCaseName: "GET on a new key", args: []string{"new", "GET"}, expected: "$-1\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: -1, maxTTL: -1},
+		{testCaseName: "GET on an existing key", existing: true, args: []string{"new", "GET"}, expected: "$3\r\nold\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: -1, maxTTL: -1},
+		{testCaseName: "NX and GET on an existing key", existing: true, args: []string{"new", "NX", "GET"}, expected: "$3\r\nold\r\n", expectedValue: "$3\r\nold\r\n", minTTL: 49000, maxTTL: 50000},
+		{testCaseName: "XX and GET on a new key", args: []string{"new", "GET", "XX"}, expected: "$-1\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "KEEPTTL on an existing key", existing: true, args: []string{"new", "KEEPTTL"}, expected: "+OK\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: 49000, maxTTL: 50000},
+		{testCaseName: "KEEPTTL on a new key", args: []string{"new", "KEEPTTL"}, expected: "+OK\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: -1, maxTTL: -1},
+		{testCaseName: "EX", existing: true, args: []string{"new", "EX", "100"}, expected: "+OK\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: 99000, maxTTL: 100000},
+		{testCaseName: "PX", args: []string{"new", "px", "100000"}, expected: "+OK\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: 99000, maxTTL: 100000},
+		{testCaseName: "EXAT", args: []string{"new", "EXAT", exat}, expected: "+OK\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: 98000, maxTTL: 100000},
+		{testCaseName: "PXAT", args: []string{"new", "PXAT", pxat}, expected: "+OK\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: 99000, maxTTL: 100000},
+		{testCaseName: "XX, GET and EX", existing: true, args: []string{"new", "XX", "GET", "EX", "100"}, expected: "$3\r\nold\r\n", expectedValue: "$3\r\nnew\r\n", minTTL: 99000, maxTTL: 100000},
+
+		{testCaseName: "NX and XX", args: []string{"new", "NX", "XX"}, expected: "-ERR syntax error\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "EX and KEEPTTL", args: []string{"new", "EX", "100", "KEEPTTL"}, expected: "-ERR syntax error\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "KEEPTTL and PX", args: []string{"new", "KEEPTTL", "PX", "100"}, expected: "-ERR syntax error\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "EX and PX", args: []string{"new", "EX", "100", "PX", "100"}, expected: "-ERR syntax error\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "EX twice", args: []string{"new", "EX", "100", "EX", "100"}, expected: "-ERR syntax error\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "EX without a time", args: []string{"new", "EX"}, expected: "-ERR syntax error\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "Unknown option", args: []string{"new", "FOO"}, expected: "-ERR syntax error\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "Time that is not an integer", args: []string{"new", "EX", "soon"}, expected: "-ERR value is not an integer or out of range\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "Zero EX", args: []string{"new", "EX", "0"}, expected: "-ERR invalid expire time in 'set' command\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "Negative PX", args: []string{"new", "PX", "-100"}, expected: "-ERR invalid expire time in 'set' command\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "Zero PXAT", existing: true, args: []string{"new", "PXAT", "0"}, expected: "-ERR invalid expire time in 'set' command\r\n", expectedValue: "$3\r\nold\r\n", minTTL: 49000, maxTTL: 50000},
+		{testCaseName: "EX overflowing when converted to milliseconds", args: []string{"new", "EX", "9223372036854776"}, expected: "-ERR invalid expire time in 'set' command\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "PX overflowing when added to now", args: []string{"new", "PX", "9223372036854775807"}, expected: "-ERR invalid expire time in 'set' command\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+		{testCaseName: "EXAT overflowing when converted to milliseconds", args: []string{"new", "EXAT", "9223372036854776"}, expected: "-ERR invalid expire time in 'set' command\r\n", expectedValue: "$-1\r\n", minTTL: -2, maxTTL: -2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			server := newServerState()
+			if tc.existing {
+				run(t, server, handlers.SetEX, "k", "50", "old")
+			}
+
+			if reply := run(t, server, handlers.Set, append([]string{"k"}, tc.args...)...); reply != tc.expected {
+				t.Fatalf("Expected %q, got %q", tc.expected, reply)
+			}
+			if reply := run(t, server, handlers.Get, "k"); reply != tc.expectedValue {
+				t.Fatalf("Expected k to hold %q, got %q", tc.expectedValue, reply)
+			}
+			if ttl := pttl(t, server, "k"); ttl < tc.minTTL || ttl > tc.maxTTL {
+				t.Fatalf("Expected a PTTL between %d and %d, got %d", tc.minTTL, tc.maxTTL, ttl)
+			}
+		})
+	}
+}
+
+func TestSetGetWrongType(t *testing.T) {
+	server := newServerState()
+	run(t, server, handlers.RPush, "k", "a")
+
+	expected := "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+	if reply := run(t, server, handlers.Set, "k", "v", "GET"); reply != expected {
+		t.Fatalf("Expected %q, got %q", expected, reply)
+	}
+	if reply := run(t, server, handlers.Set, "k", "v"); reply != "+OK\r\n" {
+		t.Fatalf("Expected SET without GET to overwrite the list, got %q", reply)
+	}
+}
@@ -0,0 +1,513 @@
+package handlers
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+// setReply replies with members as a set in RESP3, and as an array in RESP2
+func setReply(members []string) resp.Value {
+	values := make([]resp.Value, len(members))
+	for i, member := range members {
+		values[i] = resp.BulkString(member)
+	}
+	return resp.Set(values...)
+}
+
+func SAdd(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	set, exists, errMsg := lookupObject[*types.Set](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		set = types.NewSet()
+		server.SetItem(key, types.DBItem{Object: set, Expiry: -1})
+	}
+
+	var added int64
+	for _, member := range args[1:] {
+		if set.Add(string(member)) {
+			added++
+		}
+	}
+
+	if added > 0 {
+		server.PropagateArgs("SADD", args)
+	}
+	writeInteger(client, added)
+}
+
+func SRem(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	set, exists, errMsg := lookupObject[*types.Set](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+
+	var removed int64
+	for _, member := range args[1:] {
+		if set.Remove(string(member)) {
+			removed++
+		}
+	}
+	if set.Len() == 0 {
+		server.DeleteItem(key)
+	}
+
+	if removed > 0 {
+		server.PropagateArgs("SREM", args)
+	}
+	writeInteger(client, removed)
+}
+
+func SIsMember(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	set, exists, errMsg := lookupObject[*types.Set](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	if exists && set.Contains(string(args[1])) {
+		writeInteger(client, 1)
+		return
+	}
+	writeInteger(client, 0)
+}
+
+// SMIsMember replies with 1 or 0 for each member, depending on whether it is in the set
+func SMIsMember(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	set, exists, errMsg := lookupObject[*types.Set](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	results := make([]resp.Value, len(args)-1)
+	for i, member := range args[1:] {
+		results[i] = resp.Integer(0)
+		if exists && set.Contains(string(member)) {
+			results[i] = resp.Integer(1)
+		}
+	}
+	writeValue(client, resp.Array(results...))
+}
+
+func SMembers(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	set, exists, errMsg := lookupObject[*types.Set](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, setReply(nil))
+		return
+	}
+	writeValue(client, setReply(set.Members()))
+}
+
+func SCard(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	set, exists, errMsg := lookupObject[*types.Set](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+	writeInteger(client, int64(set.Len()))
+}
+
+// SPop removes and replies with a random member, or with a count with up to count
+// distinct members. The removal is propagated as SREM, as replicas would not pick
+// the same members.
+func SPop(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	if len(args) > 2 {
+		writeError(client, errSyntax)
+		return
+	}
+
+	count, hasCount := int64(1), len(args) == 2
+	if hasCount {
+		n, err := strconv.ParseInt(string(args[1]), 10, 64)
+		if err != nil || n < 0 {
+			writeError(client, "ERR value is out of range, must be positive")
+			return
+		}
+		count = n
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	set, exists, errMsg := lookupObject[*types.Set](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		if hasCount {
+			writeValue(client, setReply(nil))
+		} else {
+			writeValue(client, resp.NullBulkString())
+		}
+		return
+	}
+
+	members, _ := sampleRandom(set.Len(), int(min(count, int64(set.Len()))), func() (string, struct{}) {
+		return set.Random(), struct{}{}
+	}, func() ([]string, []struct{}) {
+		return set.Members(), make([]struct{}, set.Len())
+	})
+	for _, member := range members {
+		set.Remove(member)
+	}
+	if set.Len() == 0 {
+		server.DeleteItem(key)
+	}
+
+	if len(members) > 0 {
+		server.Propagate(append([]string{"SREM", key}, members...)...)
+	}
+	if !hasCount {
+		writeValue(client, resp.BulkString(members[0]))
+		return
+	}
+	writeValue(client, setReply(members))
+}
+
+// SRandMember replies with a random member, or with a count with up to count
+// distinct members, or exactly -count members that may repeat if count is negative
+func SRandMember(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	if len(args) > 2 {
+		writeError(client, errSyntax)
+		return
+	}
+
+	count, hasCount := int64(1), len(args) == 2
+	if hasCount {
+		n, err := strconv.ParseInt(string(args[1]), 10, 64)
+		if err != nil {
+			writeError(client, errNotInt)
+			return
+		}
+		if n < -(1<<31) || n > 1<<31 {
+			writeError(client, "ERR value is out of range")
+			return
+		}
+		count = n
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	set, exists, errMsg := lookupObject[*types.Set](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		if hasCount {
+			writeValue(client, resp.Array())
+		} else {
+			writeValue(client, resp.NullBulkString())
+		}
+		return
+	}
+
+	if !hasCount {
+		writeValue(client, resp.BulkString(set.Random()))
+		return
+	}
+
+	members, _ := sampleRandom(set.Len(), int(count), func() (string, struct{}) {
+		return set.Random(), struct{}{}
+	}, func() ([]string, []struct{}) {
+		return set.Members(), make([]struct{}, set.Len())
+	})
+	writeValue(client, resp.BulkStrings(members...))
+}
+
+// SMove moves a member from one set to another
+func SMove(client *types.Client, server *types.ServerState, args [][]byte) {
+	source, destination, member := string(args[0]), string(args[1]), string(args[2])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	src, srcExists, errMsg := lookupObject[*types.Set](server, source)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	dst, dstExists, errMsg := lookupObject[*types.Set](server, destination)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	if !srcExists || !src.Contains(member) {
+		writeInteger(client, 0)
+		return
+	}
+	if source == destination {
+		writeInteger(client, 1)
+		return
+	}
+
+	src.Remove(member)
+	if src.Len() == 0 {
+		server.DeleteItem(source)
+	}
+	if !dstExists {
+		dst = types.NewSet()
+		server.SetItem(destination, types.DBItem{Object: dst, Expiry: -1})
+	}
+	dst.Add(member)
+
+	server.Propagate("SMOVE", source, destination, member)
+	writeInteger(client, 1)
+}
+
+// lookupSets returns the sets stored at keys, with nil for keys that do not exist.
+// It must be called with DBMutex held.
+func lookupSets(server *types.ServerState, keys []string) ([]*types.Set, string) {
+	sets := make([]*types.Set, len(keys))
+	for i, key := range keys {
+		set, _, errMsg := lookupObject[*types.Set](server, key)
+		if errMsg != "" {
+			return nil, errMsg
+		}
+		sets[i] = set
+	}
+	return sets, ""
+}
+
+// setIntersection returns the members of all sets, stopping once limit members were
+// found if limit is positive. A nil set counts as an empty one.
+func setIntersection(sets []*types.Set, limit int) []string {
+	for _, set := range sets {
+		if set == nil {
+			return nil
+		}
+	}
+
+	// Walk the smallest set, so that the fewest members are looked up in the others
+	sorted := slices.Clone(sets)
+	slices.SortFunc(sorted, func(a, b *types.Set) int { return a.Len() - b.Len() })
+
+	members := []string{}
+	sorted[0].Range(func(member string) bool {
+		for _, other := range sorted[1:] {
+			if !other.Contains(member) {
+				return true
+			}
+		}
+		members = append(members, member)
+		return limit <= 0 || len(members) < limit
+	})
+	return members
+}
+
+func setUnion(sets []*types.Set) []string {
+	union := types.NewSet()
+	for _, set := range sets {
+		if set == nil {
+			continue
+		}
+		set.Range(func(member string) bool {
+			union.Add(member)
+			return true
+		})
+	}
+	return union.Members()
+}
+
+// setDifference returns the members of the first set that are in none of the others
+func setDifference(sets []*types.Set) []string {
+	if sets[0] == nil {
+		return nil
+	}
+
+	members := []string{}
+	sets[0].Range(func(member string) bool {
+		for _, other := range sets[1:] {
+			if other != nil && other.Contains(member) {
+				return true
+			}
+		}
+		members = append(members, member)
+		return true
+	})
+	return members
+}
+
+func SInter(client *types.Client, server *types.ServerState, args [][]byte) {
+	setAlgebra(client, server, argStrings(args), func(sets []*types.Set) []string { return setIntersection(sets, 0) })
+}
+
+func SUnion(client *types.Client, server *types.ServerState, args [][]byte) {
+	setAlgebra(client, server, argStrings(args), setUnion)
+}
+
+func SDiff(client *types.Client, server *types.ServerState, args [][]byte) {
+	setAlgebra(client, server, argStrings(args), setDifference)
+}
+
+func setAlgebra(client *types.Client, server *types.ServerState, keys []string, op func([]*types.Set) []string) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	sets, errMsg := lookupSets(server, keys)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	writeValue(client, setReply(op(sets)))
+}
+
+func SInterStore(client *types.Client, server *types.ServerState, args [][]byte) {
+	setAlgebraStore(client, server, "SINTERSTORE", args, func(sets []*types.Set) []string { return setIntersection(sets, 0) })
+}
+
+func SUnionStore(client *types.Client, server *types.ServerState, args [][]byte) {
+	setAlgebraStore(client, server, "SUNIONSTORE", args, setUnion)
+}
+
+func SDiffStore(client *types.Client, server *types.ServerState, args [][]byte) {
+	setAlgebraStore(client, server, "SDIFFSTORE", args, setDifference)
+}
+
+// setAlgebraStore stores the result of op at the destination, overwriting any
+// value there, or deletes the destination if the result is empty
+func setAlgebraStore(client *types.Client, server *types.ServerState, command string, args [][]byte, op func([]*types.Set) []string) {
+	destination := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	sets, errMsg := lookupSets(server, argStrings(args[1:]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	members := op(sets)
+	if len(members) == 0 {
+		if checkIfKeyExists(destination, server) {
+			server.DeleteItem(destination)
+			server.Propagate("DEL", destination)
+		}
+		writeInteger(client, 0)
+		return
+	}
+
+	result := types.NewSet()
+	for _, member := range members {
+		result.Add(member)
+	}
+	server.SetItem(destination, types.DBItem{Object: result, Expiry: -1})
+
+	server.PropagateArgs(command, args)
+	writeInteger(client, int64(len(members)))
+}
+
+// SInterCard replies with the size of the intersection, counting up to LIMIT members if given
+func SInterCard(client *types.Client, server *types.ServerState, args [][]byte) {
+	keys, rest, errMsg := parseNumKeys(args)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	limit := 0
+	switch {
+	case len(rest) == 0:
+	case len(rest) == 2 && strings.ToUpper(string(rest[0])) == "LIMIT":
+		n, err := strconv.Atoi(string(rest[1]))
+		if err != nil {
+			writeError(client, errNotInt)
+			return
+		}
+		if n < 0 {
+			writeError(client, "ERR LIMIT can't be negative")
+			return
+		}
+		limit = n
+	default:
+		writeError(client, errSyntax)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	sets, errMsg := lookupSets(server, keys)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	writeInteger(client, int64(len(setIntersection(sets, limit))))
+}
+
+func SScan(client *types.Client, server *types.ServerState, args [][]byte) {
+	opts, errMsg := parseScanOptions(args[1:], "SSCAN")
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	set, exists, errMsg := lookupObject[*types.Set](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeScanReply(client, 0, nil)
+		return
+	}
+
+	members := []string{}
+	cursor := scanDict[struct{}](set, opts.cursor, opts.count, func(member string, _ struct{}) {
+		if opts.pattern == "" || matchGlob(opts.pattern, member) {
+			members = append(members, member)
+		}
+	})
+	writeScanReply(client, cursor, members)
+}
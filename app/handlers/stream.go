@@ -0,0 +1,588 @@
+package handlers
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+const errInvalidStreamID = "ERR Invalid stream ID specified as stream command argument"
+
+// parseStreamID parses an ID given as "ms-seq", or as "ms" alone, in which case the
+// sequence number is defaultSeq
+func parseStreamID(arg string, defaultSeq uint64) (types.StreamID, bool) {
+	msPart, seqPart, hasSeq := strings.Cut(arg, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return types.StreamID{}, false
+	}
+	if !hasSeq {
+		return types.StreamID{Ms: ms, Seq: defaultSeq}, true
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return types.StreamID{}, false
+	}
+	return types.StreamID{Ms: ms, Seq: seq}, true
+}
+
+// parseStreamRangeBound parses the start or end of a range: "-" or "+" for the
+// smallest or largest ID, or an ID, which is excluded if prefixed with "(". The
+// sequence number may be omitted, to include the whole millisecond.
+func parseStreamRangeBound(arg string, start bool) (types.StreamID, string) {
+	switch arg {
+	case "-":
+		return types.StreamID{}, ""
+	case "+":
+		return types.MaxStreamID, ""
+	}
+
+	exclusive := strings.HasPrefix(arg, "(")
+	if exclusive {
+		arg = arg[1:]
+	}
+	defaultSeq := uint64(0)
+	if !start {
+		defaultSeq = types.MaxStreamID.Seq
+	}
+	id, ok := parseStreamID(arg, defaultSeq)
+	if !ok {
+		return types.StreamID{}, errInvalidStreamID
+	}
+	if !exclusive {
+		return id, ""
+	}
+
+	if start {
+		if id, ok = id.Next(); !ok {
+			return types.StreamID{}, "ERR invalid start ID for the interval"
+		}
+	} else if id, ok = id.Prev(); !ok {
+		return types.StreamID{}, "ERR invalid end ID for the interval"
+	}
+	return id, ""
+}
+
+// streamEntriesValue builds the reply for entries: an array of ID and fields pairs.
+// Entries without fields were deleted, and have nil fields.
+func streamEntriesValue(entries []types.StreamEntry) resp.Value {
+	values := make([]resp.Value, len(entries))
+	for i, e := range entries {
+		fields := resp.NullArray()
+		if e.Fields != nil {
+			fields = bulkArray(e.Fields)
+		}
+		values[i] = resp.Array(resp.BulkString(e.ID.String()), fields)
+	}
+	return resp.Array(values...)
+}
+
+// streamTrim holds the trimming options of XADD and XTRIM
+type streamTrim struct {
+	strategy string // MAXLEN or MINID, empty for no trimming
+	maxLen   int
+	minID    types.StreamID
+	approx   bool // Only remove whole nodes
+	limit    int  // Most entries removed, 0 for no limit
+}
+
+// parseStreamTrim parses "MAXLEN|MINID [=|~] threshold [LIMIT count]" at the start
+// of args, and returns the number of arguments it consumed
+func parseStreamTrim(args [][]byte) (streamTrim, int, string) {
+	trim := streamTrim{strategy: strings.ToUpper(string(args[0]))}
+	i := 1
+	if i < len(args) && (string(args[i]) == "=" || string(args[i]) == "~") {
+		trim.approx = string(args[i]) == "~"
+		i++
+	}
+	if i >= len(args) {
+		return streamTrim{}, 0, errSyntax
+	}
+
+	switch trim.strategy {
+	case "MAXLEN":
+		n, err := strconv.Atoi(string(args[i]))
+		if err != nil {
+			return streamTrim{}, 0, errNotInt
+		}
+		if n < 0 {
+			return streamTrim{}, 0, "ERR The MAXLEN argument must be >= 0."
+		}
+		trim.maxLen = n
+	case "MINID":
+		id, ok := parseStreamID(string(args[i]), 0)
+		if !ok {
+			return streamTrim{}, 0, errInvalidStreamID
+		}
+		trim.minID = id
+	}
+	i++
+
+	if i+1 < len(args) && strings.ToUpper(string(args[i])) == "LIMIT" {
+		n, err := strconv.Atoi(string(args[i+1]))
+		if err != nil {
+			return streamTrim{}, 0, errNotInt
+		}
+		if n < 0 {
+			return streamTrim{}, 0, "ERR The LIMIT argument must be >= 0."
+		}
+		if !trim.approx {
+			return streamTrim{}, 0, "ERR syntax error, LIMIT cannot be used without the special ~ option"
+		}
+		trim.limit = n
+		i += 2
+	}
+	return trim, i, ""
+}
+
+// apply trims the stream stored at key and returns the number of entries removed.
+// The trimming is propagated as an exact MAXLEN, so that replicas end up with the
+// same entries however their nodes are laid out. It must be called with DBMutex held.
+func (trim streamTrim) apply(server *types.ServerState, key string, stream *types.Stream) int {
+	var removed int
+	switch trim.strategy {
+	case "MAXLEN":
+		removed = stream.TrimMaxLen(trim.maxLen, trim.approx, trim.limit)
+	case "MINID":
+		removed = stream.TrimMinID(trim.minID, trim.approx, trim.limit)
+	}
+	if removed > 0 {
+		server.Propagate("XTRIM", key, "MAXLEN", "=", strconv.Itoa(stream.Len()))
+	}
+	return removed
+}
+
+// nextStreamID returns the ID of an entry added to the stream with the given ID
+// argument: "*" to generate it from the current time, "ms-*" to generate the
+// sequence number only, or an explicit ID, which must be greater than the last one
+func nextStreamID(stream *types.Stream, arg string) (types.StreamID, string) {
+	last := stream.LastID()
+	if arg == "*" {
+		if now := uint64(time.Now().UnixMilli()); now > last.Ms {
+			return types.StreamID{Ms: now}, ""
+		}
+		id, ok := last.Next()
+		if !ok {
+			return types.StreamID{}, "ERR The stream has exhausted the last possible ID, unable to add more items"
+		}
+		return id, ""
+	}
+
+	var id types.StreamID
+	if msPart, ok := strings.CutSuffix(arg, "-*"); ok {
+		ms, err := strconv.ParseUint(msPart, 10, 64)
+		if err != nil {
+			return types.StreamID{}, errInvalidStreamID
+		}
+		id.Ms = ms
+		if ms == last.Ms {
+			if id, ok = last.Next(); !ok || id.Ms != ms {
+				return types.StreamID{}, "ERR The ID specified in XADD is equal or smaller than the target stream top item"
+			}
+		}
+	} else if id, ok = parseStreamID(arg, 0); !ok {
+		return types.StreamID{}, errInvalidStreamID
+	}
+
+	if id == (types.StreamID{}) {
+		return types.StreamID{}, "ERR The ID specified in XADD must be greater than 0-0"
+	}
+	if id.Compare(last) <= 0 {
+		return types.StreamID{}, "ERR The ID specified in XADD is equal or smaller than the target stream top item"
+	}
+	return id, ""
+}
+
+// XAdd appends an entry to a stream, creating it unless NOMKSTREAM is given, and
+// replies with its ID. The entry is propagated with its ID, followed by the
+// trimming if any entries were removed.
+func XAdd(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	noMkStream, trim := false, streamTrim{}
+
+	i := 1
+options:
+	for i < len(args) {
+		switch strings.ToUpper(string(args[i])) {
+		case "NOMKSTREAM":
+			noMkStream = true
+			i++
+		case "MAXLEN", "MINID":
+			var n int
+			var errMsg string
+			trim, n, errMsg = parseStreamTrim(args[i:])
+			if errMsg != "" {
+				writeError(client, errMsg)
+				return
+			}
+			i += n
+		default:
+			break options
+		}
+	}
+
+	fields := args[min(i+1, len(args)):]
+	if i >= len(args) || len(fields) == 0 || len(fields)%2 != 0 {
+		writeError(client, "ERR wrong number of arguments for 'xadd' command")
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		if noMkStream {
+			writeValue(client, resp.NullBulkString())
+			return
+		}
+		stream = types.NewStream()
+	}
+
+	id, errMsg := nextStreamID(stream, string(args[i]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	stream.Add(id, fields)
+	if exists {
+		server.SignalKeyReady(key)
+	} else {
+		server.SetItem(key, types.DBItem{Object: stream, Expiry: -1})
+	}
+
+	server.PropagateArgs("XADD", append([][]byte{[]byte(key), []byte(id.String())}, fields...))
+	trim.apply(server, key, stream)
+	writeValue(client, resp.BulkString(id.String()))
+}
+
+func XLen(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+	writeInteger(client, int64(stream.Len()))
+}
+
+func XRange(client *types.Client, server *types.ServerState, args [][]byte) {
+	xrange(client, server, string(args[0]), string(args[1]), string(args[2]), args[3:], false)
+}
+
+// XRevRange is XRANGE from the end, with the bounds given from end to start
+func XRevRange(client *types.Client, server *types.ServerState, args [][]byte) {
+	xrange(client, server, string(args[0]), string(args[2]), string(args[1]), args[3:], true)
+}
+
+func xrange(client *types.Client, server *types.ServerState, key, startArg, endArg string, options [][]byte, reverse bool) {
+	start, errMsg := parseStreamRangeBound(startArg, true)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	end, errMsg := parseStreamRangeBound(endArg, false)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	count := -1
+	switch {
+	case len(options) == 0:
+	case len(options) == 2 && strings.ToUpper(string(options[0])) == "COUNT":
+		n, err := strconv.Atoi(string(options[1]))
+		if err != nil {
+			writeError(client, errNotInt)
+			return
+		}
+		count = max(n, 0)
+	default:
+		writeError(client, errSyntax)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.Array())
+		return
+	}
+	writeValue(client, streamEntriesValue(stream.Range(start, end, reverse, count)))
+}
+
+// XDel deletes entries by ID and replies with the number of entries deleted
+func XDel(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	ids := make([]types.StreamID, len(args)-1)
+	for i, arg := range args[1:] {
+		id, ok := parseStreamID(string(arg), 0)
+		if !ok {
+			writeError(client, errInvalidStreamID)
+			return
+		}
+		ids[i] = id
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+
+	var deleted int64
+	for _, id := range ids {
+		if stream.Delete(id) {
+			deleted++
+		}
+	}
+
+	if deleted > 0 {
+		server.PropagateArgs("XDEL", args)
+	}
+	writeInteger(client, deleted)
+}
+
+// XTrim trims a stream and replies with the number of entries removed
+func XTrim(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	strategy := strings.ToUpper(string(args[1]))
+	if strategy != "MAXLEN" && strategy != "MINID" {
+		writeError(client, errSyntax)
+		return
+	}
+	trim, n, errMsg := parseStreamTrim(args[1:])
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if n != len(args)-1 {
+		writeError(client, errSyntax)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+	writeInteger(client, int64(trim.apply(server, key, stream)))
+}
+
+// xreadValue builds the reply of XREAD: a map from each key to its entries in
+// RESP3, and an array of key and entries pairs in RESP2
+func xreadValue(client *types.Client, keys []string, entries [][]types.StreamEntry) resp.Value {
+	elems := make([]resp.Value, 0, len(keys)*2)
+	for i, key := range keys {
+		if client.Protocol == 3 {
+			elems = append(elems, resp.BulkString(key), streamEntriesValue(entries[i]))
+		} else {
+			elems = append(elems, resp.Array(resp.BulkString(key), streamEntriesValue(entries[i])))
+		}
+	}
+	if client.Protocol == 3 {
+		return resp.Map(elems...)
+	}
+	return resp.Array(elems...)
+}
+
+// xreadArgs holds the parsed arguments of XREAD and XREADGROUP
+type xreadArgs struct {
+	group    string // Group and consumer reading, for XREADGROUP
+	consumer string
+	count    int // Most entries returned per stream, negative for no limit
+	block    bool
+	timeout  time.Duration // How long to block for, 0 to block forever
+	noAck    bool          // Do not add the entries to the pending entries list
+	keys     []string
+	ids      []string
+}
+
+// parseXRead parses "[COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id
+// [id ...]", along with "GROUP group consumer" and "[NOACK]" for XREADGROUP
+func parseXRead(args [][]byte, command string) (xreadArgs, string) {
+	opts := xreadArgs{count: -1}
+	group := command == "XREADGROUP"
+
+	i := 0
+	for ; i < len(args) && strings.ToUpper(string(args[i])) != "STREAMS"; i++ {
+		option := strings.ToUpper(string(args[i]))
+		if option == "NOACK" && group {
+			opts.noAck = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return xreadArgs{}, errSyntax
+		}
+		i++
+
+		switch option {
+		case "COUNT":
+			n, err := strconv.Atoi(string(args[i]))
+			if err != nil {
+				return xreadArgs{}, errNotInt
+			}
+			if n > 0 {
+				opts.count = n
+			}
+		case "BLOCK":
+			ms, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil {
+				return xreadArgs{}, "ERR timeout is not an integer or out of range"
+			}
+			if ms < 0 {
+				return xreadArgs{}, "ERR timeout is negative"
+			}
+			opts.block, opts.timeout = true, time.Duration(ms)*time.Millisecond
+		case "GROUP":
+			if !group || i+1 >= len(args) {
+				return xreadArgs{}, errSyntax
+			}
+			opts.group, opts.consumer = string(args[i]), string(args[i+1])
+			i++
+		default:
+			return xreadArgs{}, errSyntax
+		}
+	}
+
+	if i >= len(args) {
+		return xreadArgs{}, errSyntax
+	}
+	if group && opts.group == "" {
+		return xreadArgs{}, "ERR Missing GROUP option for XREADGROUP"
+	}
+	streams := args[i+1:]
+	if len(streams) == 0 || len(streams)%2 != 0 {
+		return xreadArgs{}, "ERR Unbalanced '" + strings.ToLower(command) + "' list of streams: for each stream key an ID or '$' must be specified."
+	}
+	opts.keys, opts.ids = argStrings(streams[:len(streams)/2]), argStrings(streams[len(streams)/2:])
+	return opts, ""
+}
+
+// XRead replies with the entries added to each stream after the given ID, "$"
+// standing for the last ID of the stream. With BLOCK, it waits up to the given
+// number of milliseconds, or forever if 0, for entries to be added if there are none.
+func XRead(client *types.Client, server *types.ServerState, args [][]byte) {
+	opts, errMsg := parseXRead(args, "XREAD")
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	// The entries are read after these IDs, which is "$" until it is resolved
+	ids := make([]types.StreamID, len(opts.keys))
+	for j, arg := range opts.ids {
+		if arg == "$" {
+			continue
+		}
+		if arg == ">" {
+			writeError(client, "ERR The > ID can be specified only when calling XREADGROUP using the GROUP <group> <consumer> option.")
+			return
+		}
+		id, ok := parseStreamID(arg, 0)
+		if !ok {
+			writeError(client, errInvalidStreamID)
+			return
+		}
+		ids[j] = id
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	// read returns the entries of the stream at keys[j] after ids[j]
+	read := func(j int) ([]types.StreamEntry, string) {
+		stream, exists, errMsg := lookupObject[*types.Stream](server, opts.keys[j])
+		if errMsg != "" {
+			return nil, errMsg
+		}
+		start, ok := ids[j].Next()
+		if !exists || !ok {
+			return nil, ""
+		}
+		return stream.Range(start, types.MaxStreamID, false, opts.count), ""
+	}
+
+	for j, arg := range opts.ids {
+		if arg != "$" {
+			continue
+		}
+		stream, _, errMsg := lookupObject[*types.Stream](server, opts.keys[j])
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		if stream != nil {
+			ids[j] = stream.LastID()
+		}
+	}
+
+	// Only the streams with entries are part of the reply
+	keys, entries := []string{}, [][]types.StreamEntry{}
+	for j, key := range opts.keys {
+		e, errMsg := read(j)
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		if len(e) > 0 {
+			keys, entries = append(keys, key), append(entries, e)
+		}
+	}
+	if len(keys) > 0 {
+		writeValue(client, xreadValue(client, keys, entries))
+		return
+	}
+	if !opts.block {
+		writeValue(client, resp.NullArray())
+		return
+	}
+
+	reply, ok := blockClient(client, server, opts.keys, opts.timeout, func(key string) (resp.Value, bool) {
+		e, _ := read(slices.Index(opts.keys, key))
+		if len(e) == 0 {
+			return resp.Value{}, false
+		}
+		return xreadValue(client, []string{key}, [][]types.StreamEntry{e}), true
+	})
+	if !ok {
+		writeValue(client, resp.NullArray())
+		return
+	}
+	writeValue(client, reply)
+}
@@ -0,0 +1,1076 @@
+package handlers
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+const errNoStreamKey = "ERR The XGROUP subcommand requires the key to exist. Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically."
+
+func errNoGroup(key, group string) string {
+	return "NOGROUP No such consumer group '" + group + "' for key name '" + key + "'"
+}
+
+func errNoKeyOrGroup(key, group string) string {
+	return "NOGROUP No such key '" + key + "' or consumer group '" + group + "'"
+}
+
+// checkSubcommandArgs reports whether a subcommand was given between minArgs and
+// maxArgs arguments, not counting its name, and replies with an error otherwise
+func checkSubcommandArgs(client *types.Client, name string, args [][]byte, minArgs, maxArgs int) bool {
+	if len(args) < minArgs || len(args) > maxArgs {
+		writeError(client, "ERR wrong number of arguments for '"+name+"' command")
+		return false
+	}
+	return true
+}
+
+// lookupStreamGroup returns the stream stored at key and its group with the given
+// name. It reports false if either does not exist, and returns the error to reply
+// with if the key holds a value of another type. It must be called with DBMutex held.
+func lookupStreamGroup(server *types.ServerState, key, name string) (*types.Stream, *types.StreamGroup, bool, string) {
+	stream, exists, errMsg := lookupObject[*types.Stream](server, key)
+	if errMsg != "" || !exists {
+		return nil, nil, false, errMsg
+	}
+	group, ok := stream.Group(name)
+	return stream, group, ok, ""
+}
+
+// parseEntriesRead parses the argument of the ENTRIESREAD option
+func parseEntriesRead(arg string) (int64, string) {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, errNotInt
+	}
+	if n < -1 {
+		return 0, "ERR value for ENTRIESREAD must be positive or -1"
+	}
+	return n, ""
+}
+
+// XGroup manages the consumer groups of a stream and their consumers, through the
+// CREATE, SETID, DESTROY, CREATECONSUMER and DELCONSUMER subcommands
+func XGroup(client *types.Client, server *types.ServerState, args [][]byte) {
+	subcommand, rest := strings.ToUpper(string(args[0])), args[1:]
+	switch subcommand {
+	case "CREATE":
+		if checkSubcommandArgs(client, "xgroup|create", rest, 3, 6) {
+			xgroupCreate(client, server, rest)
+		}
+	case "SETID":
+		if checkSubcommandArgs(client, "xgroup|setid", rest, 3, 5) {
+			xgroupSetID(client, server, rest)
+		}
+	case "DESTROY":
+		if checkSubcommandArgs(client, "xgroup|destroy", rest, 2, 2) {
+			xgroupDestroy(client, server, rest)
+		}
+	case "CREATECONSUMER":
+		if checkSubcommandArgs(client, "xgroup|createconsumer", rest, 3, 3) {
+			xgroupCreateConsumer(client, server, rest)
+		}
+	case "DELCONSUMER":
+		if checkSubcommandArgs(client, "xgroup|delconsumer", rest, 3, 3) {
+			xgroupDelConsumer(client, server, rest)
+		}
+	default:
+		writeError(client, "ERR unknown subcommand '"+string(args[0])+"'. Try XGROUP HELP.")
+	}
+}
+
+// xgroupCreate creates a group that reads the entries after the given ID, or after
+// the last entry for "$". MKSTREAM creates an empty stream if the key does not exist.
+func xgroupCreate(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, name := string(args[0]), string(args[1])
+	mkStream, entriesRead := false, int64(-1)
+	for i := 3; i < len(args); i++ {
+		switch option := strings.ToUpper(string(args[i])); {
+		case option == "MKSTREAM":
+			mkStream = true
+		case option == "ENTRIESREAD" && i+1 < len(args):
+			i++
+			n, errMsg := parseEntriesRead(string(args[i]))
+			if errMsg != "" {
+				writeError(client, errMsg)
+				return
+			}
+			entriesRead = n
+		default:
+			writeError(client, errSyntax)
+			return
+		}
+	}
+
+	var id types.StreamID
+	if string(args[2]) != "$" {
+		var ok bool
+		if id, ok = parseStreamID(string(args[2]), 0); !ok {
+			writeError(client, errInvalidStreamID)
+			return
+		}
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		if !mkStream {
+			writeError(client, errNoStreamKey)
+			return
+		}
+		stream = types.NewStream()
+		server.SetItem(key, types.DBItem{Object: stream, Expiry: -1})
+	}
+	if string(args[2]) == "$" {
+		id = stream.LastID()
+	}
+
+	if !stream.CreateGroup(name, id, entriesRead) {
+		writeError(client, "BUSYGROUP Consumer Group name already exists")
+		return
+	}
+
+	server.PropagateArgs("XGROUP", append([][]byte{[]byte("CREATE")}, args...))
+	writeOK(client)
+}
+
+// xgroupSetID sets the last entry delivered to a group
+func xgroupSetID(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, name := string(args[0]), string(args[1])
+	entriesRead := int64(-1)
+	switch {
+	case len(args) == 3:
+	case len(args) == 5 && strings.ToUpper(string(args[3])) == "ENTRIESREAD":
+		n, errMsg := parseEntriesRead(string(args[4]))
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		entriesRead = n
+	default:
+		writeError(client, errSyntax)
+		return
+	}
+
+	var id types.StreamID
+	if string(args[2]) != "$" {
+		var ok bool
+		if id, ok = parseStreamID(string(args[2]), 0); !ok {
+			writeError(client, errInvalidStreamID)
+			return
+		}
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeError(client, errNoStreamKey)
+		return
+	}
+	group, ok := stream.Group(name)
+	if !ok {
+		writeError(client, errNoGroup(key, name))
+		return
+	}
+
+	if string(args[2]) == "$" {
+		id = stream.LastID()
+	}
+	group.LastID, group.EntriesRead = id, entriesRead
+
+	server.PropagateArgs("XGROUP", append([][]byte{[]byte("SETID")}, args...))
+	writeOK(client)
+}
+
+func xgroupDestroy(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, name := string(args[0]), string(args[1])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeError(client, errNoStreamKey)
+		return
+	}
+
+	if !stream.DestroyGroup(name) {
+		writeInteger(client, 0)
+		return
+	}
+	server.Propagate("XGROUP", "DESTROY", key, name)
+	writeInteger(client, 1)
+}
+
+func xgroupCreateConsumer(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, name, consumer := string(args[0]), string(args[1]), string(args[2])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	_, group, ok, errMsg := lookupStreamGroup(server, key, name)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !ok {
+		writeError(client, errNoGroup(key, name))
+		return
+	}
+
+	if _, created := group.CreateConsumer(consumer, time.Now().UnixMilli()); !created {
+		writeInteger(client, 0)
+		return
+	}
+	server.Propagate("XGROUP", "CREATECONSUMER", key, name, consumer)
+	writeInteger(client, 1)
+}
+
+// xgroupDelConsumer deletes a consumer and replies with the number of entries that
+// were pending for it, which are no longer pending for the group
+func xgroupDelConsumer(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, name, consumer := string(args[0]), string(args[1]), string(args[2])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	_, group, ok, errMsg := lookupStreamGroup(server, key, name)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !ok {
+		writeError(client, errNoGroup(key, name))
+		return
+	}
+
+	pending, deleted := group.DeleteConsumer(consumer)
+	if deleted {
+		server.Propagate("XGROUP", "DELCONSUMER", key, name, consumer)
+	}
+	writeInteger(client, int64(pending))
+}
+
+// propagateClaim propagates that an entry was delivered to a consumer as an XCLAIM
+// that sets the exact delivery time and count, as Redis does, since replicas can
+// neither pick the same time nor tell which entries a read delivered.
+// It must be called with DBMutex held.
+func propagateClaim(server *types.ServerState, key, group string, pe *types.PendingEntry) {
+	server.Propagate("XCLAIM", key, group, pe.Consumer, "0", pe.ID.String(),
+		"TIME", strconv.FormatInt(pe.DeliveryTime, 10),
+		"RETRYCOUNT", strconv.FormatInt(pe.DeliveryCount, 10),
+		"FORCE", "JUSTID")
+}
+
+// XReadGroup reads from streams through a consumer group. The ID ">" reads the
+// entries never delivered to the group, which become pending for the consumer
+// unless NOACK is given, and may block like XREAD. Any other ID reads the entries
+// pending for the consumer after that ID.
+func XReadGroup(client *types.Client, server *types.ServerState, args [][]byte) {
+	opts, errMsg := parseXRead(args, "XREADGROUP")
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	// The pending entries are read after these IDs, unless the argument is ">"
+	ids := make([]types.StreamID, len(opts.keys))
+	history := false
+	for j, arg := range opts.ids {
+		if arg == ">" {
+			continue
+		}
+		if arg == "$" {
+			writeError(client, "ERR The $ ID is meaningful only for XREAD")
+			return
+		}
+		id, ok := parseStreamID(arg, 0)
+		if !ok {
+			writeError(client, errInvalidStreamID)
+			return
+		}
+		ids[j], history = id, true
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	// read returns the entries of the stream at keys[j] for the consumer
+	read := func(j int) ([]types.StreamEntry, string) {
+		key := opts.keys[j]
+		stream, group, ok, errMsg := lookupStreamGroup(server, key, opts.group)
+		if errMsg != "" {
+			return nil, errMsg
+		}
+		if !ok {
+			return nil, errNoKeyOrGroup(key, opts.group) + " in XREADGROUP with GROUP option"
+		}
+
+		now := time.Now().UnixMilli()
+		consumer, created := group.CreateConsumer(opts.consumer, now)
+		if created {
+			server.Propagate("XGROUP", "CREATECONSUMER", key, opts.group, opts.consumer)
+		}
+		consumer.SeenTime = now
+
+		if opts.ids[j] != ">" {
+			return readPending(stream, consumer, ids[j], opts.count), ""
+		}
+
+		start, ok := group.LastID.Next()
+		if !ok {
+			return nil, ""
+		}
+		entries := stream.Range(start, types.MaxStreamID, false, opts.count)
+		if len(entries) == 0 {
+			return nil, ""
+		}
+		for _, e := range entries {
+			stream.Advance(group, e.ID)
+			if !opts.noAck {
+				propagateClaim(server, key, opts.group, group.Claim(e.ID, consumer, now, 1))
+			}
+		}
+		consumer.ActiveTime = now
+		server.Propagate("XGROUP", "SETID", key, opts.group, group.LastID.String(), "ENTRIESREAD", strconv.FormatInt(group.EntriesRead, 10))
+		return entries, ""
+	}
+
+	keys, entries := []string{}, [][]types.StreamEntry{}
+	for j, key := range opts.keys {
+		e, errMsg := read(j)
+		if errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		// Streams read from the pending entries are part of the reply even if empty
+		if len(e) > 0 || opts.ids[j] != ">" {
+			keys, entries = append(keys, key), append(entries, e)
+		}
+	}
+	if len(keys) > 0 {
+		writeValue(client, xreadValue(client, keys, entries))
+		return
+	}
+	if !opts.block || history {
+		writeValue(client, resp.NullArray())
+		return
+	}
+
+	reply, ok := blockClient(client, server, opts.keys, opts.timeout, func(key string) (resp.Value, bool) {
+		e, errMsg := read(slices.Index(opts.keys, key))
+		if errMsg != "" {
+			// The group was destroyed while the client was blocked
+			return resp.Error(errMsg), true
+		}
+		if len(e) == 0 {
+			return resp.Value{}, false
+		}
+		return xreadValue(client, []string{key}, [][]types.StreamEntry{e}), true
+	})
+	if !ok {
+		writeValue(client, resp.NullArray())
+		return
+	}
+	writeValue(client, reply)
+}
+
+// readPending returns the entries pending for consumer after the given ID, with
+// nil fields for the entries deleted from the stream since they were delivered
+func readPending(stream *types.Stream, consumer *types.StreamConsumer, after types.StreamID, count int) []types.StreamEntry {
+	entries := []types.StreamEntry{}
+	start, ok := after.Next()
+	if !ok {
+		return entries
+	}
+	consumer.Pending.Range(start, types.MaxStreamID, func(pe *types.PendingEntry) bool {
+		e, ok := stream.Get(pe.ID)
+		if !ok {
+			e = types.StreamEntry{ID: pe.ID}
+		}
+		entries = append(entries, e)
+		return len(entries) != count
+	})
+	return entries
+}
+
+// XAck acknowledges entries, removing them from the pending entries of the group,
+// and replies with the number of entries that were pending
+func XAck(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, name := string(args[0]), string(args[1])
+	ids := make([]types.StreamID, len(args)-2)
+	for i, arg := range args[2:] {
+		id, ok := parseStreamID(string(arg), 0)
+		if !ok {
+			writeError(client, errInvalidStreamID)
+			return
+		}
+		ids[i] = id
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	_, group, ok, errMsg := lookupStreamGroup(server, key, name)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !ok {
+		writeInteger(client, 0)
+		return
+	}
+
+	var acked int64
+	for _, id := range ids {
+		if group.Ack(id) {
+			acked++
+		}
+	}
+
+	if acked > 0 {
+		server.PropagateArgs("XACK", args)
+	}
+	writeInteger(client, acked)
+}
+
+// XPending replies with a summary of the pending entries of a group, or with the
+// pending entries in a range, optionally only those idle for a minimum time or
+// pending for a given consumer
+func XPending(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, name := string(args[0]), string(args[1])
+	extended := len(args) > 2
+
+	var minIdle int64
+	var start, end types.StreamID
+	count, consumerName := 0, ""
+	if extended {
+		rest := args[2:]
+		if len(rest) > 0 && strings.ToUpper(string(rest[0])) == "IDLE" {
+			if len(rest) < 2 {
+				writeError(client, errSyntax)
+				return
+			}
+			n, err := strconv.ParseInt(string(rest[1]), 10, 64)
+			if err != nil {
+				writeError(client, errNotInt)
+				return
+			}
+			minIdle, rest = n, rest[2:]
+		}
+		if len(rest) < 3 || len(rest) > 4 {
+			writeError(client, errSyntax)
+			return
+		}
+
+		var errMsg string
+		if start, errMsg = parseStreamRangeBound(string(rest[0]), true); errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		if end, errMsg = parseStreamRangeBound(string(rest[1]), false); errMsg != "" {
+			writeError(client, errMsg)
+			return
+		}
+		n, err := strconv.Atoi(string(rest[2]))
+		if err != nil {
+			writeError(client, errNotInt)
+			return
+		}
+		count = max(n, 0)
+		if len(rest) == 4 {
+			consumerName = string(rest[3])
+		}
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	_, group, ok, errMsg := lookupStreamGroup(server, key, name)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !ok {
+		writeError(client, errNoKeyOrGroup(key, name))
+		return
+	}
+
+	if !extended {
+		if group.Pending.Len() == 0 {
+			writeValue(client, resp.Array(resp.Integer(0), resp.NullBulkString(), resp.NullBulkString(), resp.NullArray()))
+			return
+		}
+
+		var first, last types.StreamID
+		group.Pending.Range(types.StreamID{}, types.MaxStreamID, func(pe *types.PendingEntry) bool {
+			if first == (types.StreamID{}) {
+				first = pe.ID
+			}
+			last = pe.ID
+			return true
+		})
+		consumers := []resp.Value{}
+		for _, consumer := range group.Consumers() {
+			if n := consumer.Pending.Len(); n > 0 {
+				consumers = append(consumers, resp.Array(resp.BulkString(consumer.Name), resp.BulkString(strconv.Itoa(n))))
+			}
+		}
+		writeValue(client, resp.Array(
+			resp.Integer(int64(group.Pending.Len())),
+			resp.BulkString(first.String()),
+			resp.BulkString(last.String()),
+			resp.Array(consumers...),
+		))
+		return
+	}
+
+	pending := group.Pending
+	if consumerName != "" {
+		consumer, ok := group.Consumer(consumerName)
+		if !ok {
+			writeValue(client, resp.Array())
+			return
+		}
+		pending = consumer.Pending
+	}
+
+	now := time.Now().UnixMilli()
+	entries := []resp.Value{}
+	if count > 0 {
+		pending.Range(start, end, func(pe *types.PendingEntry) bool {
+			idle := now - pe.DeliveryTime
+			if idle >= minIdle {
+				entries = append(entries, resp.Array(
+					resp.BulkString(pe.ID.String()),
+					resp.BulkString(pe.Consumer),
+					resp.Integer(idle),
+					resp.Integer(pe.DeliveryCount),
+				))
+			}
+			return len(entries) < count
+		})
+	}
+	writeValue(client, resp.Array(entries...))
+}
+
+// claimOptions holds the options XCLAIM and XAUTOCLAIM share
+type claimOptions struct {
+	minIdle      int64 // Only claim entries idle for at least this many milliseconds
+	deliveryTime int64
+	retryCount   int64 // Delivery count to set, -1 to increment it
+	force        bool  // Claim entries that are not pending, as long as they exist
+	justID       bool  // Reply with IDs only, and leave the delivery counts alone
+}
+
+// claim claims the entries with the given IDs for the consumer, and returns the
+// claimed entries along with the IDs of the pending entries that were found deleted
+// from the stream, which are removed from the pending entries. Both are propagated.
+// It must be called with DBMutex held.
+func claim(server *types.ServerState, key, name string, stream *types.Stream, group *types.StreamGroup, consumer *types.StreamConsumer, ids []types.StreamID, opts claimOptions, now int64) ([]types.StreamEntry, []types.StreamID) {
+	claimed, deleted := []types.StreamEntry{}, []types.StreamID{}
+	for _, id := range ids {
+		pe, pending := group.Pending.Get(id)
+		e, exists := stream.Get(id)
+		switch {
+		case pending && !exists:
+			group.Ack(id)
+			deleted = append(deleted, id)
+			continue
+		case !pending && (!opts.force || !exists):
+			continue
+		case pending && now-pe.DeliveryTime < opts.minIdle:
+			continue
+		}
+
+		// Entries made pending with FORCE count as delivered once, as in Redis
+		deliveryCount := int64(1)
+		if pending {
+			deliveryCount = pe.DeliveryCount
+		}
+		switch {
+		case opts.retryCount >= 0:
+			deliveryCount = opts.retryCount
+		case !opts.justID:
+			deliveryCount++
+		}
+		propagateClaim(server, key, name, group.Claim(id, consumer, opts.deliveryTime, deliveryCount))
+		claimed = append(claimed, e)
+	}
+
+	if len(deleted) > 0 {
+		acks := []string{"XACK", key, name}
+		for _, id := range deleted {
+			acks = append(acks, id.String())
+		}
+		server.Propagate(acks...)
+	}
+	if len(claimed) > 0 {
+		consumer.ActiveTime = now
+	}
+	return claimed, deleted
+}
+
+// claimReply replies with the claimed entries, or only their IDs with JUSTID
+func claimReply(entries []types.StreamEntry, justID bool) resp.Value {
+	if !justID {
+		return streamEntriesValue(entries)
+	}
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID.String()
+	}
+	return resp.BulkStrings(ids...)
+}
+
+// XClaim changes the consumer the given pending entries are pending for, if they
+// have been idle for at least the given time, and replies with the claimed entries
+func XClaim(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, name, consumerName := string(args[0]), string(args[1]), string(args[2])
+	minIdle, err := strconv.ParseInt(string(args[3]), 10, 64)
+	if err != nil {
+		writeError(client, "ERR Invalid min-idle-time argument for XCLAIM")
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	opts := claimOptions{minIdle: max(minIdle, 0), deliveryTime: now, retryCount: -1}
+	ids := []types.StreamID{}
+	i := 4
+	for ; i < len(args); i++ {
+		id, ok := parseStreamID(string(args[i]), 0)
+		if !ok {
+			break
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		writeError(client, errInvalidStreamID)
+		return
+	}
+
+	var lastID types.StreamID
+	hasLastID := false
+	for ; i < len(args); i++ {
+		option := strings.ToUpper(string(args[i]))
+		switch {
+		case option == "FORCE":
+			opts.force = true
+			continue
+		case option == "JUSTID":
+			opts.justID = true
+			continue
+		case i+1 >= len(args):
+			writeError(client, errSyntax)
+			return
+		}
+
+		i++
+		switch option {
+		case "IDLE", "TIME", "RETRYCOUNT":
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil {
+				writeError(client, "ERR Invalid "+option+" option argument for XCLAIM")
+				return
+			}
+			switch option {
+			case "IDLE":
+				opts.deliveryTime = now - n
+			case "TIME":
+				opts.deliveryTime = n
+			case "RETRYCOUNT":
+				opts.retryCount = n
+			}
+		case "LASTID":
+			id, ok := parseStreamID(string(args[i]), 0)
+			if !ok {
+				writeError(client, errInvalidStreamID)
+				return
+			}
+			lastID, hasLastID = id, true
+		default:
+			writeError(client, "ERR Unrecognized XCLAIM option '"+string(args[i-1])+"'")
+			return
+		}
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, group, ok, errMsg := lookupStreamGroup(server, key, name)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !ok {
+		writeError(client, errNoKeyOrGroup(key, name))
+		return
+	}
+
+	if hasLastID && lastID.Compare(group.LastID) > 0 {
+		group.LastID = lastID
+		server.Propagate("XGROUP", "SETID", key, name, lastID.String(), "ENTRIESREAD", strconv.FormatInt(group.EntriesRead, 10))
+	}
+	consumer, created := group.CreateConsumer(consumerName, now)
+	if created {
+		server.Propagate("XGROUP", "CREATECONSUMER", key, name, consumerName)
+	}
+	consumer.SeenTime = now
+
+	claimed, _ := claim(server, key, name, stream, group, consumer, ids, opts, now)
+	writeValue(client, claimReply(claimed, opts.justID))
+}
+
+// XAutoClaim claims the pending entries idle for at least the given time, scanning
+// the pending entries from start, like SCAN. It replies with the ID to continue from,
+// the claimed entries, and the IDs of the entries found deleted from the stream.
+func XAutoClaim(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, name, consumerName := string(args[0]), string(args[1]), string(args[2])
+	minIdle, err := strconv.ParseInt(string(args[3]), 10, 64)
+	if err != nil {
+		writeError(client, "ERR Invalid min-idle-time argument for XAUTOCLAIM")
+		return
+	}
+	start, errMsg := parseStreamRangeBound(string(args[4]), true)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	opts := claimOptions{minIdle: max(minIdle, 0), deliveryTime: now, retryCount: -1}
+	count := 100
+	for i := 5; i < len(args); i++ {
+		switch option := strings.ToUpper(string(args[i])); {
+		case option == "JUSTID":
+			opts.justID = true
+		case option == "COUNT" && i+1 < len(args):
+			i++
+			n, err := strconv.Atoi(string(args[i]))
+			if err != nil {
+				writeError(client, errNotInt)
+				return
+			}
+			if n < 1 || n > 1<<20 {
+				writeError(client, "ERR COUNT must be > 0")
+				return
+			}
+			count = n
+		default:
+			writeError(client, errSyntax)
+			return
+		}
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, group, ok, errMsg := lookupStreamGroup(server, key, name)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !ok {
+		writeError(client, errNoKeyOrGroup(key, name))
+		return
+	}
+
+	consumer, created := group.CreateConsumer(consumerName, now)
+	if created {
+		server.Propagate("XGROUP", "CREATECONSUMER", key, name, consumerName)
+	}
+	consumer.SeenTime = now
+
+	// At most count*10 pending entries are examined, plus one to continue from
+	attempts := count * 10
+	candidates, ids := []types.StreamID{}, []types.StreamID{}
+	group.Pending.Range(start, types.MaxStreamID, func(pe *types.PendingEntry) bool {
+		candidates = append(candidates, pe.ID)
+		return len(candidates) <= attempts
+	})
+
+	// Entries found deleted are removed rather than claimed, and do not count
+	next, claimable := types.StreamID{}, 0
+	for i, id := range candidates {
+		if i == attempts || claimable == count {
+			next = id
+			break
+		}
+		pe, _ := group.Pending.Get(id)
+		if _, exists := stream.Get(id); exists {
+			if now-pe.DeliveryTime < opts.minIdle {
+				continue
+			}
+			claimable++
+		}
+		ids = append(ids, id)
+	}
+
+	claimed, deleted := claim(server, key, name, stream, group, consumer, ids, opts, now)
+	deletedIDs := make([]string, len(deleted))
+	for i, id := range deleted {
+		deletedIDs[i] = id.String()
+	}
+	writeValue(client, resp.Array(
+		resp.BulkString(next.String()),
+		claimReply(claimed, opts.justID),
+		resp.BulkStrings(deletedIDs...),
+	))
+}
+
+// XInfo reports on a stream, its groups or the consumers of a group, through the
+// STREAM, GROUPS and CONSUMERS subcommands
+func XInfo(client *types.Client, server *types.ServerState, args [][]byte) {
+	subcommand, rest := strings.ToUpper(string(args[0])), args[1:]
+	switch subcommand {
+	case "STREAM":
+		if checkSubcommandArgs(client, "xinfo|stream", rest, 1, 4) {
+			xinfoStream(client, server, rest)
+		}
+	case "GROUPS":
+		if checkSubcommandArgs(client, "xinfo|groups", rest, 1, 1) {
+			xinfoGroups(client, server, rest)
+		}
+	case "CONSUMERS":
+		if checkSubcommandArgs(client, "xinfo|consumers", rest, 2, 2) {
+			xinfoConsumers(client, server, rest)
+		}
+	default:
+		writeError(client, "ERR unknown subcommand '"+string(args[0])+"'. Try XINFO HELP.")
+	}
+}
+
+// entriesReadValue replies with the number of entries read by a group, or nil if
+// it is unknown
+func entriesReadValue(n int64) resp.Value {
+	if n == -1 {
+		return resp.NullBulkString()
+	}
+	return resp.Integer(n)
+}
+
+func lagValue(stream *types.Stream, group *types.StreamGroup) resp.Value {
+	lag, ok := stream.Lag(group)
+	if !ok {
+		return resp.NullBulkString()
+	}
+	return resp.Integer(lag)
+}
+
+// xinfoStream describes a stream, or with FULL, its entries and groups in detail,
+// up to COUNT entries and pending entries, 10 by default and 0 for all of them
+func xinfoStream(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	full, count := false, 10
+	switch {
+	case len(args) == 1:
+	case len(args) >= 2 && strings.ToUpper(string(args[1])) == "FULL":
+		full = true
+		if len(args) == 3 || (len(args) == 4 && strings.ToUpper(string(args[2])) != "COUNT") {
+			writeError(client, errSyntax)
+			return
+		}
+		if len(args) == 4 {
+			n, err := strconv.Atoi(string(args[3]))
+			if err != nil {
+				writeError(client, errNotInt)
+				return
+			}
+			count = max(n, 0)
+		}
+	default:
+		writeError(client, errSyntax)
+		return
+	}
+	limit := count
+	if limit == 0 {
+		limit = -1
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeError(client, "ERR no such key")
+		return
+	}
+
+	var firstID types.StreamID
+	first, hasFirst := stream.First()
+	if hasFirst {
+		firstID = first.ID
+	}
+	info := []resp.Value{
+		resp.BulkString("length"), resp.Integer(int64(stream.Len())),
+		resp.BulkString("radix-tree-keys"), resp.Integer(int64(stream.NodeCount())),
+		resp.BulkString("radix-tree-nodes"), resp.Integer(int64(stream.NodeCount())),
+		resp.BulkString("last-generated-id"), resp.BulkString(stream.LastID().String()),
+		resp.BulkString("max-deleted-entry-id"), resp.BulkString(stream.MaxDeletedID().String()),
+		resp.BulkString("entries-added"), resp.Integer(stream.EntriesAdded()),
+		resp.BulkString("recorded-first-entry-id"), resp.BulkString(firstID.String()),
+	}
+
+	if !full {
+		entryValue := func(e types.StreamEntry, ok bool) resp.Value {
+			if !ok {
+				return resp.NullBulkString()
+			}
+			return streamEntriesValue([]types.StreamEntry{e}).Elems[0]
+		}
+		last, hasLast := stream.Last()
+		info = append(info,
+			resp.BulkString("groups"), resp.Integer(int64(len(stream.GroupNames()))),
+			resp.BulkString("first-entry"), entryValue(first, hasFirst),
+			resp.BulkString("last-entry"), entryValue(last, hasLast),
+		)
+		writeValue(client, resp.Map(info...))
+		return
+	}
+
+	groups := []resp.Value{}
+	for _, name := range stream.GroupNames() {
+		group, _ := stream.Group(name)
+
+		pending := []resp.Value{}
+		group.Pending.Range(types.StreamID{}, types.MaxStreamID, func(pe *types.PendingEntry) bool {
+			pending = append(pending, resp.Array(
+				resp.BulkString(pe.ID.String()),
+				resp.BulkString(pe.Consumer),
+				resp.Integer(pe.DeliveryTime),
+				resp.Integer(pe.DeliveryCount),
+			))
+			return len(pending) != limit
+		})
+
+		consumers := []resp.Value{}
+		for _, consumer := range group.Consumers() {
+			consumerPending := []resp.Value{}
+			consumer.Pending.Range(types.StreamID{}, types.MaxStreamID, func(pe *types.PendingEntry) bool {
+				consumerPending = append(consumerPending, resp.Array(
+					resp.BulkString(pe.ID.String()),
+					resp.Integer(pe.DeliveryTime),
+					resp.Integer(pe.DeliveryCount),
+				))
+				return len(consumerPending) != limit
+			})
+			consumers = append(consumers, resp.Map(
+				resp.BulkString("name"), resp.BulkString(consumer.Name),
+				resp.BulkString("seen-time"), resp.Integer(consumer.SeenTime),
+				resp.BulkString("active-time"), resp.Integer(consumer.ActiveTime),
+				resp.BulkString("pel-count"), resp.Integer(int64(consumer.Pending.Len())),
+				resp.BulkString("pending"), resp.Array(consumerPending...),
+			))
+		}
+
+		groups = append(groups, resp.Map(
+			resp.BulkString("name"), resp.BulkString(name),
+			resp.BulkString("last-delivered-id"), resp.BulkString(group.LastID.String()),
+			resp.BulkString("entries-read"), entriesReadValue(group.EntriesRead),
+			resp.BulkString("lag"), lagValue(stream, group),
+			resp.BulkString("pel-count"), resp.Integer(int64(group.Pending.Len())),
+			resp.BulkString("pending"), resp.Array(pending...),
+			resp.BulkString("consumers"), resp.Array(consumers...),
+		))
+	}
+
+	info = append(info,
+		resp.BulkString("entries"), streamEntriesValue(stream.Range(types.StreamID{}, types.MaxStreamID, false, limit)),
+		resp.BulkString("groups"), resp.Array(groups...),
+	)
+	writeValue(client, resp.Map(info...))
+}
+
+func xinfoGroups(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeError(client, "ERR no such key")
+		return
+	}
+
+	groups := []resp.Value{}
+	for _, name := range stream.GroupNames() {
+		group, _ := stream.Group(name)
+		groups = append(groups, resp.Map(
+			resp.BulkString("name"), resp.BulkString(name),
+			resp.BulkString("consumers"), resp.Integer(int64(len(group.Consumers()))),
+			resp.BulkString("pending"), resp.Integer(int64(group.Pending.Len())),
+			resp.BulkString("last-delivered-id"), resp.BulkString(group.LastID.String()),
+			resp.BulkString("entries-read"), entriesReadValue(group.EntriesRead),
+			resp.BulkString("lag"), lagValue(stream, group),
+		))
+	}
+	writeValue(client, resp.Array(groups...))
+}
+
+// xinfoConsumers lists the consumers of a group, with the time in milliseconds since
+// they were last seen, and since they last read or claimed an entry
+func xinfoConsumers(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, name := string(args[0]), string(args[1])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	stream, exists, errMsg := lookupObject[*types.Stream](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeError(client, "ERR no such key")
+		return
+	}
+	group, ok := stream.Group(name)
+	if !ok {
+		writeError(client, errNoGroup(key, name))
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	consumers := []resp.Value{}
+	for _, consumer := range group.Consumers() {
+		inactive := int64(-1)
+		if consumer.ActiveTime != -1 {
+			inactive = now - consumer.ActiveTime
+		}
+		consumers = append(consumers, resp.Map(
+			resp.BulkString("name"), resp.BulkString(consumer.Name),
+			resp.BulkString("pending"), resp.Integer(int64(consumer.Pending.Len())),
+			resp.BulkString("idle"), resp.Integer(now-consumer.SeenTime),
+			resp.BulkString("inactive"), resp.Integer(inactive),
+		))
+	}
+	writeValue(client, resp.Array(consumers...))
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+// maxStringLen mirrors Redis' proto-max-bulk-len, the largest a string value may grow to
+const maxStringLen = 512 * 1024 * 1024
+
+func Incr(client *types.Client, server *types.ServerState, args [][]byte) {
+	incrBy(client, server, "INCR", string(args[0]), 1)
+}
+
+func Decr(client *types.Client, server *types.ServerState, args [][]byte) {
+	incrBy(client, server, "DECR", string(args[0]), -1)
+}
+
+func IncrBy(client *types.Client, server *types.ServerState, args [][]byte) {
+	n, err := strconv.ParseInt(string(args[1]), 10, 64)
+	if err != nil {
+		writeError(client, errNotInt)
+		return
+	}
+	incrBy(client, server, "INCRBY", string(args[0]), n)
+}
+
+func DecrBy(client *types.Client, server *types.ServerState, args [][]byte) {
+	n, err := strconv.ParseInt(string(args[1]), 10, 64)
+	if err != nil {
+		writeError(client, errNotInt)
+		return
+	}
+	if n == math.MinInt64 {
+		writeError(client, "ERR decrement would overflow")
+		return
+	}
+	incrBy(client, server, "DECRBY", string(args[0]), -n)
+}
+
+func incrBy(client *types.Client, server *types.ServerState, command string, key string, delta int64) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, exists, errMsg := lookupString(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		item.Expiry = -1
+	}
+
+	var current int64
+	if exists {
+		// Only integers in canonical form count, so "007" or "+1" are not incremented
+		n, err := strconv.ParseInt(string(item.Value), 10, 64)
+		if err != nil || strconv.FormatInt(n, 10) != string(item.Value) {
+			writeError(client, errNotInt)
+			return
+		}
+		current = n
+	}
+
+	if (delta > 0 && current > math.MaxInt64-delta) || (delta < 0 && current < math.MinInt64-delta) {
+		writeError(client, "ERR increment or decrement would overflow")
+		return
+	}
+
+	current += delta
+	item.Value = []byte(strconv.FormatInt(current, 10))
+	server.SetItem(key, item)
+
+	switch command {
+	case "INCR", "DECR":
+		server.Propagate(command, key)
+	default:
+		server.Propagate("INCRBY", key, strconv.FormatInt(delta, 10))
+	}
+	writeInteger(client, current)
+}
+
+func IncrByFloat(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	delta, err := strconv.ParseFloat(string(args[1]), 64)
+	if err != nil || math.IsNaN(delta) || math.IsInf(delta, 0) {
+		writeError(client, "ERR value is not a valid float")
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, exists, errMsg := lookupString(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		item.Expiry = -1
+	}
+
+	var current float64
+	if exists {
+		current, err = strconv.ParseFloat(string(item.Value), 64)
+		if err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+			writeError(client, "ERR value is not a valid float")
+			return
+		}
+	}
+
+	current += delta
+	if math.IsNaN(current) || math.IsInf(current, 0) {
+		writeError(client, "ERR increment would produce NaN or Infinity")
+		return
+	}
+
+	formatted := strconv.FormatFloat(current, 'f', -1, 64)
+	item.Value = []byte(formatted)
+	server.SetItem(key, item)
+
+	// The result is propagated rather than the increment, so that replicas do not
+	// depend on their own floating point rounding
+	server.Propagate("SET", key, formatted, "KEEPTTL")
+	writeValue(client, resp.BulkString(formatted))
+}
+
+func Append(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, exists, errMsg := lookupString(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		item.Expiry = -1
+	}
+	if len(item.Value)+len(args[1]) > maxStringLen {
+		writeError(client, "ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+		return
+	}
+
+	item.Value = append(item.Value, args[1]...)
+	server.SetItem(key, item)
+
+	server.PropagateArgs("APPEND", args)
+	writeInteger(client, int64(len(item.Value)))
+}
+
+func Strlen(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, _, errMsg := lookupString(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	writeInteger(client, int64(len(item.Value)))
+}
+
+func GetRange(client *types.Client, server *types.ServerState, args [][]byte) {
+	start, err1 := strconv.ParseInt(string(args[1]), 10, 64)
+	end, err2 := strconv.ParseInt(string(args[2]), 10, 64)
+	if err1 != nil || err2 != nil {
+		writeError(client, errNotInt)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, _, errMsg := lookupString(server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	from, to, ok := normalizeRange(start, end, int64(len(item.Value)))
+	if !ok {
+		writeValue(client, resp.BulkString(""))
+		return
+	}
+	writeValue(client, resp.BulkBytes(item.Value[from:to+1]))
+}
+
+// normalizeRange converts an inclusive range, where negative indexes count from
+// the end, to valid indexes into a sequence of the given length. It returns false
+// if the range is empty.
+func normalizeRange(start, end, length int64) (int64, int64, bool) {
+	if start < 0 && end < 0 && start > end {
+		return 0, 0, false
+	}
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if end < 0 {
+		end = max(length+end, 0)
+	}
+	end = min(end, length-1)
+
+	if start > end || length == 0 {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+func SetRange(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	offset, err := strconv.ParseInt(string(args[1]), 10, 64)
+	if err != nil {
+		writeError(client, errNotInt)
+		return
+	}
+	if offset < 0 {
+		writeError(client, "ERR offset is out of range")
+		return
+	}
+	value := args[2]
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	item, exists, errMsg := lookupString(server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	// Setting an empty range neither creates nor grows the string
+	if len(value) == 0 {
+		writeInteger(client, int64(len(item.Value)))
+		return
+	}
+	// Compared without adding, which would overflow for offsets near the maximum
+	if offset > maxStringLen-int64(len(value)) {
+		writeError(client, "ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+		return
+	}
+	if !exists {
+		item.Expiry = -1
+	}
+
+	if needed := int(offset) + len(value); needed > len(item.Value) {
+		grown := make([]byte, needed)
+		copy(grown, item.Value)
+		item.Value = grown
+	}
+	copy(item.Value[offset:], value)
+	server.SetItem(key, item)
+
+	server.PropagateArgs("SETRANGE", args)
+	writeInteger(client, int64(len(item.Value)))
+}
@@ -0,0 +1,64 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/handlers"
+)
+
+func TestSetRange(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		args         []string
+		expected     string
+	}{
+		{testCaseName: "Creates the string", args: []string{"k", "2", "ab"}, expected: ":4\r\n"},
+		{testCaseName: "Overwrites within the string", args: []string{"k", "0", "xy"}, expected: ":4\r\n"},
+		{testCaseName: "Negative offset", args: []string{"k", "-1", "a"}, expected: "-ERR offset is out of range\r\n"},
+		{testCaseName: "Past the maximum size", args: []string{"k", "536870912", "a"}, expected: "-ERR string exceeds maximum allowed size (proto-max-bulk-len)\r\n"},
+		{testCaseName: "Offset that would overflow", args: []string{"k", "9223372036854775807", "1"}, expected: "-ERR string exceeds maximum allowed size (proto-max-bulk-len)\r\n"},
+		{testCaseName: "Not an integer", args: []string{"k", "x", "a"}, expected: "-ERR value is not an integer or out of range\r\n"},
+	}
+
+	server := newServerState()
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			if reply := run(t, server, handlers.SetRange, tc.args...); reply != tc.expected {
+				t.Fatalf("Expected %q, got %q", tc.expected, reply)
+			}
+		})
+	}
+
+	if reply := run(t, server, handlers.Get, "k"); reply != "$4\r\nxyab\r\n" {
+		t.Fatalf("Expected %q, got %q", "$4\r\nxyab\r\n", reply)
+	}
+}
+
+func TestIncrDecr(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		handler      handler
+		args         []string
+		expected     string
+	}{
+		{testCaseName: "DECRBY a number", handler: handlers.DecrBy, args: []string{"n", "5"}, expected: ":5\r\n"},
+		{testCaseName: "DECRBY not an integer", handler: handlers.DecrBy, args: []string{"n", "abc"}, expected: "-ERR value is not an integer or out of range\r\n"},
+		{testCaseName: "DECRBY the minimum integer", handler: handlers.DecrBy, args: []string{"n", "-9223372036854775808"}, expected: "-ERR decrement would overflow\r\n"},
+		{testCaseName: "INCR a leading zero", handler: handlers.Incr, args: []string{"zero"}, expected: "-ERR value is not an integer or out of range\r\n"},
+		{testCaseName: "INCR a plus sign", handler: handlers.Incr, args: []string{"plus"}, expected: "-ERR value is not an integer or out of range\r\n"},
+		{testCaseName: "INCR past the maximum", handler: handlers.Incr, args: []string{"max"}, expected: "-ERR increment or decrement would overflow\r\n"},
+	}
+
+	server := newServerState()
+	run(t, server, handlers.Set, "n", "10")
+	run(t, server, handlers.Set, "zero", "007")
+	run(t, server, handlers.Set, "plus", "+1")
+	run(t, server, handlers.Set, "max", "9223372036854775807")
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			if reply := run(t, server, tc.handler, tc.args...); reply != tc.expected {
+				t.Fatalf("Expected %q, got %q", tc.expected, reply)
+			}
+		})
+	}
+}
@@ -1,12 +1,139 @@
 package handlers
 
 import (
+	"math/rand"
+	"time"
+
 	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+const (
+	errSyntax    = "ERR syntax error"
+	errNotInt    = "ERR value is not an integer or out of range"
+	errWrongType = "WRONGTYPE Operation against a key holding the wrong kind of value"
 )
 
+// checkIfKeyExists reports whether a value of any type is stored at key.
+// It must be called with DBMutex held.
 func checkIfKeyExists(key string, server *types.ServerState) bool {
-	_, okString := server.DB[key]
-	_, okStream := server.Streams[key]
+	_, ok := lookupItem(server, key)
+	return ok
+}
+
+// argStrings converts command arguments to strings, for those naming keys,
+// fields or members, which are looked up by string
+func argStrings(args [][]byte) []string {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = string(arg)
+	}
+	return strs
+}
+
+// lookupItem returns the item stored at key, deleting it first if it has expired.
+// Replicas only hide expired keys, as the deletion is propagated by the master.
+// It must be called with DBMutex held.
+func lookupItem(server *types.ServerState, key string) (types.DBItem, bool) {
+	item, ok := server.DB.Get(key)
+	if !ok {
+		return types.DBItem{}, false
+	}
+
+	if item.IsExpired(time.Now().UnixMilli()) {
+		if server.Role == "master" {
+			server.DeleteItem(key)
+			server.Propagate("DEL", key)
+		}
+		return types.DBItem{}, false
+	}
+
+	return item, true
+}
+
+// lookupString returns the string stored at key. If the key holds a value of
+// another type, it returns the error to reply with.
+// It must be called with DBMutex held.
+func lookupString(server *types.ServerState, key string) (types.DBItem, bool, string) {
+	item, ok := lookupItem(server, key)
+	if ok && item.Object != nil {
+		return types.DBItem{}, false, errWrongType
+	}
+	return item, ok, ""
+}
+
+// lookupObject returns the value of type T stored at key. If the key holds a value
+// of another type, it returns the error to reply with.
+// It must be called with DBMutex held.
+func lookupObject[T types.Object](server *types.ServerState, key string) (T, bool, string) {
+	var zero T
+	item, ok := lookupItem(server, key)
+	if !ok {
+		return zero, false, ""
+	}
+
+	obj, ok := item.Object.(T)
+	if !ok {
+		return zero, false, errWrongType
+	}
+	return obj, true, ""
+}
+
+// sampleRandom picks count distinct random elements out of the n elements of a
+// collection, or -count elements that may repeat if count is negative. random
+// returns a random element, identified by its key, and all returns every element.
+func sampleRandom[T any](n, count int, random func() (string, T), all func() ([]string, []T)) ([]string, []T) {
+	keys, values := []string{}, []T{}
+
+	if count < 0 {
+		for range -count {
+			key, value := random()
+			keys, values = append(keys, key), append(values, value)
+		}
+		return keys, values
+	}
+
+	// Past a third of the collection, it is cheaper to shuffle all elements than to
+	// draw random ones until enough distinct elements were picked
+	if count*3 > n {
+		keys, values = all()
+		rand.Shuffle(len(keys), func(i, j int) {
+			keys[i], keys[j] = keys[j], keys[i]
+			values[i], values[j] = values[j], values[i]
+		})
+		count = min(count, len(keys))
+		return keys[:count], values[:count]
+	}
+
+	picked := map[string]struct{}{}
+	for len(keys) < count {
+		key, value := random()
+		if _, ok := picked[key]; ok {
+			continue
+		}
+		picked[key] = struct{}{}
+		keys, values = append(keys, key), append(values, value)
+	}
+	return keys, values
+}
+
+// writeValue writes a reply in the protocol version spoken on the connection
+func writeValue(client *types.Client, value resp.Value) {
+	if client.Protocol == 3 {
+		client.Write(value.Encode())
+		return
+	}
+	client.Write(value.EncodeRESP2())
+}
+
+func writeError(client *types.Client, msg string) {
+	writeValue(client, resp.Error(msg))
+}
+
+func writeInteger(client *types.Client, n int64) {
+	writeValue(client, resp.Integer(n))
+}
 
-	return okString || okStream
-}
\ No newline at end of file
+func writeOK(client *types.Client) {
+	writeValue(client, resp.SimpleString("OK"))
+}
@@ -0,0 +1,74 @@
+package handlers_test
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+// replyRecorder is a connection that records the replies written to it
+type replyRecorder struct {
+	net.Conn
+	replies bytes.Buffer
+}
+
+func (r *replyRecorder) Write(b []byte) (int, error) {
+	return r.replies.Write(b)
+}
+
+func newServerState() *types.ServerState {
+	return &types.ServerState{
+		DB:           types.NewDict[types.DBItem](),
+		Expires:      map[string]struct{}{},
+		FieldExpires: map[string]struct{}{},
+		Blocked:      map[string][]*types.BlockedClient{},
+		Role:         "master",
+	}
+}
+
+type handler func(client *types.Client, server *types.ServerState, args [][]byte)
+
+// run calls a handler with the given arguments, as the dispatcher does, and
+// returns the reply it wrote
+func run(t *testing.T, server *types.ServerState, h handler, args ...string) string {
+	t.Helper()
+	conn := &replyRecorder{}
+	argv := make([][]byte, len(args))
+	for i, arg := range args {
+		argv[i] = []byte(arg)
+	}
+	h(types.NewClient(conn), server, argv)
+	return conn.replies.String()
+}
+
+// addReplica attaches a replica to server, and returns a reader over the commands
+// propagated to it
+func addReplica(t *testing.T, server *types.ServerState) *resp.Reader {
+	t.Helper()
+	conn, peer := net.Pipe()
+	t.Cleanup(func() { peer.Close() })
+	peer.SetReadDeadline(time.Now().Add(time.Second))
+	server.Replicas = append(server.Replicas, types.NewReplica(conn, server))
+	return resp.NewReader(peer)
+}
+
+// expectPropagated reads the next command propagated to a replica and checks it
+func expectPropagated(t *testing.T, replica *resp.Reader, expected ...string) {
+	t.Helper()
+	args, _, err := replica.ReadCommand()
+	if err != nil {
+		t.Fatalf("Expected %q to be propagated, got %v", expected, err)
+	}
+	got := make([]string, len(args))
+	for i, arg := range args {
+		got[i] = string(arg)
+	}
+	if strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Fatalf("Expected %q to be propagated, got %q", expected, got)
+	}
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// zrangeBy is what the bounds of a ZRANGE refer to
+type zrangeBy int
+
+const (
+	byRank zrangeBy = iota
+	byScore
+	byLex
+)
+
+// zrangeSpec holds the parsed arguments of ZRANGE and the commands built on it
+type zrangeSpec struct {
+	by         zrangeBy
+	rev        bool
+	start      int // Bounds when by rank
+	stop       int
+	scores     types.ScoreRange // Bounds when by score
+	lex        types.LexRange   // Bounds when by lex
+	offset     int
+	limit      int // Most entries returned, negative for no limit
+	withScores bool
+}
+
+// parseScoreRange parses the bounds of a score range, which are exclusive when
+// prefixed with "("
+func parseScoreRange(minArg, maxArg string) (types.ScoreRange, string) {
+	var r types.ScoreRange
+	var ok1, ok2 bool
+	r.Min, r.MinExclusive, ok1 = parseScoreBound(minArg)
+	r.Max, r.MaxExclusive, ok2 = parseScoreBound(maxArg)
+	if !ok1 || !ok2 {
+		return types.ScoreRange{}, "ERR min or max is not a float"
+	}
+	return r, ""
+}
+
+func parseScoreBound(arg string) (float64, bool, bool) {
+	exclusive := strings.HasPrefix(arg, "(")
+	if exclusive {
+		arg = arg[1:]
+	}
+	score, ok := parseScore(arg)
+	return score, exclusive, ok
+}
+
+// parseLexRange parses the bounds of a lex range, which are "-", "+", or a member
+// prefixed with "[" if inclusive or "(" if exclusive
+func parseLexRange(minArg, maxArg string) (types.LexRange, string) {
+	var r types.LexRange
+	var ok1, ok2 bool
+	r.Min, ok1 = parseLexBound(minArg)
+	r.Max, ok2 = parseLexBound(maxArg)
+	if !ok1 || !ok2 {
+		return types.LexRange{}, "ERR min or max not valid string range item"
+	}
+	return r, ""
+}
+
+func parseLexBound(arg string) (types.LexBound, bool) {
+	switch {
+	case arg == "-":
+		return types.LexBound{Inf: -1}, true
+	case arg == "+":
+		return types.LexBound{Inf: 1}, true
+	case strings.HasPrefix(arg, "["):
+		return types.LexBound{Value: arg[1:]}, true
+	case strings.HasPrefix(arg, "("):
+		return types.LexBound{Value: arg[1:], Exclusive: true}, true
+	}
+	return types.LexBound{}, false
+}
+
+// parseZRange parses "start stop [BYSCORE | BYLEX] [REV] [LIMIT offset count]
+// [WITHSCORES]" into spec. The BYSCORE, BYLEX and REV options are only accepted by
+// the unified ZRANGE and ZRANGESTORE: the older commands set them in spec
+// beforehand. WITHSCORES is not accepted when the result is stored.
+func parseZRange(args [][]byte, spec zrangeSpec, unified, store bool) (zrangeSpec, string) {
+	hasLimit := false
+	spec.limit = -1
+	for i := 2; i < len(args); i++ {
+		switch option := strings.ToUpper(string(args[i])); {
+		case option == "BYSCORE" && unified:
+			spec.by = byScore
+		case option == "BYLEX" && unified:
+			spec.by = byLex
+		case option == "REV" && unified:
+			spec.rev = true
+		case option == "WITHSCORES" && !store:
+			spec.withScores = true
+		case option == "LIMIT" && i+2 < len(args):
+			offset, err1 := strconv.Atoi(string(args[i+1]))
+			limit, err2 := strconv.Atoi(string(args[i+2]))
+			if err1 != nil || err2 != nil {
+				return zrangeSpec{}, errNotInt
+			}
+			spec.offset, spec.limit, hasLimit = offset, limit, true
+			i += 2
+		default:
+			return zrangeSpec{}, errSyntax
+		}
+	}
+
+	switch {
+	case hasLimit && spec.by == byRank:
+		return zrangeSpec{}, "ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX"
+	case spec.withScores && spec.by == byLex:
+		return zrangeSpec{}, "ERR syntax error, WITHSCORES not supported in combination with BYLEX"
+	}
+
+	// In reverse, the range is given from the highest to the lowest score or member
+	minArg, maxArg := string(args[0]), string(args[1])
+	if spec.rev {
+		minArg, maxArg = maxArg, minArg
+	}
+
+	var errMsg string
+	switch spec.by {
+	case byRank:
+		start, err1 := strconv.Atoi(string(args[0]))
+		stop, err2 := strconv.Atoi(string(args[1]))
+		if err1 != nil || err2 != nil {
+			return zrangeSpec{}, errNotInt
+		}
+		spec.start, spec.stop = start, stop
+	case byScore:
+		spec.scores, errMsg = parseScoreRange(minArg, maxArg)
+	case byLex:
+		spec.lex, errMsg = parseLexRange(minArg, maxArg)
+	}
+	return spec, errMsg
+}
+
+// entries returns the entries of zset selected by spec
+func (spec zrangeSpec) entries(zset *types.ZSet) []types.ZEntry {
+	if spec.offset < 0 {
+		return []types.ZEntry{}
+	}
+
+	switch spec.by {
+	case byScore:
+		return zset.RangeByScore(spec.scores, spec.rev, spec.offset, spec.limit)
+	case byLex:
+		return zset.RangeByLex(spec.lex, spec.rev, spec.offset, spec.limit)
+	}
+
+	start, stop, ok := normalizeIndexRange(spec.start, spec.stop, zset.Len())
+	if !ok {
+		return []types.ZEntry{}
+	}
+	return zset.RangeByRank(start, stop, spec.rev)
+}
+
+// ZRange replies with a range of members by rank, score or member, depending on the
+// options, optionally in reverse order
+func ZRange(client *types.Client, server *types.ServerState, args [][]byte) {
+	zrange(client, server, args, zrangeSpec{}, true)
+}
+
+func ZRevRange(client *types.Client, server *types.ServerState, args [][]byte) {
+	zrange(client, server, args, zrangeSpec{rev: true}, false)
+}
+
+func ZRangeByScore(client *types.Client, server *types.ServerState, args [][]byte) {
+	zrange(client, server, args, zrangeSpec{by: byScore}, false)
+}
+
+func ZRevRangeByScore(client *types.Client, server *types.ServerState, args [][]byte) {
+	zrange(client, server, args, zrangeSpec{by: byScore, rev: true}, false)
+}
+
+func ZRangeByLex(client *types.Client, server *types.ServerState, args [][]byte) {
+	zrange(client, server, args, zrangeSpec{by: byLex}, false)
+}
+
+func ZRevRangeByLex(client *types.Client, server *types.ServerState, args [][]byte) {
+	zrange(client, server, args, zrangeSpec{by: byLex, rev: true}, false)
+}
+
+func zrange(client *types.Client, server *types.ServerState, args [][]byte, spec zrangeSpec, unified bool) {
+	spec, errMsg := parseZRange(args[1:], spec, unified, false)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeZEntries(client, nil, false)
+		return
+	}
+	writeZEntries(client, spec.entries(zset), spec.withScores)
+}
+
+// ZRangeStore stores the range selected like ZRANGE at the destination, and replies
+// with its size
+func ZRangeStore(client *types.Client, server *types.ServerState, args [][]byte) {
+	destination, source := string(args[0]), string(args[1])
+	spec, errMsg := parseZRange(args[2:], zrangeSpec{}, true, true)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, source)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	result := types.NewZSet()
+	if exists {
+		for _, e := range spec.entries(zset) {
+			result.Add(e.Member, e.Score)
+		}
+	}
+	storeZSet(server, destination, result, "ZRANGESTORE", args)
+	writeInteger(client, int64(result.Len()))
+}
+
+// ZCount replies with the number of members with a score in the range
+func ZCount(client *types.Client, server *types.ServerState, args [][]byte) {
+	r, errMsg := parseScoreRange(string(args[1]), string(args[2]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	zcount(client, server, string(args[0]), func(zset *types.ZSet) int { return zset.CountByScore(r) })
+}
+
+// ZLexCount replies with the number of members in the range
+func ZLexCount(client *types.Client, server *types.ServerState, args [][]byte) {
+	r, errMsg := parseLexRange(string(args[1]), string(args[2]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	zcount(client, server, string(args[0]), func(zset *types.ZSet) int { return zset.CountByLex(r) })
+}
+
+func zcount(client *types.Client, server *types.ServerState, key string, count func(*types.ZSet) int) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+	writeInteger(client, int64(count(zset)))
+}
+
+func ZRemRangeByRank(client *types.Client, server *types.ServerState, args [][]byte) {
+	zremrange(client, server, "ZREMRANGEBYRANK", args, zrangeSpec{by: byRank})
+}
+
+func ZRemRangeByScore(client *types.Client, server *types.ServerState, args [][]byte) {
+	zremrange(client, server, "ZREMRANGEBYSCORE", args, zrangeSpec{by: byScore})
+}
+
+func ZRemRangeByLex(client *types.Client, server *types.ServerState, args [][]byte) {
+	zremrange(client, server, "ZREMRANGEBYLEX", args, zrangeSpec{by: byLex})
+}
+
+// zremrange removes the members in a range and replies with how many were removed
+func zremrange(client *types.Client, server *types.ServerState, command string, args [][]byte, spec zrangeSpec) {
+	key := string(args[0])
+	spec, errMsg := parseZRange(args[1:], spec, false, true)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+
+	entries := spec.entries(zset)
+	for _, e := range entries {
+		zset.Remove(e.Member)
+	}
+	if zset.Len() == 0 {
+		server.DeleteItem(key)
+	}
+
+	if len(entries) > 0 {
+		server.PropagateArgs(command, args)
+	}
+	writeInteger(client, int64(len(entries)))
+}
@@ -0,0 +1,733 @@
+package handlers
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+const (
+	errNotFloat = "ERR value is not a valid float"
+	errNaNScore = "ERR resulting score is not a number (NaN)"
+)
+
+// parseScore parses a score, which may be "inf" or "-inf" but not NaN
+func parseScore(arg string) (float64, bool) {
+	score, err := strconv.ParseFloat(arg, 64)
+	return score, err == nil && !math.IsNaN(score)
+}
+
+// formatScore formats a score the way it is written in replies, so that replicas
+// parse back exactly the same value when it is propagated
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+	return resp.FormatDouble(score)
+}
+
+// writeZEntries replies with the members, each followed by its score if withScores
+// is set. RESP3 replies with member-score pairs rather than a flat array.
+func writeZEntries(client *types.Client, entries []types.ZEntry, withScores bool) {
+	elems := make([]resp.Value, 0, len(entries)*2)
+	for _, e := range entries {
+		switch {
+		case !withScores:
+			elems = append(elems, resp.BulkString(e.Member))
+		case client.Protocol == 3:
+			elems = append(elems, resp.Array(resp.BulkString(e.Member), resp.Double(e.Score)))
+		default:
+			elems = append(elems, resp.BulkString(e.Member), resp.Double(e.Score))
+		}
+	}
+	writeValue(client, resp.Array(elems...))
+}
+
+// ZAdd adds members with their scores, or updates the scores of existing members.
+// NX only adds, XX only updates, and GT and LT only update to a greater or lower
+// score. CH counts updated members in the reply, and INCR increments the score of a
+// single member like ZINCRBY.
+func ZAdd(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+	var nx, xx, gt, lt, ch, incr bool
+
+	i := 1
+options:
+	for ; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GT":
+			gt = true
+		case "LT":
+			lt = true
+		case "CH":
+			ch = true
+		case "INCR":
+			incr = true
+		default:
+			break options
+		}
+	}
+
+	pairs := args[i:]
+	switch {
+	case len(pairs) == 0 || len(pairs)%2 != 0:
+		writeError(client, errSyntax)
+		return
+	case nx && xx:
+		writeError(client, "ERR XX and NX options at the same time are not compatible")
+		return
+	case (gt && lt) || (nx && (gt || lt)):
+		writeError(client, "ERR GT, LT, and/or NX options at the same time are not compatible")
+		return
+	case incr && len(pairs) > 2:
+		writeError(client, "ERR INCR option supports a single increment-element pair")
+		return
+	}
+
+	scores := make([]float64, len(pairs)/2)
+	for j := range scores {
+		score, ok := parseScore(string(pairs[j*2]))
+		if !ok {
+			writeError(client, errNotFloat)
+			return
+		}
+		scores[j] = score
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		zset = types.NewZSet()
+	}
+
+	var added, updated int64
+	var score float64
+	skipped := false
+	for j := range scores {
+		member := pairs[j*2+1]
+		score = scores[j]
+		cur, ok := zset.Score(string(member))
+		if incr && ok {
+			score += cur
+			if math.IsNaN(score) {
+				writeError(client, errNaNScore)
+				return
+			}
+		}
+
+		switch {
+		case ok && (nx || (gt && score <= cur) || (lt && score >= cur)):
+			skipped = true
+		case !ok && xx:
+			skipped = true
+		case ok:
+			if score != cur {
+				zset.Add(string(member), score)
+				updated++
+			}
+		default:
+			zset.Add(string(member), score)
+			added++
+		}
+	}
+
+	if added+updated > 0 {
+		if !exists {
+			server.SetItem(key, types.DBItem{Object: zset, Expiry: -1})
+		}
+		if incr {
+			// The resulting score is propagated rather than the increment, so that
+			// replicas do not depend on their own floating point rounding
+			server.Propagate("ZADD", key, formatScore(score), string(pairs[1]))
+		} else {
+			server.PropagateArgs("ZADD", args)
+		}
+	}
+
+	switch {
+	case incr && skipped:
+		writeValue(client, resp.NullBulkString())
+	case incr:
+		writeValue(client, resp.Double(score))
+	case ch:
+		writeInteger(client, added+updated)
+	default:
+		writeInteger(client, added)
+	}
+}
+
+// ZIncrBy increments the score of a member, which is added if it does not exist.
+// The resulting score is propagated as ZADD.
+func ZIncrBy(client *types.Client, server *types.ServerState, args [][]byte) {
+	key, member := string(args[0]), string(args[2])
+	delta, ok := parseScore(string(args[1]))
+	if !ok {
+		writeError(client, errNotFloat)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	score := delta
+	if exists {
+		cur, _ := zset.Score(member)
+		score += cur
+	}
+	if math.IsNaN(score) {
+		writeError(client, errNaNScore)
+		return
+	}
+
+	if !exists {
+		zset = types.NewZSet()
+		server.SetItem(key, types.DBItem{Object: zset, Expiry: -1})
+	}
+	zset.Add(member, score)
+
+	server.Propagate("ZADD", key, formatScore(score), member)
+	writeValue(client, resp.Double(score))
+}
+
+func ZRem(client *types.Client, server *types.ServerState, args [][]byte) {
+	key := string(args[0])
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+
+	var removed int64
+	for _, member := range args[1:] {
+		if zset.Remove(string(member)) {
+			removed++
+		}
+	}
+	if zset.Len() == 0 {
+		server.DeleteItem(key)
+	}
+
+	if removed > 0 {
+		server.PropagateArgs("ZREM", args)
+	}
+	writeInteger(client, removed)
+}
+
+func ZCard(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeInteger(client, 0)
+		return
+	}
+	writeInteger(client, int64(zset.Len()))
+}
+
+func ZScore(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+
+	score, ok := zset.Score(string(args[1]))
+	if !ok {
+		writeValue(client, resp.NullBulkString())
+		return
+	}
+	writeValue(client, resp.Double(score))
+}
+
+// ZMScore replies with the score of each member, or nil for missing members
+func ZMScore(client *types.Client, server *types.ServerState, args [][]byte) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	scores := make([]resp.Value, len(args)-1)
+	for i, member := range args[1:] {
+		scores[i] = resp.NullBulkString()
+		if !exists {
+			continue
+		}
+		if score, ok := zset.Score(string(member)); ok {
+			scores[i] = resp.Double(score)
+		}
+	}
+	writeValue(client, resp.Array(scores...))
+}
+
+func ZRank(client *types.Client, server *types.ServerState, args [][]byte) {
+	zrank(client, server, args, false)
+}
+
+func ZRevRank(client *types.Client, server *types.ServerState, args [][]byte) {
+	zrank(client, server, args, true)
+}
+
+// zrank replies with the rank of a member, along with its score if WITHSCORE is given
+func zrank(client *types.Client, server *types.ServerState, args [][]byte, reverse bool) {
+	withScore := false
+	switch {
+	case len(args) == 3 && strings.ToUpper(string(args[2])) == "WITHSCORE":
+		withScore = true
+	case len(args) > 2:
+		writeError(client, errSyntax)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	var rank int
+	if exists {
+		rank, exists = zset.Rank(string(args[1]), reverse)
+	}
+	switch {
+	case !exists && withScore:
+		writeValue(client, resp.NullArray())
+	case !exists:
+		writeValue(client, resp.NullBulkString())
+	case withScore:
+		score, _ := zset.Score(string(args[1]))
+		writeValue(client, resp.Array(resp.Integer(int64(rank)), resp.Double(score)))
+	default:
+		writeInteger(client, int64(rank))
+	}
+}
+
+func ZPopMin(client *types.Client, server *types.ServerState, args [][]byte) {
+	zpop(client, server, args, false)
+}
+
+func ZPopMax(client *types.Client, server *types.ServerState, args [][]byte) {
+	zpop(client, server, args, true)
+}
+
+// zpop removes and replies with the member with the lowest or highest score, or
+// with up to count members if a count is given
+func zpop(client *types.Client, server *types.ServerState, args [][]byte, max bool) {
+	key := string(args[0])
+	if len(args) > 2 {
+		writeError(client, errSyntax)
+		return
+	}
+
+	count, hasCount := 1, len(args) == 2
+	if hasCount {
+		n, err := strconv.Atoi(string(args[1]))
+		if err != nil || n < 0 {
+			writeError(client, "ERR value is out of range, must be positive")
+			return
+		}
+		count = n
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, key)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeValue(client, resp.Array())
+		return
+	}
+
+	entries := popEntries(server, key, zset, count, max)
+	if !hasCount {
+		writeValue(client, resp.Array(resp.BulkString(entries[0].Member), resp.Double(entries[0].Score)))
+		return
+	}
+	writeZEntries(client, entries, true)
+}
+
+// popEntries pops up to count entries off the sorted set stored at key, deleting the
+// key once it is empty, and propagates the pop as ZPOPMIN or ZPOPMAX.
+// It must be called with DBMutex held.
+func popEntries(server *types.ServerState, key string, zset *types.ZSet, count int, max bool) []types.ZEntry {
+	entries := zset.Pop(count, max)
+	if zset.Len() == 0 {
+		server.DeleteItem(key)
+	}
+
+	if len(entries) > 0 {
+		command := "ZPOPMIN"
+		if max {
+			command = "ZPOPMAX"
+		}
+		server.Propagate(command, key, strconv.Itoa(len(entries)))
+	}
+	return entries
+}
+
+func BZPopMin(client *types.Client, server *types.ServerState, args [][]byte) {
+	blockingZPop(client, server, args, false)
+}
+
+func BZPopMax(client *types.Client, server *types.ServerState, args [][]byte) {
+	blockingZPop(client, server, args, true)
+}
+
+// blockingZPop pops a member from the first non-empty sorted set among the keys, or
+// blocks until one of them is added to. The reply is the key, the member and its score.
+func blockingZPop(client *types.Client, server *types.ServerState, args [][]byte, max bool) {
+	keys := argStrings(args[:len(args)-1])
+	timeout, errMsg := parseTimeout(string(args[len(args)-1]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	// tryPop pops from the first of keys holding a sorted set
+	tryPop := func(keys []string) (resp.Value, bool, string) {
+		for _, key := range keys {
+			zset, exists, errMsg := lookupObject[*types.ZSet](server, key)
+			if errMsg != "" {
+				return resp.Value{}, false, errMsg
+			}
+			if !exists {
+				continue
+			}
+			e := popEntries(server, key, zset, 1, max)[0]
+			return resp.Array(resp.BulkString(key), resp.BulkString(e.Member), resp.Double(e.Score)), true, ""
+		}
+		return resp.Value{}, false, ""
+	}
+
+	reply, ok, errMsg := tryPop(keys)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if ok {
+		writeValue(client, reply)
+		return
+	}
+
+	reply, ok = blockClient(client, server, keys, timeout, func(key string) (resp.Value, bool) {
+		reply, ok, _ := tryPop([]string{key})
+		return reply, ok
+	})
+	if !ok {
+		writeValue(client, resp.NullArray())
+		return
+	}
+	writeValue(client, reply)
+}
+
+// zsetInput is a sorted set given to ZUNIONSTORE or ZINTERSTORE. Plain sets are
+// accepted too, with every member scoring 1. Both are nil for a missing key.
+type zsetInput struct {
+	zset   *types.ZSet
+	set    *types.Set
+	weight float64
+}
+
+func (in zsetInput) len() int {
+	switch {
+	case in.zset != nil:
+		return in.zset.Len()
+	case in.set != nil:
+		return in.set.Len()
+	}
+	return 0
+}
+
+// score returns the weighted score of member
+func (in zsetInput) score(member string) (float64, bool) {
+	switch {
+	case in.zset != nil:
+		score, ok := in.zset.Score(member)
+		return in.weighted(score), ok
+	case in.set != nil && in.set.Contains(member):
+		return in.weighted(1), true
+	}
+	return 0, false
+}
+
+// rangeEntries calls fn for every member with its weighted score
+func (in zsetInput) rangeEntries(fn func(member string, score float64)) {
+	switch {
+	case in.zset != nil:
+		in.zset.Range(func(e types.ZEntry) bool {
+			fn(e.Member, in.weighted(e.Score))
+			return true
+		})
+	case in.set != nil:
+		in.set.Range(func(member string) bool {
+			fn(member, in.weighted(1))
+			return true
+		})
+	}
+}
+
+func (in zsetInput) weighted(score float64) float64 {
+	// An infinite score with a weight of 0 counts as 0, as in Redis
+	if score = score * in.weight; math.IsNaN(score) {
+		return 0
+	}
+	return score
+}
+
+// aggregateScores combines the scores of a member found in several inputs
+func aggregateScores(aggregate string, a, b float64) float64 {
+	switch aggregate {
+	case "MIN":
+		return min(a, b)
+	case "MAX":
+		return max(a, b)
+	}
+	// inf + -inf counts as 0, as in Redis
+	if sum := a + b; !math.IsNaN(sum) {
+		return sum
+	}
+	return 0
+}
+
+// parseZSetAlgebra parses "numkeys key [key ...] [WEIGHTS weight [weight ...]]
+// [AGGREGATE SUM|MIN|MAX]", followed by "[WITHSCORES]" unless the result is stored,
+// and looks up the inputs. It must be called with DBMutex held.
+func parseZSetAlgebra(server *types.ServerState, command string, args [][]byte, store bool) (inputs []zsetInput, aggregate string, withScores bool, errMsg string) {
+	numKeys, err := strconv.Atoi(string(args[0]))
+	if err != nil {
+		return nil, "", false, errNotInt
+	}
+	if numKeys < 1 {
+		return nil, "", false, "ERR at least 1 input key is needed for '" + strings.ToLower(command) + "' command"
+	}
+	if numKeys > len(args)-1 {
+		return nil, "", false, errSyntax
+	}
+
+	keys, rest := args[1:numKeys+1], args[numKeys+1:]
+	weights := make([]float64, numKeys)
+	for i := range weights {
+		weights[i] = 1
+	}
+	aggregate = "SUM"
+	for i := 0; i < len(rest); i++ {
+		switch option := strings.ToUpper(string(rest[i])); {
+		case option == "WEIGHTS" && len(rest)-i-1 >= numKeys:
+			for j := range weights {
+				weight, ok := parseScore(string(rest[i+1+j]))
+				if !ok {
+					return nil, "", false, "ERR weight value is not a float"
+				}
+				weights[j] = weight
+			}
+			i += numKeys
+		case option == "AGGREGATE" && i+1 < len(rest):
+			i++
+			aggregate = strings.ToUpper(string(rest[i]))
+			if aggregate != "SUM" && aggregate != "MIN" && aggregate != "MAX" {
+				return nil, "", false, errSyntax
+			}
+		case option == "WITHSCORES" && !store:
+			withScores = true
+		default:
+			return nil, "", false, errSyntax
+		}
+	}
+
+	inputs = make([]zsetInput, numKeys)
+	for i, key := range keys {
+		inputs[i].weight = weights[i]
+		item, ok := lookupItem(server, string(key))
+		if !ok {
+			continue
+		}
+		switch obj := item.Object.(type) {
+		case *types.ZSet:
+			inputs[i].zset = obj
+		case *types.Set:
+			inputs[i].set = obj
+		default:
+			return nil, "", false, errWrongType
+		}
+	}
+	return inputs, aggregate, withScores, ""
+}
+
+func zsetUnion(inputs []zsetInput, aggregate string) *types.ZSet {
+	result := types.NewZSet()
+	for _, in := range inputs {
+		in.rangeEntries(func(member string, score float64) {
+			if cur, ok := result.Score(member); ok {
+				score = aggregateScores(aggregate, cur, score)
+			}
+			result.Add(member, score)
+		})
+	}
+	return result
+}
+
+func zsetIntersection(inputs []zsetInput, aggregate string) *types.ZSet {
+	result := types.NewZSet()
+
+	// Walk the smallest input, so that the fewest members are looked up in the others
+	sorted := slices.Clone(inputs)
+	slices.SortFunc(sorted, func(a, b zsetInput) int { return a.len() - b.len() })
+
+	sorted[0].rangeEntries(func(member string, score float64) {
+		for _, other := range sorted[1:] {
+			otherScore, ok := other.score(member)
+			if !ok {
+				return
+			}
+			score = aggregateScores(aggregate, score, otherScore)
+		}
+		result.Add(member, score)
+	})
+	return result
+}
+
+func ZUnion(client *types.Client, server *types.ServerState, args [][]byte) {
+	zsetAlgebra(client, server, "ZUNION", args, zsetUnion)
+}
+
+func ZInter(client *types.Client, server *types.ServerState, args [][]byte) {
+	zsetAlgebra(client, server, "ZINTER", args, zsetIntersection)
+}
+
+func zsetAlgebra(client *types.Client, server *types.ServerState, command string, args [][]byte, op func([]zsetInput, string) *types.ZSet) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	inputs, aggregate, withScores, errMsg := parseZSetAlgebra(server, command, args, false)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	result := op(inputs, aggregate)
+	writeZEntries(client, result.RangeByRank(0, result.Len()-1, false), withScores)
+}
+
+func ZUnionStore(client *types.Client, server *types.ServerState, args [][]byte) {
+	zsetAlgebraStore(client, server, "ZUNIONSTORE", args, zsetUnion)
+}
+
+func ZInterStore(client *types.Client, server *types.ServerState, args [][]byte) {
+	zsetAlgebraStore(client, server, "ZINTERSTORE", args, zsetIntersection)
+}
+
+func zsetAlgebraStore(client *types.Client, server *types.ServerState, command string, args [][]byte, op func([]zsetInput, string) *types.ZSet) {
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	inputs, aggregate, _, errMsg := parseZSetAlgebra(server, command, args[1:], true)
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	result := op(inputs, aggregate)
+	storeZSet(server, string(args[0]), result, command, args)
+	writeInteger(client, int64(result.Len()))
+}
+
+// storeZSet stores result at destination, overwriting any value there, or deletes
+// the destination if result is empty. The command is propagated unless nothing changed.
+// It must be called with DBMutex held.
+func storeZSet(server *types.ServerState, destination string, result *types.ZSet, command string, args [][]byte) {
+	if result.Len() == 0 {
+		if checkIfKeyExists(destination, server) {
+			server.DeleteItem(destination)
+			server.Propagate("DEL", destination)
+		}
+		return
+	}
+
+	server.SetItem(destination, types.DBItem{Object: result, Expiry: -1})
+	server.PropagateArgs(command, args)
+}
+
+func ZScan(client *types.Client, server *types.ServerState, args [][]byte) {
+	opts, errMsg := parseScanOptions(args[1:], "ZSCAN")
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+
+	server.DBMutex.Lock()
+	defer server.DBMutex.Unlock()
+
+	zset, exists, errMsg := lookupObject[*types.ZSet](server, string(args[0]))
+	if errMsg != "" {
+		writeError(client, errMsg)
+		return
+	}
+	if !exists {
+		writeScanReply(client, 0, nil)
+		return
+	}
+
+	elems := []string{}
+	cursor := scanDict[float64](zset, opts.cursor, opts.count, func(member string, score float64) {
+		if opts.pattern == "" || matchGlob(opts.pattern, member) {
+			elems = append(elems, member, formatScore(score))
+		}
+	})
+	writeScanReply(client, cursor, elems)
+}
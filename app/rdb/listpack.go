@@ -0,0 +1,184 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+)
+
+// A listpack packs strings and integers in a single buffer: a header with its size
+// in bytes and its number of elements, the elements, and a terminating byte. Each
+// element is an encoding byte, its data, and its size in bytes to walk it backwards.
+// Redis keeps the entries of stream nodes in listpacks and saves them as is.
+const (
+	listpackHeaderSize = 6
+	listpackEnd        = 0xFF
+)
+
+var errListpack = errors.New("invalid listpack")
+
+type listpack struct {
+	elems []byte
+	count int
+}
+
+func (lp *listpack) appendInt(v int64) {
+	start := len(lp.elems)
+	switch {
+	case v >= 0 && v <= 127:
+		lp.elems = append(lp.elems, byte(v))
+	case v >= -1<<12 && v < 1<<12:
+		u := uint64(v) & (1<<13 - 1)
+		lp.elems = append(lp.elems, 0xC0|byte(u>>8), byte(u))
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		lp.elems = append(lp.elems, 0xF1)
+		lp.elems = binary.LittleEndian.AppendUint16(lp.elems, uint16(v))
+	case v >= -1<<23 && v < 1<<23:
+		lp.elems = append(lp.elems, 0xF2, byte(v), byte(v>>8), byte(v>>16))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		lp.elems = append(lp.elems, 0xF3)
+		lp.elems = binary.LittleEndian.AppendUint32(lp.elems, uint32(v))
+	default:
+		lp.elems = append(lp.elems, 0xF4)
+		lp.elems = binary.LittleEndian.AppendUint64(lp.elems, uint64(v))
+	}
+	lp.appendBacklen(len(lp.elems) - start)
+}
+
+func (lp *listpack) appendString(s []byte) {
+	start := len(lp.elems)
+	switch n := len(s); {
+	case n < 1<<6:
+		lp.elems = append(lp.elems, 0x80|byte(n))
+	case n < 1<<12:
+		lp.elems = append(lp.elems, 0xE0|byte(n>>8), byte(n))
+	default:
+		lp.elems = append(lp.elems, 0xF0)
+		lp.elems = binary.LittleEndian.AppendUint32(lp.elems, uint32(n))
+	}
+	lp.elems = append(lp.elems, s...)
+	lp.appendBacklen(len(lp.elems) - start)
+}
+
+// appendBacklen ends an element with its size, in 7 bit groups from the most
+// significant one. All but the first group have their high bit set.
+func (lp *listpack) appendBacklen(size int) {
+	n := backlenSize(size)
+	for i := n - 1; i >= 0; i-- {
+		b := byte(size>>(7*i)) & 127
+		if i != n-1 {
+			b |= 128
+		}
+		lp.elems = append(lp.elems, b)
+	}
+	lp.count++
+}
+
+func backlenSize(size int) int {
+	switch {
+	case size <= 127:
+		return 1
+	case size < 16383:
+		return 2
+	case size < 2097151:
+		return 3
+	case size < 268435455:
+		return 4
+	}
+	return 5
+}
+
+func (lp *listpack) bytes() []byte {
+	b := make([]byte, 0, listpackHeaderSize+len(lp.elems)+1)
+	b = binary.LittleEndian.AppendUint32(b, uint32(listpackHeaderSize+len(lp.elems)+1))
+	// Counts that do not fit are stored as 65535, meaning unknown
+	b = binary.LittleEndian.AppendUint16(b, uint16(min(lp.count, math.MaxUint16)))
+	b = append(b, lp.elems...)
+	return append(b, listpackEnd)
+}
+
+type listpackReader struct {
+	data []byte
+	pos  int
+}
+
+func newListpackReader(data []byte) (*listpackReader, error) {
+	if len(data) < listpackHeaderSize+1 || binary.LittleEndian.Uint32(data) != uint32(len(data)) || data[len(data)-1] != listpackEnd {
+		return nil, errListpack
+	}
+	return &listpackReader{data: data, pos: listpackHeaderSize}, nil
+}
+
+// done reports whether all the elements were read
+func (r *listpackReader) done() bool {
+	return r.data[r.pos] == listpackEnd
+}
+
+// next reads an element, which is either a string or an integer
+func (r *listpackReader) next() (s []byte, v int64, isInt bool, err error) {
+	b := r.data[r.pos:]
+	// Every element needs at least its encoding byte and a backlen byte before the end
+	if len(b) < 3 {
+		return nil, 0, false, errListpack
+	}
+
+	var size int
+	switch enc := b[0]; {
+	case enc&0x80 == 0:
+		v, isInt, size = int64(enc), true, 1
+	case enc&0xC0 == 0x80:
+		n := int(enc & 0x3F)
+		s, size = b[1:min(1+n, len(b))], 1+n
+	case enc&0xE0 == 0xC0:
+		v, isInt, size = int64(uint64(enc&0x1F)<<8|uint64(b[1]))<<51>>51, true, 2
+	case enc&0xF0 == 0xE0:
+		n := int(enc&0x0F)<<8 | int(b[1])
+		s, size = b[2:min(2+n, len(b))], 2+n
+	case enc == 0xF0 && len(b) >= 5:
+		n := int(binary.LittleEndian.Uint32(b[1:]))
+		if n < 0 || n > len(b) {
+			return nil, 0, false, errListpack
+		}
+		s, size = b[5:min(5+n, len(b))], 5+n
+	case enc == 0xF1 && len(b) >= 3:
+		v, isInt, size = int64(int16(binary.LittleEndian.Uint16(b[1:]))), true, 3
+	case enc == 0xF2 && len(b) >= 4:
+		v, isInt, size = int64(uint64(b[1])|uint64(b[2])<<8|uint64(b[3])<<16)<<40>>40, true, 4
+	case enc == 0xF3 && len(b) >= 5:
+		v, isInt, size = int64(int32(binary.LittleEndian.Uint32(b[1:]))), true, 5
+	case enc == 0xF4 && len(b) >= 9:
+		v, isInt, size = int64(binary.LittleEndian.Uint64(b[1:])), true, 9
+	default:
+		return nil, 0, false, errListpack
+	}
+
+	size += backlenSize(size)
+	if size > len(b)-1 {
+		return nil, 0, false, errListpack
+	}
+	r.pos += size
+	return s, v, isInt, nil
+}
+
+// nextInt reads an integer, which may also be stored as a string
+func (r *listpackReader) nextInt() (int64, error) {
+	s, v, isInt, err := r.next()
+	if err != nil || isInt {
+		return v, err
+	}
+	v, err = strconv.ParseInt(string(s), 10, 64)
+	if err != nil {
+		return 0, errListpack
+	}
+	return v, nil
+}
+
+// nextString reads a string, formatting integers in decimal
+func (r *listpackReader) nextString() ([]byte, error) {
+	s, v, isInt, err := r.next()
+	if isInt {
+		return strconv.AppendInt(nil, v, 10), nil
+	}
+	return s, err
+}
@@ -0,0 +1,338 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) readBytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+func (d *decoder) readByte() (byte, error) {
+	b, err := d.readBytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// readLenOrEncoding reads a length, or reports that a string is stored in a special
+// encoding, identified by the returned value
+func (d *decoder) readLenOrEncoding() (uint64, bool, error) {
+	first, err := d.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch first >> 6 {
+	case 0:
+		return uint64(first), false, nil
+	case 1:
+		next, err := d.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(first&0x3F)<<8 | uint64(next), false, nil
+	case 3:
+		return uint64(first & 0x3F), true, nil
+	}
+
+	switch first {
+	case 0x80:
+		b, err := d.readBytes(4)
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(binary.BigEndian.Uint32(b)), false, nil
+	case 0x81:
+		b, err := d.readBytes(8)
+		if err != nil {
+			return 0, false, err
+		}
+		return binary.BigEndian.Uint64(b), false, nil
+	}
+	return 0, false, fmt.Errorf("invalid length encoding 0x%02x", first)
+}
+
+func (d *decoder) readLen() (uint64, error) {
+	n, encoded, err := d.readLenOrEncoding()
+	if err == nil && encoded {
+		return 0, errors.New("unexpected string encoding")
+	}
+	return n, err
+}
+
+// readString reads a string, copied out of the file, which may be stored as an
+// 8, 16 or 32 bit integer. LZF compressed strings are not supported, as they are
+// only written when rdbcompression is enabled.
+func (d *decoder) readString() ([]byte, error) {
+	n, encoded, err := d.readLenOrEncoding()
+	if err != nil {
+		return nil, err
+	}
+	if !encoded {
+		b, err := d.readBytes(n)
+		return bytes.Clone(b), err
+	}
+
+	switch n {
+	case 0:
+		b, err := d.readBytes(1)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int8(b[0])), 10), nil
+	case 1:
+		b, err := d.readBytes(2)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int16(binary.LittleEndian.Uint16(b))), 10), nil
+	case 2:
+		b, err := d.readBytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int32(binary.LittleEndian.Uint32(b))), 10), nil
+	}
+	return nil, fmt.Errorf("unsupported string encoding %d", n)
+}
+
+func (d *decoder) readMillis() (int64, error) {
+	b, err := d.readBytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint64(b)), nil
+}
+
+func (d *decoder) readDouble() (float64, error) {
+	b, err := d.readBytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+}
+
+// Load adds the keys of an RDB file to the keyspace, skipping those that have
+// already expired. It must be called with DBMutex held.
+func Load(server *types.ServerState, data []byte) error {
+	if len(data) < 9 || string(data[:5]) != "REDIS" {
+		return errors.New("invalid RDB header")
+	}
+	version, err := strconv.Atoi(string(data[5:9]))
+	if err != nil || version < 1 || version > rdbVersion {
+		return fmt.Errorf("unsupported RDB version %q", data[5:9])
+	}
+
+	d := &decoder{data: data, pos: 9}
+	now := time.Now().UnixMilli()
+	expiry := int64(-1)
+	for {
+		op, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		switch op {
+		case opEOF:
+			// Files written without a checksum end with 8 zero bytes
+			b, err := d.readBytes(8)
+			if err != nil {
+				return err
+			}
+			if sum := binary.LittleEndian.Uint64(b); sum != 0 && sum != checksum(data[:d.pos-8]) {
+				return errors.New("wrong RDB checksum")
+			}
+			return nil
+
+		case opAux:
+			if _, err := d.readString(); err != nil {
+				return err
+			}
+			if _, err := d.readString(); err != nil {
+				return err
+			}
+
+		case opSelectDB:
+			db, err := d.readLen()
+			if err != nil {
+				return err
+			}
+			if db != 0 {
+				return fmt.Errorf("unsupported database %d", db)
+			}
+
+		case opResizeDB:
+			for range 2 {
+				if _, err := d.readLen(); err != nil {
+					return err
+				}
+			}
+
+		case opExpireTimeMs:
+			if expiry, err = d.readMillis(); err != nil {
+				return err
+			}
+
+		case opExpireTime:
+			b, err := d.readBytes(4)
+			if err != nil {
+				return err
+			}
+			expiry = int64(binary.LittleEndian.Uint32(b)) * 1000
+
+		case opIdle:
+			if _, err := d.readLen(); err != nil {
+				return err
+			}
+
+		case opFreq:
+			if _, err := d.readByte(); err != nil {
+				return err
+			}
+
+		default:
+			key, err := d.readString()
+			if err != nil {
+				return err
+			}
+			item, err := d.readItem(op)
+			if err != nil {
+				return fmt.Errorf("failed to load key %q: %w", key, err)
+			}
+			item.Expiry = expiry
+			if !item.IsExpired(now) {
+				server.SetItem(string(key), item)
+			}
+			expiry = -1
+		}
+	}
+}
+
+func (d *decoder) readItem(valueType byte) (types.DBItem, error) {
+	switch valueType {
+	case typeString:
+		value, err := d.readString()
+		return types.DBItem{Value: value}, err
+
+	case typeList:
+		n, err := d.readLen()
+		if err != nil {
+			return types.DBItem{}, err
+		}
+		list := types.NewList()
+		for range n {
+			elem, err := d.readString()
+			if err != nil {
+				return types.DBItem{}, err
+			}
+			list.PushBack(elem)
+		}
+		return types.DBItem{Object: list}, nil
+
+	case typeSet:
+		n, err := d.readLen()
+		if err != nil {
+			return types.DBItem{}, err
+		}
+		set := types.NewSet()
+		for range n {
+			member, err := d.readString()
+			if err != nil {
+				return types.DBItem{}, err
+			}
+			set.Add(string(member))
+		}
+		return types.DBItem{Object: set}, nil
+
+	case typeZSet2:
+		n, err := d.readLen()
+		if err != nil {
+			return types.DBItem{}, err
+		}
+		zset := types.NewZSet()
+		for range n {
+			member, err := d.readString()
+			if err != nil {
+				return types.DBItem{}, err
+			}
+			score, err := d.readDouble()
+			if err != nil {
+				return types.DBItem{}, err
+			}
+			zset.Add(string(member), score)
+		}
+		return types.DBItem{Object: zset}, nil
+
+	case typeHash, typeHashMetadata:
+		hash, err := d.readHash(valueType == typeHashMetadata)
+		return types.DBItem{Object: hash}, err
+
+	case typeStreamListpacks3:
+		stream, err := d.readStream()
+		return types.DBItem{Object: stream}, err
+
+	case typeModule2:
+		obj, err := d.readModule()
+		return types.DBItem{Object: obj}, err
+	}
+	return types.DBItem{}, fmt.Errorf("unsupported value type %d", valueType)
+}
+
+// readHash reads a hash, along with the expiries of its fields if it has metadata
+func (d *decoder) readHash(withExpiries bool) (*types.Hash, error) {
+	minExpiry := int64(0)
+	if withExpiries {
+		var err error
+		if minExpiry, err = d.readMillis(); err != nil {
+			return nil, err
+		}
+	}
+
+	n, err := d.readLen()
+	if err != nil {
+		return nil, err
+	}
+	hash := types.NewHash()
+	for range n {
+		ttl := uint64(0)
+		if withExpiries {
+			if ttl, err = d.readLen(); err != nil {
+				return nil, err
+			}
+		}
+		field, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		hash.Set(string(field), value)
+		if ttl != 0 {
+			hash.SetExpiry(string(field), minExpiry+int64(ttl)-1)
+		}
+	}
+	return hash, nil
+}
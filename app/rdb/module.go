@@ -0,0 +1,223 @@
+package rdb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// Values of module types are saved under a 64 bit ID: the 9 characters of the type
+// name, 6 bits each, followed by a 10 bit encoding version. They are then written
+// as a sequence of tagged elements ending with moduleOpEOF. Filters and documents
+// are saved in layouts of their own, so they are saved under type names of their
+// own too, rather than those of RedisBloom and RedisJSON, whose loaders would not
+// read them.
+const (
+	moduleOpEOF    = 0
+	moduleOpUint   = 2
+	moduleOpDouble = 4
+	moduleOpString = 5
+
+	moduleNameCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	moduleEncVersion  = 0
+
+	bloomModuleType  = "rsgbloom-"
+	cuckooModuleType = "rsgcuckoo"
+	jsonModuleType   = "rsgjson--"
+)
+
+var errModule = errors.New("invalid module value")
+
+func moduleID(name string) uint64 {
+	id := uint64(0)
+	for _, c := range []byte(name) {
+		id = id<<6 | uint64(strings.IndexByte(moduleNameCharset, c))
+	}
+	return id<<10 | moduleEncVersion
+}
+
+func moduleName(id uint64) string {
+	name := make([]byte, 9)
+	id >>= 10
+	for i := len(name) - 1; i >= 0; i-- {
+		name[i] = moduleNameCharset[id&63]
+		id >>= 6
+	}
+	return string(name)
+}
+
+func (e *encoder) writeModuleUint(v uint64) {
+	e.writeLen(moduleOpUint)
+	e.writeLen(v)
+}
+
+func (e *encoder) writeModuleDouble(f float64) {
+	e.writeLen(moduleOpDouble)
+	e.writeDouble(f)
+}
+
+func (e *encoder) writeModuleString(s []byte) {
+	e.writeLen(moduleOpString)
+	e.writeString(s)
+}
+
+func (e *encoder) writeModule(obj types.Object) {
+	switch obj := obj.(type) {
+	case *types.BloomFilter:
+		e.writeLen(moduleID(bloomModuleType))
+		e.writeModuleUint(obj.Expansion())
+		e.writeModuleDouble(obj.ErrorRate())
+		layers := obj.Layers()
+		e.writeModuleUint(uint64(len(layers)))
+		for _, l := range layers {
+			e.writeModuleUint(l.Capacity)
+			e.writeModuleUint(l.Count)
+			e.writeModuleUint(l.Hashes)
+			e.writeModuleUint(l.NumBits)
+			e.writeModuleString(l.Bits)
+		}
+
+	case *types.CuckooFilter:
+		e.writeLen(moduleID(cuckooModuleType))
+		e.writeModuleUint(obj.Capacity())
+		layers := obj.Layers()
+		e.writeModuleUint(uint64(len(layers)))
+		for _, l := range layers {
+			e.writeModuleString(l)
+		}
+
+	case *types.JSON:
+		e.writeLen(moduleID(jsonModuleType))
+		e.writeModuleString([]byte(types.MarshalJSON(obj.Root())))
+	}
+
+	e.writeLen(moduleOpEOF)
+}
+
+func (d *decoder) readModuleOp(op uint64) error {
+	got, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	if got != op {
+		return errModule
+	}
+	return nil
+}
+
+func (d *decoder) readModuleUint() (uint64, error) {
+	if err := d.readModuleOp(moduleOpUint); err != nil {
+		return 0, err
+	}
+	return d.readLen()
+}
+
+func (d *decoder) readModuleDouble() (float64, error) {
+	if err := d.readModuleOp(moduleOpDouble); err != nil {
+		return 0, err
+	}
+	return d.readDouble()
+}
+
+func (d *decoder) readModuleString() ([]byte, error) {
+	if err := d.readModuleOp(moduleOpString); err != nil {
+		return nil, err
+	}
+	return d.readString()
+}
+
+func (d *decoder) readModule() (types.Object, error) {
+	id, err := d.readLen()
+	if err != nil {
+		return nil, err
+	}
+	name := moduleName(id)
+	if id&1023 != moduleEncVersion {
+		return nil, fmt.Errorf("unsupported encoding version %d of module type %s", id&1023, name)
+	}
+
+	var obj types.Object
+	switch name {
+	case bloomModuleType:
+		obj, err = d.readBloomFilter()
+	case cuckooModuleType:
+		obj, err = d.readCuckooFilter()
+	case jsonModuleType:
+		obj, err = d.readJSON()
+	default:
+		return nil, fmt.Errorf("unknown module type %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.readModuleOp(moduleOpEOF); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (d *decoder) readBloomFilter() (types.Object, error) {
+	expansion, err := d.readModuleUint()
+	if err != nil {
+		return nil, err
+	}
+	errorRate, err := d.readModuleDouble()
+	if err != nil {
+		return nil, err
+	}
+	numLayers, err := d.readModuleUint()
+	if err != nil {
+		return nil, err
+	}
+
+	layers := []types.BloomLayer{}
+	for range numLayers {
+		var l types.BloomLayer
+		for _, v := range []*uint64{&l.Capacity, &l.Count, &l.Hashes, &l.NumBits} {
+			if *v, err = d.readModuleUint(); err != nil {
+				return nil, err
+			}
+		}
+		if l.Bits, err = d.readModuleString(); err != nil {
+			return nil, err
+		}
+		layers = append(layers, l)
+	}
+	return types.RestoreBloomFilter(errorRate, expansion, layers)
+}
+
+func (d *decoder) readCuckooFilter() (types.Object, error) {
+	capacity, err := d.readModuleUint()
+	if err != nil {
+		return nil, err
+	}
+	numLayers, err := d.readModuleUint()
+	if err != nil {
+		return nil, err
+	}
+
+	layers := [][]byte{}
+	for range numLayers {
+		l, err := d.readModuleString()
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, l)
+	}
+	return types.RestoreCuckooFilter(capacity, layers)
+}
+
+func (d *decoder) readJSON() (types.Object, error) {
+	data, err := d.readModuleString()
+	if err != nil {
+		return nil, err
+	}
+	root, err := types.ParseJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewJSON(root), nil
+}
@@ -0,0 +1,205 @@
+// Package rdb encodes the keyspace in the RDB format, in which a master sends its
+// dataset to a replica on a full resynchronization, and loads it back.
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc64"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// Files are written as Redis 7.4 does, the first version with field expiries in hashes
+const (
+	rdbVersion   = 12
+	redisVersion = "7.4.0"
+)
+
+// Opcodes, which share the byte preceding each key with the value types
+const (
+	opIdle         = 0xF8
+	opFreq         = 0xF9
+	opAux          = 0xFA
+	opResizeDB     = 0xFB
+	opExpireTimeMs = 0xFC
+	opExpireTime   = 0xFD
+	opSelectDB     = 0xFE
+	opEOF          = 0xFF
+)
+
+// Value types
+const (
+	typeString           = 0
+	typeList             = 1
+	typeSet              = 2
+	typeHash             = 4
+	typeZSet2            = 5
+	typeModule2          = 7
+	typeStreamListpacks3 = 21
+	typeHashMetadata     = 24
+)
+
+// Redis checksums RDB files with CRC-64/Jones, which hash/crc64 computes given the
+// reversed polynomial. Unlike hash/crc64, Redis does not invert the CRC.
+var crcTable = crc64.MakeTable(0x95ac9329ac4bc9b5)
+
+func checksum(data []byte) uint64 {
+	return ^crc64.Update(math.MaxUint64, crcTable, data)
+}
+
+type encoder struct {
+	buf []byte
+}
+
+// writeLen writes a length in 1, 2, 5 or 9 bytes, depending on its size
+func (e *encoder) writeLen(n uint64) {
+	switch {
+	case n < 1<<6:
+		e.buf = append(e.buf, byte(n))
+	case n < 1<<14:
+		e.buf = append(e.buf, 0x40|byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		e.buf = append(e.buf, 0x80)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(n))
+	default:
+		e.buf = append(e.buf, 0x81)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, n)
+	}
+}
+
+func (e *encoder) writeString(s []byte) {
+	e.writeLen(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) writeMillis(ms int64) {
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(ms))
+}
+
+func (e *encoder) writeDouble(f float64) {
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(f))
+}
+
+func (e *encoder) writeAux(key, value string) {
+	e.buf = append(e.buf, opAux)
+	e.writeString([]byte(key))
+	e.writeString([]byte(value))
+}
+
+// writeKey starts a key-value pair with the type of the value and the key
+func (e *encoder) writeKey(valueType byte, key string) {
+	e.buf = append(e.buf, valueType)
+	e.writeString([]byte(key))
+}
+
+// Encode returns a snapshot of the keyspace in the RDB format. It must be called
+// with DBMutex held.
+func Encode(server *types.ServerState) []byte {
+	e := &encoder{}
+	e.buf = fmt.Appendf(e.buf, "REDIS%04d", rdbVersion)
+	e.writeAux("redis-ver", redisVersion)
+	e.writeAux("redis-bits", "64")
+	e.writeAux("ctime", strconv.FormatInt(time.Now().Unix(), 10))
+	e.writeAux("aof-base", "0")
+
+	e.buf = append(e.buf, opSelectDB)
+	e.writeLen(0)
+	e.buf = append(e.buf, opResizeDB)
+	e.writeLen(uint64(server.DB.Len()))
+	e.writeLen(uint64(len(server.Expires)))
+
+	server.DB.Range(func(key string, item types.DBItem) bool {
+		if item.Expiry != -1 {
+			e.buf = append(e.buf, opExpireTimeMs)
+			e.writeMillis(item.Expiry)
+		}
+		e.writeItem(key, item)
+		return true
+	})
+
+	e.buf = append(e.buf, opEOF)
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, checksum(e.buf))
+	return e.buf
+}
+
+func (e *encoder) writeItem(key string, item types.DBItem) {
+	switch obj := item.Object.(type) {
+	case nil:
+		e.writeKey(typeString, key)
+		e.writeString(item.Value)
+
+	case *types.List:
+		e.writeKey(typeList, key)
+		e.writeLen(uint64(obj.Len()))
+		obj.Iterate(false, func(_ int, elem []byte) bool {
+			e.writeString(elem)
+			return true
+		})
+
+	case *types.Set:
+		e.writeKey(typeSet, key)
+		e.writeLen(uint64(obj.Len()))
+		obj.Range(func(member string) bool {
+			e.writeString([]byte(member))
+			return true
+		})
+
+	case *types.ZSet:
+		e.writeKey(typeZSet2, key)
+		e.writeLen(uint64(obj.Len()))
+		obj.Range(func(entry types.ZEntry) bool {
+			e.writeString([]byte(entry.Member))
+			e.writeDouble(entry.Score)
+			return true
+		})
+
+	case *types.Hash:
+		e.writeHash(key, obj)
+
+	case *types.Stream:
+		e.writeKey(typeStreamListpacks3, key)
+		e.writeStream(obj)
+
+	case *types.BloomFilter, *types.CuckooFilter, *types.JSON:
+		e.writeKey(typeModule2, key)
+		e.writeModule(obj)
+	}
+}
+
+// writeHash writes a hash, along with the expiries of its fields if any has one.
+// They are written relative to the earliest of them, plus one as 0 means none.
+func (e *encoder) writeHash(key string, hash *types.Hash) {
+	fields, values, expiries := []string{}, [][]byte{}, []int64{}
+	minExpiry := int64(-1)
+	hash.Range(func(field string, value []byte) bool {
+		expiry, _ := hash.Expiry(field)
+		if expiry != -1 && (minExpiry == -1 || expiry < minExpiry) {
+			minExpiry = expiry
+		}
+		fields, values, expiries = append(fields, field), append(values, value), append(expiries, expiry)
+		return true
+	})
+
+	if minExpiry == -1 {
+		e.writeKey(typeHash, key)
+	} else {
+		e.writeKey(typeHashMetadata, key)
+		e.writeMillis(minExpiry)
+	}
+	e.writeLen(uint64(len(fields)))
+	for i, field := range fields {
+		if minExpiry != -1 {
+			ttl := uint64(0)
+			if expiries[i] != -1 {
+				ttl = uint64(expiries[i]-minExpiry) + 1
+			}
+			e.writeLen(ttl)
+		}
+		e.writeString([]byte(field))
+		e.writeString(values[i])
+	}
+}
@@ -0,0 +1,260 @@
+package rdb_test
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/rdb"
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func newServerState() *types.ServerState {
+	return &types.ServerState{
+		DB:           types.NewDict[types.DBItem](),
+		Expires:      map[string]struct{}{},
+		FieldExpires: map[string]struct{}{},
+		Blocked:      map[string][]*types.BlockedClient{},
+		Role:         "master",
+	}
+}
+
+// reload encodes the keyspace of server and loads it into an empty keyspace
+func reload(t *testing.T, server *types.ServerState) *types.ServerState {
+	t.Helper()
+	loaded := newServerState()
+	if err := rdb.Load(loaded, rdb.Encode(server)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return loaded
+}
+
+func get[T types.Object](t *testing.T, server *types.ServerState, key string) T {
+	t.Helper()
+	item, ok := server.DB.Get(key)
+	if !ok {
+		t.Fatalf("Expected %s to be loaded", key)
+	}
+	obj, ok := item.Object.(T)
+	if !ok {
+		t.Fatalf("Expected %s to be loaded as a %T, got %T", key, obj, item.Object)
+	}
+	return obj
+}
+
+func TestLoadEmpty(t *testing.T) {
+	// The snapshot Redis 7.2 sends when the keyspace is empty
+	data, _ := hex.DecodeString("524544495330303131fa0972656469732d76657205372e322e30fa0a72656469732d62697473c040fa056374696d65c26d08bc65fa08757365642d6d656dc2b0c41000fa08616f662d62617365c000fff06e3bfec0ff5aa2")
+	server := newServerState()
+	if err := rdb.Load(server, data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if server.DB.Len() != 0 {
+		t.Fatalf("Expected no keys, got %d", server.DB.Len())
+	}
+
+	data[len(data)-1]++
+	if err := rdb.Load(server, data); err == nil {
+		t.Fatalf("Expected an error for a wrong checksum")
+	}
+}
+
+func TestStrings(t *testing.T) {
+	server := newServerState()
+	now := time.Now().UnixMilli()
+	server.SetItem("plain", types.DBItem{Value: []byte("value"), Expiry: -1})
+	server.SetItem("big", types.DBItem{Value: []byte(strings.Repeat("x", 20000)), Expiry: -1})
+	server.SetItem("expiring", types.DBItem{Value: []byte("soon"), Expiry: now + 60000})
+	server.SetItem("expired", types.DBItem{Value: []byte("gone"), Expiry: now - 1})
+
+	loaded := reload(t, server)
+	for _, key := range []string{"plain", "big", "expiring"} {
+		expected, _ := server.DB.Get(key)
+		item, ok := loaded.DB.Get(key)
+		if !ok || !reflect.DeepEqual(item, expected) {
+			t.Fatalf("Expected %s to be loaded as %v, got %v", key, expected, item)
+		}
+	}
+	if _, ok := loaded.DB.Get("expired"); ok {
+		t.Fatalf("Expected expired keys not to be loaded")
+	}
+	if _, ok := loaded.Expires["expiring"]; !ok {
+		t.Fatalf("Expected the expiry to be tracked")
+	}
+}
+
+func TestCollections(t *testing.T) {
+	server := newServerState()
+	list := types.NewList()
+	set := types.NewSet()
+	zset := types.NewZSet()
+	for i := range 300 {
+		list.PushBack([]byte(strconv.Itoa(i)))
+		set.Add(strconv.Itoa(i))
+		zset.Add(strconv.Itoa(i), float64(i)/3)
+	}
+	server.SetItem("list", types.DBItem{Object: list, Expiry: -1})
+	server.SetItem("set", types.DBItem{Object: set, Expiry: -1})
+	server.SetItem("zset", types.DBItem{Object: zset, Expiry: -1})
+
+	expiry := time.Now().UnixMilli() + 60000
+	hash := types.NewHash()
+	hash.Set("a", []byte("1"))
+	hash.Set("b", []byte("2"))
+	hash.Set("c", []byte("3"))
+	hash.SetExpiry("b", expiry+500)
+	hash.SetExpiry("c", expiry)
+	server.SetItem("hash", types.DBItem{Object: hash, Expiry: -1})
+
+	loaded := reload(t, server)
+	if got := get[*types.List](t, loaded, "list").Range(0, -1); !reflect.DeepEqual(got, list.Range(0, -1)) {
+		t.Fatalf("Expected the list elements in order, got %q", got)
+	}
+	if got := get[*types.Set](t, loaded, "set"); got.Len() != 300 || !got.Contains("299") {
+		t.Fatalf("Expected the set members, got %v", got.Members())
+	}
+	if got := get[*types.ZSet](t, loaded, "zset"); !reflect.DeepEqual(got.RangeByRank(0, -1, false), zset.RangeByRank(0, -1, false)) {
+		t.Fatalf("Expected the sorted set entries")
+	}
+
+	h := get[*types.Hash](t, loaded, "hash")
+	for field, expected := range map[string]int64{"a": -1, "b": expiry + 500, "c": expiry} {
+		original, _ := hash.Get(field)
+		if value, _ := h.Get(field); string(value) != string(original) {
+			t.Fatalf("Expected field %s to be loaded, got %q", field, value)
+		}
+		if got, _ := h.Expiry(field); got != expected {
+			t.Fatalf("Expected field %s to expire at %d, got %d", field, expected, got)
+		}
+	}
+	if _, ok := loaded.FieldExpires["hash"]; !ok {
+		t.Fatalf("Expected the field expiries to be tracked")
+	}
+}
+
+func TestStream(t *testing.T) {
+	server := newServerState()
+	stream := types.NewStream()
+	ms := uint64(0)
+	for i := 1; i <= 250; i++ {
+		fields := [][]byte{[]byte("n"), []byte(strconv.Itoa(i))}
+		if i%7 == 0 {
+			fields = append(fields, []byte("extra"), []byte(strings.Repeat("x", i)))
+		}
+		// Large gaps between IDs are stored as integers of every size
+		ms += 1 << (i % 40)
+		stream.Add(types.StreamID{Ms: ms, Seq: uint64(i % 3)}, fields)
+	}
+	stream.Delete(stream.Range(types.StreamID{}, types.MaxStreamID, false, 1)[0].ID)
+	server.SetItem("stream", types.DBItem{Object: stream, Expiry: -1})
+	server.SetItem("empty", types.DBItem{Object: types.NewStream(), Expiry: -1})
+
+	loaded := reload(t, server)
+	s := get[*types.Stream](t, loaded, "stream")
+	all := func(s *types.Stream) []types.StreamEntry {
+		return s.Range(types.StreamID{}, types.MaxStreamID, false, -1)
+	}
+	if !reflect.DeepEqual(all(s), all(stream)) {
+		t.Fatalf("Expected the stream entries, got %v", all(s))
+	}
+	if s.LastID() != stream.LastID() || s.EntriesAdded() != stream.EntriesAdded() || s.MaxDeletedID() != stream.MaxDeletedID() {
+		t.Fatalf("Expected last ID %v, entries added %d and max deleted ID %v, got %v, %d and %v",
+			stream.LastID(), stream.EntriesAdded(), stream.MaxDeletedID(), s.LastID(), s.EntriesAdded(), s.MaxDeletedID())
+	}
+	if get[*types.Stream](t, loaded, "empty").Len() != 0 {
+		t.Fatalf("Expected an empty stream")
+	}
+}
+
+func TestModules(t *testing.T) {
+	server := newServerState()
+	bloom := types.NewBloomFilter(0.001, 10, 2)
+	cuckoo := types.NewCuckooFilter(8)
+	for i := range 50 {
+		bloom.Add([]byte(strconv.Itoa(i)))
+		cuckoo.Add([]byte(strconv.Itoa(i)))
+	}
+	root, _ := types.ParseJSON([]byte(`{"a":[1,2.5,"x",null,true],"b":{"c":{}}}`))
+	server.SetItem("bloom", types.DBItem{Object: bloom, Expiry: -1})
+	server.SetItem("cuckoo", types.DBItem{Object: cuckoo, Expiry: -1})
+	server.SetItem("json", types.DBItem{Object: types.NewJSON(root), Expiry: -1})
+
+	loaded := reload(t, server)
+	b := get[*types.BloomFilter](t, loaded, "bloom")
+	if len(bloom.Layers()) < 2 || !reflect.DeepEqual(b.Layers(), bloom.Layers()) || b.ErrorRate() != bloom.ErrorRate() || b.Expansion() != bloom.Expansion() {
+		t.Fatalf("Expected the bloom filter layers to be loaded")
+	}
+	c := get[*types.CuckooFilter](t, loaded, "cuckoo")
+	if len(cuckoo.Layers()) < 2 || !reflect.DeepEqual(c.Layers(), cuckoo.Layers()) || c.Capacity() != cuckoo.Capacity() {
+		t.Fatalf("Expected the cuckoo filter layers to be loaded")
+	}
+	if got := types.MarshalJSON(get[*types.JSON](t, loaded, "json").Root()); got != types.MarshalJSON(root) {
+		t.Fatalf("Expected the JSON document to be loaded, got %s", got)
+	}
+}
+
+func TestStreamGroups(t *testing.T) {
+	server := newServerState()
+	stream := types.NewStream()
+	for i := 1; i <= 5; i++ {
+		stream.Add(types.StreamID{Ms: uint64(i)}, [][]byte{[]byte("n"), []byte(strconv.Itoa(i))})
+	}
+	stream.CreateGroup("g1", types.StreamID{Ms: 4}, 4)
+	stream.CreateGroup("g2", types.StreamID{}, -1)
+	g, _ := stream.Group("g1")
+	alice, _ := g.CreateConsumer("alice", 1000)
+	bob, _ := g.CreateConsumer("bob", 2000)
+	g.CreateConsumer("idle", 3000)
+	alice.ActiveTime = 1500
+	g.Claim(types.StreamID{Ms: 1}, alice, 1100, 1)
+	g.Claim(types.StreamID{Ms: 3}, alice, 1200, 3)
+	g.Claim(types.StreamID{Ms: 2}, bob, 2100, 2)
+	server.SetItem("stream", types.DBItem{Object: stream, Expiry: -1})
+
+	loaded := reload(t, server)
+	s := get[*types.Stream](t, loaded, "stream")
+	if got := s.GroupNames(); !reflect.DeepEqual(got, []string{"g1", "g2"}) {
+		t.Fatalf("Expected groups g1 and g2, got %v", got)
+	}
+	g2, _ := s.Group("g2")
+	if g2.LastID != (types.StreamID{}) || g2.EntriesRead != -1 || len(g2.Consumers()) != 0 {
+		t.Fatalf("Expected g2 to be loaded as created, got %+v", g2)
+	}
+
+	lg, _ := s.Group("g1")
+	if lg.LastID != g.LastID || lg.EntriesRead != 4 {
+		t.Fatalf("Expected g1 to have read up to %v, got %v with %d entries read", g.LastID, lg.LastID, lg.EntriesRead)
+	}
+	pending := func(l *types.PendingList) []types.PendingEntry {
+		entries := []types.PendingEntry{}
+		l.Range(types.StreamID{}, types.MaxStreamID, func(pe *types.PendingEntry) bool {
+			entries = append(entries, *pe)
+			return true
+		})
+		return entries
+	}
+	if got := pending(lg.Pending); !reflect.DeepEqual(got, pending(g.Pending)) {
+		t.Fatalf("Expected the pending entries %v, got %v", pending(g.Pending), got)
+	}
+	consumers := lg.Consumers()
+	if len(consumers) != 3 {
+		t.Fatalf("Expected 3 consumers, got %d", len(consumers))
+	}
+	for i, expected := range g.Consumers() {
+		c := consumers[i]
+		if c.Name != expected.Name || c.SeenTime != expected.SeenTime || c.ActiveTime != expected.ActiveTime {
+			t.Fatalf("Expected consumer %+v, got %+v", expected, c)
+		}
+		if got := pending(c.Pending); !reflect.DeepEqual(got, pending(expected.Pending)) {
+			t.Fatalf("Expected the entries pending for %s to be %v, got %v", c.Name, pending(expected.Pending), got)
+		}
+	}
+
+	// Acknowledging an entry on the loaded stream finds it pending
+	if !lg.Ack(types.StreamID{Ms: 3}) {
+		t.Fatalf("Expected 3-0 to be pending")
+	}
+}
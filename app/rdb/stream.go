@@ -0,0 +1,384 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"slices"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// Streams are saved as listpacks of up to streamNodeEntries entries, keyed by the ID
+// of their first entry, the master ID. A listpack starts with the number of live and
+// deleted entries and the fields of the master entry, followed by a terminating 0.
+// Each entry is then its flags, its ID relative to the master ID, its fields, or only
+// their values if they are the same as the master entry's, and its number of
+// elements to walk the listpack backwards.
+//
+// The stream is followed by its consumer groups, each with its pending entries and
+// its consumers, along with the IDs of the entries pending for each of them.
+const (
+	streamNodeEntries = 100
+
+	streamItemDeleted    = 1
+	streamItemSameFields = 2
+)
+
+var errStream = errors.New("invalid stream")
+
+func (e *encoder) writeStreamID(id types.StreamID) {
+	e.writeLen(id.Ms)
+	e.writeLen(id.Seq)
+}
+
+// writeRawStreamID writes an ID as 16 big endian bytes, which sort in ID order, as
+// node keys and the IDs of pending entries are written
+func (e *encoder) writeRawStreamID(id types.StreamID) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, id.Ms)
+	e.buf = binary.BigEndian.AppendUint64(e.buf, id.Seq)
+}
+
+func (e *encoder) writeStream(stream *types.Stream) {
+	entries := stream.Range(types.StreamID{}, types.MaxStreamID, false, -1)
+
+	e.writeLen(uint64((len(entries) + streamNodeEntries - 1) / streamNodeEntries))
+	for start := 0; start < len(entries); start += streamNodeEntries {
+		node := entries[start:min(start+streamNodeEntries, len(entries))]
+		master := node[0]
+
+		e.writeLen(16)
+		e.writeRawStreamID(master.ID)
+		e.writeString(streamListpack(node))
+	}
+
+	first := types.StreamID{}
+	if len(entries) > 0 {
+		first = entries[0].ID
+	}
+	e.writeLen(uint64(stream.Len()))
+	e.writeStreamID(stream.LastID())
+	e.writeStreamID(first)
+	e.writeStreamID(stream.MaxDeletedID())
+	e.writeLen(uint64(stream.EntriesAdded()))
+
+	names := stream.GroupNames()
+	e.writeLen(uint64(len(names)))
+	for _, name := range names {
+		g, _ := stream.Group(name)
+		e.writeString([]byte(name))
+		e.writeStreamID(g.LastID)
+		// An unknown number of entries read, -1, is written as the largest length
+		e.writeLen(uint64(g.EntriesRead))
+
+		e.writeLen(uint64(g.Pending.Len()))
+		g.Pending.Range(types.StreamID{}, types.MaxStreamID, func(pe *types.PendingEntry) bool {
+			e.writeRawStreamID(pe.ID)
+			e.writeMillis(pe.DeliveryTime)
+			e.writeLen(uint64(pe.DeliveryCount))
+			return true
+		})
+
+		consumers := g.Consumers()
+		e.writeLen(uint64(len(consumers)))
+		for _, consumer := range consumers {
+			e.writeString([]byte(consumer.Name))
+			e.writeMillis(consumer.SeenTime)
+			e.writeMillis(consumer.ActiveTime)
+			e.writeLen(uint64(consumer.Pending.Len()))
+			consumer.Pending.Range(types.StreamID{}, types.MaxStreamID, func(pe *types.PendingEntry) bool {
+				e.writeRawStreamID(pe.ID)
+				return true
+			})
+		}
+	}
+}
+
+// streamListpack packs the entries of a node, the first being its master entry
+func streamListpack(entries []types.StreamEntry) []byte {
+	master := entries[0]
+	masterFields := streamFieldNames(master.Fields)
+
+	lp := &listpack{}
+	lp.appendInt(int64(len(entries)))
+	lp.appendInt(0) // Deleted entries
+	lp.appendInt(int64(len(masterFields)))
+	for _, field := range masterFields {
+		lp.appendString(field)
+	}
+	lp.appendInt(0)
+
+	for _, entry := range entries {
+		pairs := len(entry.Fields) / 2
+		sameFields := slices.EqualFunc(streamFieldNames(entry.Fields), masterFields, bytes.Equal)
+		if sameFields {
+			lp.appendInt(streamItemSameFields)
+		} else {
+			lp.appendInt(0)
+		}
+		lp.appendInt(int64(entry.ID.Ms - master.ID.Ms))
+		lp.appendInt(int64(entry.ID.Seq - master.ID.Seq))
+		if sameFields {
+			for i := 1; i < len(entry.Fields); i += 2 {
+				lp.appendString(entry.Fields[i])
+			}
+			lp.appendInt(int64(pairs + 3))
+		} else {
+			lp.appendInt(int64(pairs))
+			for _, f := range entry.Fields {
+				lp.appendString(f)
+			}
+			lp.appendInt(int64(2*pairs + 4))
+		}
+	}
+	return lp.bytes()
+}
+
+func streamFieldNames(fields [][]byte) [][]byte {
+	names := make([][]byte, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		names = append(names, fields[i])
+	}
+	return names
+}
+
+func (d *decoder) readStreamID() (types.StreamID, error) {
+	ms, err := d.readLen()
+	if err != nil {
+		return types.StreamID{}, err
+	}
+	seq, err := d.readLen()
+	if err != nil {
+		return types.StreamID{}, err
+	}
+	return types.StreamID{Ms: ms, Seq: seq}, nil
+}
+
+func (d *decoder) readStream() (*types.Stream, error) {
+	stream := types.NewStream()
+
+	numNodes, err := d.readLen()
+	if err != nil {
+		return nil, err
+	}
+	for range numNodes {
+		key, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		if len(key) != 16 {
+			return nil, errStream
+		}
+		master := types.StreamID{Ms: binary.BigEndian.Uint64(key), Seq: binary.BigEndian.Uint64(key[8:])}
+
+		data, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		if err := readStreamListpack(stream, master, data); err != nil {
+			return nil, err
+		}
+	}
+
+	// The length and first ID follow from the entries
+	if _, err := d.readLen(); err != nil {
+		return nil, err
+	}
+	lastID, err := d.readStreamID()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.readStreamID(); err != nil {
+		return nil, err
+	}
+	maxDeletedID, err := d.readStreamID()
+	if err != nil {
+		return nil, err
+	}
+	entriesAdded, err := d.readLen()
+	if err != nil {
+		return nil, err
+	}
+	stream.SetID(lastID, int64(entriesAdded), maxDeletedID)
+
+	numGroups, err := d.readLen()
+	if err != nil {
+		return nil, err
+	}
+	for range numGroups {
+		if err := d.readStreamGroup(stream); err != nil {
+			return nil, err
+		}
+	}
+	return stream, nil
+}
+
+func (d *decoder) readRawStreamID() (types.StreamID, error) {
+	b, err := d.readBytes(16)
+	if err != nil {
+		return types.StreamID{}, err
+	}
+	return types.StreamID{Ms: binary.BigEndian.Uint64(b), Seq: binary.BigEndian.Uint64(b[8:])}, nil
+}
+
+// readStreamGroup adds a consumer group to stream. The pending entries of the group
+// are handed to the consumers they are pending for, each of which must be one.
+func (d *decoder) readStreamGroup(stream *types.Stream) error {
+	name, err := d.readString()
+	if err != nil {
+		return err
+	}
+	lastID, err := d.readStreamID()
+	if err != nil {
+		return err
+	}
+	entriesRead, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	if !stream.CreateGroup(string(name), lastID, int64(entriesRead)) {
+		return errStream
+	}
+	g, _ := stream.Group(string(name))
+
+	numPending, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	pending := map[types.StreamID]types.PendingEntry{}
+	for range numPending {
+		id, err := d.readRawStreamID()
+		if err != nil {
+			return err
+		}
+		deliveryTime, err := d.readMillis()
+		if err != nil {
+			return err
+		}
+		deliveryCount, err := d.readLen()
+		if err != nil {
+			return err
+		}
+		pending[id] = types.PendingEntry{ID: id, DeliveryTime: deliveryTime, DeliveryCount: int64(deliveryCount)}
+	}
+
+	numConsumers, err := d.readLen()
+	if err != nil {
+		return err
+	}
+	for range numConsumers {
+		consumerName, err := d.readString()
+		if err != nil {
+			return err
+		}
+		seenTime, err := d.readMillis()
+		if err != nil {
+			return err
+		}
+		activeTime, err := d.readMillis()
+		if err != nil {
+			return err
+		}
+		consumer, created := g.CreateConsumer(string(consumerName), seenTime)
+		if !created {
+			return errStream
+		}
+		consumer.ActiveTime = activeTime
+
+		numConsumerPending, err := d.readLen()
+		if err != nil {
+			return err
+		}
+		for range numConsumerPending {
+			id, err := d.readRawStreamID()
+			if err != nil {
+				return err
+			}
+			pe, ok := pending[id]
+			if !ok {
+				return errStream
+			}
+			delete(pending, id)
+			g.Claim(id, consumer, pe.DeliveryTime, pe.DeliveryCount)
+		}
+	}
+
+	if len(pending) > 0 {
+		return errors.New("stream pending entries without a consumer")
+	}
+	return nil
+}
+
+// readStreamListpack adds the entries of a node that are not deleted to stream
+func readStreamListpack(stream *types.Stream, master types.StreamID, data []byte) error {
+	r, err := newListpackReader(data)
+	if err != nil {
+		return err
+	}
+
+	header := make([]int64, 3) // Live entries, deleted entries, master fields
+	for i := range header {
+		if header[i], err = r.nextInt(); err != nil {
+			return err
+		}
+	}
+	masterFields := make([][]byte, header[2])
+	for i := range masterFields {
+		if masterFields[i], err = r.nextString(); err != nil {
+			return err
+		}
+	}
+	if _, err := r.nextInt(); err != nil {
+		return err
+	}
+
+	for !r.done() {
+		flags, err := r.nextInt()
+		if err != nil {
+			return err
+		}
+		msDiff, err := r.nextInt()
+		if err != nil {
+			return err
+		}
+		seqDiff, err := r.nextInt()
+		if err != nil {
+			return err
+		}
+		id := types.StreamID{Ms: master.Ms + uint64(msDiff), Seq: master.Seq + uint64(seqDiff)}
+
+		var fields [][]byte
+		if flags&streamItemSameFields != 0 {
+			for _, field := range masterFields {
+				value, err := r.nextString()
+				if err != nil {
+					return err
+				}
+				fields = append(fields, field, value)
+			}
+		} else {
+			numFields, err := r.nextInt()
+			if err != nil {
+				return err
+			}
+			for range 2 * numFields {
+				f, err := r.nextString()
+				if err != nil {
+					return err
+				}
+				fields = append(fields, f)
+			}
+		}
+		if _, err := r.nextInt(); err != nil {
+			return err
+		}
+
+		if flags&streamItemDeleted == 0 {
+			if id.Compare(stream.LastID()) <= 0 && stream.EntriesAdded() > 0 {
+				return errStream
+			}
+			stream.Add(id, fields)
+		}
+	}
+	return nil
+}
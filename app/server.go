@@ -1,13 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"strings"
 
-	"github.com/codecrafters-io/redis-starter-go/app/handlers"
 	"github.com/codecrafters-io/redis-starter-go/app/types"
 	"github.com/codecrafters-io/redis-starter-go/resp"
 )
@@ -24,6 +24,8 @@ func main() {
 	}
 	defer l.Close()
 
+	startActiveExpire(serverState)
+
 	for {
 		conn, err := l.Accept()
 		if err != nil {
@@ -32,79 +34,111 @@ func main() {
 		}
 		fmt.Printf("Accepted connection from %s\n", conn.LocalAddr().String())
 
-		go handleConnection(conn, serverState, false)
+		go handleConnection(conn, resp.NewReader(conn), serverState, false)
 	}
 }
 
-func handleConnection(conn net.Conn, serverState *types.ServerState, isMasterConnection bool) {
-	defer conn.Close()
+// received is a command read from a connection, or the error that ended the reads
+type received struct {
+	args [][]byte
+	raw  []byte
+	err  error
+}
 
-	for {
-		buffer := make([]byte, 1024)
-		n, err := conn.Read(buffer)
-		if err != nil {
-			if err == io.EOF {
+func handleConnection(conn net.Conn, reader *resp.Reader, serverState *types.ServerState, isMasterConnection bool) {
+	defer conn.Close()
+	client := types.NewClient(conn)
+	client.IsMaster = isMasterConnection
+
+	// Commands are read in their own goroutine, so that a client blocked in a
+	// command is still noticed going away
+	commands := make(chan received)
+	go readCommands(reader, client, commands)
+
+	for cmd := range commands {
+		if cmd.err != nil {
+			if errors.Is(cmd.err, resp.ErrProtocol) {
+				conn.Write(resp.Error("ERR " + cmd.err.Error()).EncodeRESP2())
+			}
+			if cmd.err == io.EOF {
 				fmt.Println("Connection closed by client")
 			}
-			fmt.Println("Error reading:", err)
+			fmt.Println("Error reading:", cmd.err)
 			break
 		}
 
-		fmt.Printf("Received %d bytes: %q\n", n, buffer[:n])
-		handleCommand(buffer[:n], conn, serverState, isMasterConnection)
+		fmt.Printf("Received %d bytes\n", len(cmd.raw))
+		if len(cmd.args) == 0 {
+			continue
+		}
+		handleCommand(cmd.args, cmd.raw, client, serverState)
 	}
-}
 
-func handleCommand(buffer []byte, conn net.Conn, state *types.ServerState, isMasterCommand bool) {
-	respHandler := resp.RESPHandler{}
+	// A replica that goes away is no longer streamed to
+	serverState.DBMutex.Lock()
+	serverState.DropReplica(client)
+	serverState.DBMutex.Unlock()
+}
 
-	arr, next, err := respHandler.Array.Decode(buffer)
-	if err != nil {
-		fmt.Println("Error decoding RESP array:", err)
-		return
+// readCommands reads commands until the connection fails, which is delivered as a
+// last command holding the error
+func readCommands(reader *resp.Reader, client *types.Client, commands chan<- received) {
+	defer close(commands)
+	for {
+		arr, raw, err := reader.ReadCommand()
+		if err != nil {
+			client.MarkClosed()
+			commands <- received{err: err}
+			return
+		}
+		commands <- received{args: arr, raw: raw}
 	}
-	buffer = buffer[:len(buffer)-len(next)]
-
-	fmt.Println("Command received: ", arr)
-
-	switch strings.ToUpper(arr[0]) {
-	case "PING":
-		handlers.Ping(conn, false)
+}
 
-	case "ECHO":
-		handlers.Echo(conn, arr[1])
+func handleCommand(arr [][]byte, buffer []byte, client *types.Client, state *types.ServerState) {
+	fmt.Printf("Command received:  %s\n", arr)
 
-	case "SET":
-		toReply := !isMasterCommand
-		handlers.Set(conn, state, toReply, arr[1:]...)
-		if state.Role == "master" {
-			state.BytesSent += len(buffer)
-			streamToReplicas(state.Replicas, buffer)
+	cmd, ok := lookupCommand(string(arr[0]))
+	switch {
+	case !ok:
+		var argsPreview string
+		for _, arg := range arr[1:] {
+			argsPreview += fmt.Sprintf("'%.128s' ", arg)
 		}
+		sendError(client, fmt.Sprintf("ERR unknown command '%.128s', with args beginning with: %s", arr[0], argsPreview))
 
-	case "GET":
-		handlers.Get(conn, &state.DB, &state.DBMutex, arr[1])
-
-	case "INFO":
-		handlers.Info(conn, state)
+	case !cmd.CheckArity(len(arr)):
+		sendError(client, fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(string(arr[0]))))
 
-	case "REPLCONF":
-		handlers.ReplConf(conn, arr[1:], state)
-
-	case "PSYNC":
-		handlers.Psync(conn, state.MasterReplID, state.MasterReplOffset)
+	case cmd.Flags&FlagWrite != 0 && state.Role == "slave" && !client.IsMaster:
+		sendError(client, "READONLY You can't write against a read only replica.")
 
 	default:
-		fmt.Println("Unknown command: ", arr[0])
+		cmd.Handler(client, state, arr[1:])
+
+		// Serve the clients blocked on keys that the command made ready
+		if cmd.Flags&FlagWrite != 0 {
+			state.DBMutex.Lock()
+			state.ServeBlockedClients()
+			state.DBMutex.Unlock()
+		}
 	}
 
 	// If this was a command from master, update the acknowledgment offset
-	if isMasterCommand {
+	if client.IsMaster {
 		state.AckOffset += len(buffer)
 	}
+}
 
-	if len(next) > 0 {
-		handleCommand(next, conn, state, isMasterCommand)
+// writeReply writes a reply in the protocol version spoken on the connection
+func writeReply(client *types.Client, value resp.Value) {
+	if client.Protocol == 3 {
+		client.Write(value.Encode())
+		return
 	}
+	client.Write(value.EncodeRESP2())
 }
 
+func sendError(client *types.Client, msg string) {
+	writeReply(client, resp.Error(msg))
+}
@@ -8,8 +8,11 @@ import (
 
 func GetServerState(args *Args) *types.ServerState {
 	state := types.ServerState{
-		DB:   map[string]types.DBItem{},
-		Port: args.port,
+		DB:           types.NewDict[types.DBItem](),
+		Expires:      map[string]struct{}{},
+		FieldExpires: map[string]struct{}{},
+		Blocked:      map[string][]*types.BlockedClient{},
+		Port:         args.port,
 
 		Role:             "master",
 		MasterReplID:     "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb",
@@ -32,8 +35,5 @@ func GetServerState(args *Args) *types.ServerState {
 	// 	file.InitialiseDB(&state, args.dbfilename, args.dir)
 	// }
 
-	// Initialise the streams map
-	state.Streams = map[string][]types.StreamEntry{}
-
 	return &state
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestHandleCommandErrors(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		role         string
+		args         []string
+		expected     string
+	}{
+		{
+			testCaseName: "Unknown command",
+			role:         "master",
+			args:         []string{"NOPE", "a", "b"},
+			expected:     "-ERR unknown command 'NOPE', with args beginning with: 'a' 'b' \r\n",
+		},
+		{
+			testCaseName: "Unknown command without arguments",
+			role:         "master",
+			args:         []string{"nope"},
+			expected:     "-ERR unknown command 'nope', with args beginning with: \r\n",
+		},
+		{
+			testCaseName: "Too few arguments",
+			role:         "master",
+			args:         []string{"GET"},
+			expected:     "-ERR wrong number of arguments for 'get' command\r\n",
+		},
+		{
+			testCaseName: "Too many arguments",
+			role:         "master",
+			args:         []string{"Get", "a", "b"},
+			expected:     "-ERR wrong number of arguments for 'get' command\r\n",
+		},
+		{
+			testCaseName: "Write on a replica",
+			role:         "slave",
+			args:         []string{"SET", "k", "v"},
+			expected:     "-READONLY You can't write against a read only replica.\r\n",
+		},
+		{
+			testCaseName: "Read on a replica",
+			role:         "slave",
+			args:         []string{"GET", "k"},
+			expected:     "$-1\r\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			server := newServerState(tc.role)
+			if reply := dispatch(t, server, tc.args...); reply != tc.expected {
+				t.Fatalf("Expected %q, got %q", tc.expected, reply)
+			}
+			if server.DB.Len() != 0 {
+				t.Fatalf("Expected the keyspace to be left untouched")
+			}
+		})
+	}
+}
+
+func TestHandleCommandFromMaster(t *testing.T) {
+	// Writes received from our master are applied on a replica, without a reply
+	server := newServerState("slave")
+	conn := &replyRecorder{}
+	client := types.NewClient(conn)
+	client.IsMaster = true
+	buffer := []byte("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n")
+	handleCommand([][]byte{[]byte("SET"), []byte("k"), []byte("v")}, buffer, client, server)
+
+	if conn.replies.Len() != 0 {
+		t.Fatalf("Expected no reply to our master, got %q", conn.replies.String())
+	}
+	if _, ok := server.DB.Get("k"); !ok {
+		t.Fatalf("Expected the write to be applied")
+	}
+	if server.AckOffset != len(buffer) {
+		t.Fatalf("Expected the acknowledgement offset to be %d, got %d", len(buffer), server.AckOffset)
+	}
+}
@@ -0,0 +1,231 @@
+package types
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Bitmaps are plain string values, addressed bit by bit with the most significant
+// bit of the first byte as bit 0, as in Redis. The functions here work on the
+// []byte held in DBItem.Value, which is binary safe.
+
+// GetBit returns the bit at offset, which is 0 past the end of b
+func GetBit(b []byte, offset uint64) int {
+	i := offset / 8
+	if i >= uint64(len(b)) {
+		return 0
+	}
+	return int(b[i]>>(7-offset%8)) & 1
+}
+
+// SetBit sets the bit at offset, growing b with zero bytes to hold it, and returns
+// the updated slice along with the previous value of the bit
+func SetBit(b []byte, offset uint64, bit int) ([]byte, int) {
+	b = growBits(b, offset+1)
+	old := GetBit(b, offset)
+	mask := byte(1) << (7 - offset%8)
+	if bit == 1 {
+		b[offset/8] |= mask
+	} else {
+		b[offset/8] &^= mask
+	}
+	return b, old
+}
+
+// growBits grows b with zero bytes so that it holds at least n bits
+func growBits(b []byte, n uint64) []byte {
+	if needed := int((n + 7) / 8); needed > len(b) {
+		b = append(b, make([]byte, needed-len(b))...)
+	}
+	return b
+}
+
+// CountBits returns the number of bits set from bit start to bit end inclusive,
+// which must be within b
+func CountBits(b []byte, start, end uint64) int64 {
+	first, last := start/8, end/8
+	if first == last {
+		return int64(bits.OnesCount8(b[first] & bitsFrom(start) & bitsUpTo(end)))
+	}
+	n := bits.OnesCount8(b[first] & bitsFrom(start))
+	for _, c := range b[first+1 : last] {
+		n += bits.OnesCount8(c)
+	}
+	n += bits.OnesCount8(b[last] & bitsUpTo(end))
+	return int64(n)
+}
+
+// bitsFrom returns a mask of the bits of a byte from offset on
+func bitsFrom(offset uint64) byte {
+	return 0xff >> (offset % 8)
+}
+
+// bitsUpTo returns a mask of the bits of a byte up to offset inclusive
+func bitsUpTo(offset uint64) byte {
+	return 0xff << (7 - offset%8)
+}
+
+// FindBit returns the offset of the first bit equal to bit from bit start to bit
+// end inclusive, which must be within b, or -1 if there is none
+func FindBit(b []byte, bit int, start, end uint64) int64 {
+	// Flipping the bytes when looking for a 0 makes both cases look for a 1
+	var flip byte
+	if bit == 0 {
+		flip = 0xff
+	}
+	for i := start / 8; i <= end/8; i++ {
+		c := b[i] ^ flip
+		if i == start/8 {
+			c &= bitsFrom(start)
+		}
+		if i == end/8 {
+			c &= bitsUpTo(end)
+		}
+		if c != 0 {
+			return int64(i*8) + int64(bits.LeadingZeros8(c))
+		}
+	}
+	return -1
+}
+
+// BitOp combines srcs bytewise with AND, OR, XOR or NOT, which takes a single
+// source. Shorter sources are padded with zero bytes to the longest one.
+func BitOp(op string, srcs [][]byte) []byte {
+	var n int
+	for _, src := range srcs {
+		n = max(n, len(src))
+	}
+	res := make([]byte, n)
+	if op == "NOT" {
+		for i, c := range srcs[0] {
+			res[i] = ^c
+		}
+		return res
+	}
+
+	copy(res, srcs[0])
+	for _, src := range srcs[1:] {
+		for i := range res {
+			var c byte
+			if i < len(src) {
+				c = src[i]
+			}
+			switch op {
+			case "AND":
+				res[i] &= c
+			case "OR":
+				res[i] |= c
+			case "XOR":
+				res[i] ^= c
+			}
+		}
+	}
+	return res
+}
+
+// Overflow is how BITFIELD handles values that do not fit an integer type
+type Overflow int
+
+const (
+	OverflowWrap Overflow = iota // Wrap around, as with C integers
+	OverflowSat                  // Saturate to the minimum or maximum value
+	OverflowFail                 // Leave the value alone and report the failure
+)
+
+// BitfieldType is a signed or unsigned integer type of 1 to 64 bits, or 63 bits
+// for unsigned ones, so that every value fits an int64
+type BitfieldType struct {
+	Signed bool
+	Bits   uint
+}
+
+func (t BitfieldType) limits() (int64, int64) {
+	if t.Signed {
+		maxValue := int64(math.MaxInt64 >> (64 - t.Bits))
+		return -maxValue - 1, maxValue
+	}
+	return 0, int64(math.MaxInt64 >> (63 - t.Bits))
+}
+
+// Get returns the integer stored at offset, treating bits past the end of b as 0
+func (t BitfieldType) Get(b []byte, offset uint64) int64 {
+	var v uint64
+	for i := uint64(0); i < uint64(t.Bits); i++ {
+		v = v<<1 | uint64(GetBit(b, offset+i))
+	}
+	if t.Signed && t.Bits < 64 && v&(1<<(t.Bits-1)) != 0 {
+		v |= math.MaxUint64 << t.Bits // Sign extension
+	}
+	return int64(v)
+}
+
+// Set stores the low bits of v at offset, growing b to hold them, and returns the
+// updated slice
+func (t BitfieldType) Set(b []byte, offset uint64, v int64) []byte {
+	b = growBits(b, offset+uint64(t.Bits))
+	for i := uint64(0); i < uint64(t.Bits); i++ {
+		bit := int(uint64(v)>>(uint64(t.Bits)-1-i)) & 1
+		b, _ = SetBit(b, offset+i, bit)
+	}
+	return b
+}
+
+// Add returns v plus incr, handling a result that does not fit the type as
+// overflow says. It reports false if the result does not fit and overflow is
+// OverflowFail.
+func (t BitfieldType) Add(v, incr int64, overflow Overflow) (int64, bool) {
+	minValue, maxValue := t.limits()
+
+	// Computing the sum in 128 bits keeps it exact for every type and argument
+	lo, carry := bits.Add64(uint64(v), uint64(incr), 0)
+	hi := carry + uint64(v>>63) + uint64(incr>>63) // Sign extension of both operands
+	negative := int64(hi) < 0
+	tooBig := !negative && (hi != 0 || lo > uint64(maxValue))
+	tooSmall := negative && (hi != math.MaxUint64 || int64(lo) >= 0 || int64(lo) < minValue)
+	if !t.Signed && negative {
+		tooSmall = true
+	}
+	if !tooBig && !tooSmall {
+		return int64(lo), true
+	}
+
+	switch overflow {
+	case OverflowSat:
+		if tooBig {
+			return maxValue, true
+		}
+		return minValue, true
+	case OverflowFail:
+		return 0, false
+	}
+
+	// Wrapping keeps the low bits, sign extended for signed types
+	if t.Bits == 64 {
+		return int64(lo), true
+	}
+	lo &= 1<<t.Bits - 1
+	if t.Signed && lo&(1<<(t.Bits-1)) != 0 {
+		lo |= math.MaxUint64 << t.Bits
+	}
+	return int64(lo), true
+}
+
+// Fit returns v, handling a value that does not fit the type as overflow says, like
+// Add. Negative values are out of range of unsigned types from above, as in Redis,
+// which reads the value of an unsigned field to set as a 64 bit unsigned integer.
+func (t BitfieldType) Fit(v int64, overflow Overflow) (int64, bool) {
+	if t.Signed {
+		return t.Add(0, v, overflow)
+	}
+	_, maxValue := t.limits()
+	if uint64(v) <= uint64(maxValue) {
+		return v, true
+	}
+	switch overflow {
+	case OverflowSat:
+		return maxValue, true
+	case OverflowFail:
+		return 0, false
+	}
+	return int64(uint64(v) & uint64(maxValue)), true
+}
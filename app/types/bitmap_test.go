@@ -0,0 +1,118 @@
+package types_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestBits(t *testing.T) {
+	b, old := types.SetBit(nil, 9, 1)
+	if len(b) != 2 || b[1] != 0x40 || old != 0 {
+		t.Fatalf("Expected [0x00 0x40] and a previous bit of 0, got %x and %d", b, old)
+	}
+	if types.GetBit(b, 9) != 1 || types.GetBit(b, 8) != 0 || types.GetBit(b, 100) != 0 {
+		t.Fatalf("Unexpected bits in %x", b)
+	}
+
+	b = []byte{0xff, 0xf0, 0x00}
+	tests := []struct {
+		testCaseName string
+		start, end   uint64
+		count        int64
+		firstSet     int64
+		firstClear   int64
+	}{
+		{testCaseName: "Whole string", start: 0, end: 23, count: 12, firstSet: 0, firstClear: 12},
+		{testCaseName: "Within a byte", start: 10, end: 13, count: 2, firstSet: 10, firstClear: 12},
+		{testCaseName: "Across bytes", start: 3, end: 17, count: 9, firstSet: 3, firstClear: 12},
+		{testCaseName: "Zeros only", start: 16, end: 23, count: 0, firstSet: -1, firstClear: 16},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			if n := types.CountBits(b, tc.start, tc.end); n != tc.count {
+				t.Fatalf("Expected %d bits set, got %d", tc.count, n)
+			}
+			if pos := types.FindBit(b, 1, tc.start, tc.end); pos != tc.firstSet {
+				t.Fatalf("Expected the first bit set at %d, got %d", tc.firstSet, pos)
+			}
+			if pos := types.FindBit(b, 0, tc.start, tc.end); pos != tc.firstClear {
+				t.Fatalf("Expected the first bit cleared at %d, got %d", tc.firstClear, pos)
+			}
+		})
+	}
+}
+
+func TestBitOp(t *testing.T) {
+	srcs := [][]byte{{0xf0, 0x0f}, {0xff}}
+	tests := []struct {
+		op       string
+		expected []byte
+	}{
+		{op: "AND", expected: []byte{0xf0, 0x00}},
+		{op: "OR", expected: []byte{0xff, 0x0f}},
+		{op: "XOR", expected: []byte{0x0f, 0x0f}},
+		{op: "NOT", expected: []byte{0x0f, 0xf0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.op, func(t *testing.T) {
+			res := types.BitOp(tc.op, srcs)
+			if string(res) != string(tc.expected) {
+				t.Fatalf("Expected %x, got %x", tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestBitfield(t *testing.T) {
+	i8 := types.BitfieldType{Signed: true, Bits: 8}
+	u4 := types.BitfieldType{Bits: 4}
+	i64 := types.BitfieldType{Signed: true, Bits: 64}
+
+	// Fields need not be aligned on bytes
+	b := i8.Set(nil, 4, -2)
+	if len(b) != 2 || b[0] != 0x0f || b[1] != 0xe0 {
+		t.Fatalf("Expected [0x0f 0xe0], got %x", b)
+	}
+	if v := i8.Get(b, 4); v != -2 {
+		t.Fatalf("Expected -2, got %d", v)
+	}
+	if v := u4.Get(b, 4); v != 15 {
+		t.Fatalf("Expected 15, got %d", v)
+	}
+
+	tests := []struct {
+		testCaseName string
+		typ          types.BitfieldType
+		v, incr      int64
+		overflow     types.Overflow
+		expected     int64
+		ok           bool
+	}{
+		{testCaseName: "In range", typ: i8, v: 100, incr: 27, overflow: types.OverflowFail, expected: 127, ok: true},
+		{testCaseName: "Signed wrap", typ: i8, v: 100, incr: 28, overflow: types.OverflowWrap, expected: -128, ok: true},
+		{testCaseName: "Signed saturation", typ: i8, v: -100, incr: -100, overflow: types.OverflowSat, expected: -128, ok: true},
+		{testCaseName: "Signed failure", typ: i8, v: 100, incr: 28, overflow: types.OverflowFail, ok: false},
+		{testCaseName: "Unsigned wrap", typ: u4, v: 15, incr: 2, overflow: types.OverflowWrap, expected: 1, ok: true},
+		{testCaseName: "Unsigned underflow", typ: u4, v: 1, incr: -2, overflow: types.OverflowWrap, expected: 15, ok: true},
+		{testCaseName: "Unsigned saturation", typ: u4, v: 1, incr: -2, overflow: types.OverflowSat, expected: 0, ok: true},
+		{testCaseName: "64 bit wrap", typ: i64, v: math.MaxInt64, incr: 1, overflow: types.OverflowWrap, expected: math.MinInt64, ok: true},
+		{testCaseName: "64 bit saturation", typ: i64, v: math.MaxInt64, incr: math.MaxInt64, overflow: types.OverflowSat, expected: math.MaxInt64, ok: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			v, ok := tc.typ.Add(tc.v, tc.incr, tc.overflow)
+			if ok != tc.ok || (ok && v != tc.expected) {
+				t.Fatalf("Expected %d and %t, got %d and %t", tc.expected, tc.ok, v, ok)
+			}
+		})
+	}
+
+	if v, _ := u4.Fit(-1, types.OverflowSat); v != 15 {
+		t.Fatalf("Expected -1 to saturate to 15, got %d", v)
+	}
+}
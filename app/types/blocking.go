@@ -0,0 +1,87 @@
+package types
+
+// BlockedClient is a client waiting in a blocking command, such as BLPOP, for one
+// of its keys to become ready
+type BlockedClient struct {
+	Keys []string
+
+	// Serve tries to complete the command once key may be ready. It is called with
+	// DBMutex held and reports whether the command completed, unblocking the client.
+	Serve func(key string) bool
+
+	Done chan struct{} // Closed once the command completed
+}
+
+func NewBlockedClient(keys []string, serve func(key string) bool) *BlockedClient {
+	return &BlockedClient{Keys: keys, Serve: serve, Done: make(chan struct{})}
+}
+
+// Block parks a client until one of its keys becomes ready. Clients blocked on
+// the same key are served in the order they blocked.
+// It must be called with DBMutex held.
+func (s *ServerState) Block(bc *BlockedClient) {
+	for _, key := range bc.Keys {
+		s.Blocked[key] = append(s.Blocked[key], bc)
+	}
+}
+
+// Unblock removes a client from the keys it is blocked on, for instance once its
+// timeout has expired. It must be called with DBMutex held.
+func (s *ServerState) Unblock(bc *BlockedClient) {
+	for _, key := range bc.Keys {
+		clients := s.Blocked[key]
+		for i, c := range clients {
+			if c == bc {
+				clients = append(clients[:i], clients[i+1:]...)
+				break
+			}
+		}
+		if len(clients) == 0 {
+			delete(s.Blocked, key)
+		} else {
+			s.Blocked[key] = clients
+		}
+	}
+}
+
+// SignalKeyReady records that key was written to in a way that may let clients
+// blocked on it complete. It must be called with DBMutex held.
+func (s *ServerState) SignalKeyReady(key string) {
+	if _, ok := s.Blocked[key]; !ok {
+		return
+	}
+	if _, ok := s.readyKeys[key]; ok {
+		return
+	}
+	if s.readyKeys == nil {
+		s.readyKeys = map[string]struct{}{}
+	}
+	s.readyKeys[key] = struct{}{}
+	s.readyKeyOrder = append(s.readyKeyOrder, key)
+}
+
+// ServeBlockedClients serves the clients blocked on keys that were signalled as
+// ready, in the order they blocked, until the keys are consumed. Serving a client
+// may make further keys ready, which are served as well.
+// It must be called with DBMutex held.
+func (s *ServerState) ServeBlockedClients() {
+	for len(s.readyKeyOrder) > 0 {
+		keys := s.readyKeyOrder
+		s.readyKeyOrder = nil
+		clear(s.readyKeys)
+
+		for _, key := range keys {
+			// Copied, as serving a client removes it from the list
+			clients := append([]*BlockedClient{}, s.Blocked[key]...)
+			for _, bc := range clients {
+				if _, ok := s.DB.Get(key); !ok {
+					break
+				}
+				if bc.Serve(key) {
+					s.Unblock(bc)
+					close(bc.Done)
+				}
+			}
+		}
+	}
+}
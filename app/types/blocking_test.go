@@ -0,0 +1,186 @@
+package types_test
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func newBlockingServer() *types.ServerState {
+	return &types.ServerState{
+		DB:           types.NewDict[types.DBItem](),
+		Expires:      map[string]struct{}{},
+		FieldExpires: map[string]struct{}{},
+		Blocked:      map[string][]*types.BlockedClient{},
+	}
+}
+
+// push appends elements to the list at key, signalling it as ready
+func push(server *types.ServerState, key string, elems ...string) {
+	item, ok := server.DB.Get(key)
+	if !ok {
+		item = types.DBItem{Object: types.NewList(), Expiry: -1}
+	}
+	for _, elem := range elems {
+		item.Object.(*types.List).PushBack([]byte(elem))
+	}
+	server.SetItem(key, item)
+}
+
+// blockPopper blocks a client named name on keys, which pops an element from the
+// first ready key and records it in served
+func blockPopper(server *types.ServerState, name string, served *[]string, keys ...string) *types.BlockedClient {
+	bc := types.NewBlockedClient(keys, func(key string) bool {
+		item, ok := server.DB.Get(key)
+		if !ok {
+			return false
+		}
+		list := item.Object.(*types.List)
+		elem, _ := list.PopFront()
+		if list.Len() == 0 {
+			server.DeleteItem(key)
+		}
+		*served = append(*served, name+":"+string(elem))
+		return true
+	})
+	server.Block(bc)
+	return bc
+}
+
+func isDone(bc *types.BlockedClient) bool {
+	select {
+	case <-bc.Done:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestServeBlockedClientsInOrder(t *testing.T) {
+	server := newBlockingServer()
+	served := []string{}
+	a := blockPopper(server, "a", &served, "k1")
+	b := blockPopper(server, "b", &served, "k2", "k1")
+	c := blockPopper(server, "c", &served, "k1")
+
+	// Pushing to a key nobody waits on serves nobody
+	push(server, "other", "x")
+	server.ServeBlockedClients()
+	if len(served) != 0 {
+		t.Fatalf("Expected no client to be served, got %v", served)
+	}
+
+	push(server, "k1", "1", "2")
+	server.ServeBlockedClients()
+	if expected := []string{"a:1", "b:2"}; !reflect.DeepEqual(served, expected) {
+		t.Fatalf("Expected %v, got %v", expected, served)
+	}
+	if !isDone(a) || !isDone(b) || isDone(c) {
+		t.Fatalf("Expected a and b to be unblocked, and c to still be blocked")
+	}
+	// b is removed from every key it was blocked on
+	if got := server.Blocked["k1"]; len(got) != 1 || got[0] != c {
+		t.Fatalf("Expected only c to be blocked on k1, got %v", got)
+	}
+	if _, ok := server.Blocked["k2"]; ok {
+		t.Fatalf("Expected no client to be blocked on k2")
+	}
+
+	d := blockPopper(server, "d", &served, "k1")
+	push(server, "k1", "3")
+	push(server, "k1", "4")
+	server.ServeBlockedClients()
+	if expected := []string{"a:1", "b:2", "c:3", "d:4"}; !reflect.DeepEqual(served, expected) {
+		t.Fatalf("Expected %v, got %v", expected, served)
+	}
+	if !isDone(c) || !isDone(d) || len(server.Blocked) != 0 {
+		t.Fatalf("Expected all clients to be unblocked, got %v", server.Blocked)
+	}
+}
+
+func TestServeBlockedClientsChained(t *testing.T) {
+	server := newBlockingServer()
+	served := []string{}
+	// Serving a moves its element to k2, which makes b ready in the same pass
+	a := types.NewBlockedClient([]string{"k1"}, func(key string) bool {
+		item, _ := server.DB.Get(key)
+		elem, _ := item.Object.(*types.List).PopFront()
+		server.DeleteItem(key)
+		push(server, "k2", string(elem))
+		served = append(served, "a:"+string(elem))
+		return true
+	})
+	server.Block(a)
+	blockPopper(server, "b", &served, "k2")
+
+	push(server, "k1", "x")
+	server.ServeBlockedClients()
+	if expected := []string{"a:x", "b:x"}; !reflect.DeepEqual(served, expected) {
+		t.Fatalf("Expected %v, got %v", expected, served)
+	}
+	if len(server.Blocked) != 0 {
+		t.Fatalf("Expected all clients to be unblocked, got %v", server.Blocked)
+	}
+}
+
+func TestUnblockOnTimeout(t *testing.T) {
+	server := newBlockingServer()
+	served := []string{}
+	a := blockPopper(server, "a", &served, "k1", "k2")
+	b := blockPopper(server, "b", &served, "k1")
+
+	// As blocking commands do once their timeout expires
+	select {
+	case <-a.Done:
+		t.Fatalf("Expected a not to be served")
+	case <-time.After(10 * time.Millisecond):
+		server.Unblock(a)
+	}
+	if _, ok := server.Blocked["k2"]; ok {
+		t.Fatalf("Expected no client to be blocked on k2")
+	}
+
+	push(server, "k1", "1", "2")
+	push(server, "k2", "3")
+	server.ServeBlockedClients()
+	if expected := []string{"b:1"}; !reflect.DeepEqual(served, expected) {
+		t.Fatalf("Expected %v, got %v", expected, served)
+	}
+	if isDone(a) || !isDone(b) || len(server.Blocked) != 0 {
+		t.Fatalf("Expected only b to be served, and no client to be left blocked, got %v", server.Blocked)
+	}
+}
+
+func TestUnblockOnDisconnect(t *testing.T) {
+	server := newBlockingServer()
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	client := types.NewClient(conn)
+
+	served := []string{}
+	a := blockPopper(server, "a", &served, "k")
+	b := blockPopper(server, "b", &served, "k")
+	c := blockPopper(server, "c", &served, "k")
+
+	// b's connection goes away, which may be marked more than once
+	client.MarkClosed()
+	client.MarkClosed()
+	select {
+	case <-client.Closed():
+		server.Unblock(b)
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Closed to be closed")
+	}
+
+	push(server, "k", "1", "2", "3")
+	server.ServeBlockedClients()
+	if expected := []string{"a:1", "c:2"}; !reflect.DeepEqual(served, expected) {
+		t.Fatalf("Expected %v, got %v", expected, served)
+	}
+	if !isDone(a) || isDone(b) || !isDone(c) || len(server.Blocked) != 0 {
+		t.Fatalf("Expected a and c to be served, and no client to be left blocked, got %v", server.Blocked)
+	}
+}
@@ -0,0 +1,171 @@
+package types
+
+import (
+	"errors"
+	"math"
+)
+
+// A scalable Bloom filter is a stack of Bloom filters, as in RedisBloom. Items are
+// added to the last filter until it holds as many as its capacity, at which point a
+// filter Expansion times larger, with half the error rate, is added. The error rate
+// of the whole stack stays below twice that of the first filter.
+const (
+	BloomDefaultErrorRate = 0.01
+	BloomDefaultCapacity  = 100
+	BloomDefaultExpansion = 2
+
+	bloomTighteningRatio = 0.5
+	bloomSeed            = 0xc6a4a7935bd1e995
+)
+
+// ErrBloomFull is returned when adding to a filter that cannot grow and is full
+var ErrBloomFull = errors.New("non scaling filter is full")
+
+type bloomLayer struct {
+	bits     []byte
+	numBits  uint64
+	hashes   uint64
+	capacity uint64
+	count    uint64
+}
+
+func newBloomLayer(capacity uint64, errorRate float64) *bloomLayer {
+	// Bits per item and number of hashes minimizing the size for the error rate
+	bpe := -math.Log(errorRate) / (math.Ln2 * math.Ln2)
+	numBits := max(uint64(math.Ceil(float64(capacity)*bpe)), 8)
+	return &bloomLayer{
+		bits:     make([]byte, (numBits+7)/8),
+		numBits:  numBits,
+		hashes:   uint64(math.Ceil(math.Ln2 * bpe)),
+		capacity: capacity,
+	}
+}
+
+// bloomHashes returns the two hashes the positions of an item are derived from
+func bloomHashes(item []byte) (uint64, uint64) {
+	a := murmurHash64A(item, bloomSeed)
+	return a, murmurHash64A(item, a)
+}
+
+func (l *bloomLayer) contains(a, b uint64) bool {
+	for i := uint64(0); i < l.hashes; i++ {
+		pos := (a + i*b) % l.numBits
+		if l.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *bloomLayer) add(a, b uint64) {
+	for i := uint64(0); i < l.hashes; i++ {
+		pos := (a + i*b) % l.numBits
+		l.bits[pos/8] |= 1 << (pos % 8)
+	}
+	l.count++
+}
+
+type BloomFilter struct {
+	layers    []*bloomLayer
+	errorRate float64
+	expansion uint64 // 0 if the filter does not scale
+}
+
+// NewBloomFilter returns an empty filter holding capacity items with the given
+// error rate before it grows, or fills up if expansion is 0
+func NewBloomFilter(errorRate float64, capacity, expansion uint64) *BloomFilter {
+	return &BloomFilter{
+		layers:    []*bloomLayer{newBloomLayer(capacity, errorRate)},
+		errorRate: errorRate,
+		expansion: expansion,
+	}
+}
+
+func (f *BloomFilter) Type() string {
+	return "MBbloom--"
+}
+
+func (f *BloomFilter) Copy() Object {
+	c := &BloomFilter{errorRate: f.errorRate, expansion: f.expansion}
+	for _, l := range f.layers {
+		lc := *l
+		lc.bits = append([]byte(nil), l.bits...)
+		c.layers = append(c.layers, &lc)
+	}
+	return c
+}
+
+// BloomLayer is the state of one filter of the stack, as saved in snapshots
+type BloomLayer struct {
+	Bits     []byte
+	NumBits  uint64
+	Hashes   uint64
+	Capacity uint64
+	Count    uint64
+}
+
+// Layers returns the state of the filters of the stack, from the first one added.
+// The bits are shared with the filter.
+func (f *BloomFilter) Layers() []BloomLayer {
+	layers := make([]BloomLayer, len(f.layers))
+	for i, l := range f.layers {
+		layers[i] = BloomLayer{Bits: l.bits, NumBits: l.numBits, Hashes: l.hashes, Capacity: l.capacity, Count: l.count}
+	}
+	return layers
+}
+
+func (f *BloomFilter) ErrorRate() float64 {
+	return f.errorRate
+}
+
+func (f *BloomFilter) Expansion() uint64 {
+	return f.expansion
+}
+
+// RestoreBloomFilter rebuilds a filter saved with Layers
+func RestoreBloomFilter(errorRate float64, expansion uint64, layers []BloomLayer) (*BloomFilter, error) {
+	if len(layers) == 0 {
+		return nil, errors.New("bloom filter without layers")
+	}
+	f := &BloomFilter{errorRate: errorRate, expansion: expansion}
+	for _, l := range layers {
+		if l.NumBits == 0 || l.Hashes == 0 || uint64(len(l.Bits)) != (l.NumBits+7)/8 {
+			return nil, errors.New("invalid bloom filter layer")
+		}
+		f.layers = append(f.layers, &bloomLayer{bits: l.Bits, numBits: l.NumBits, hashes: l.Hashes, capacity: l.Capacity, count: l.Count})
+	}
+	return f, nil
+}
+
+// Exists reports whether an item may have been added to the filter
+func (f *BloomFilter) Exists(item []byte) bool {
+	a, b := bloomHashes(item)
+	for _, l := range f.layers {
+		if l.contains(a, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add adds an item and reports whether it was not in the filter yet
+func (f *BloomFilter) Add(item []byte) (bool, error) {
+	a, b := bloomHashes(item)
+	for _, l := range f.layers {
+		if l.contains(a, b) {
+			return false, nil
+		}
+	}
+
+	last := f.layers[len(f.layers)-1]
+	if last.count >= last.capacity {
+		if f.expansion == 0 {
+			return false, ErrBloomFull
+		}
+		errorRate := f.errorRate * math.Pow(bloomTighteningRatio, float64(len(f.layers)))
+		last = newBloomLayer(last.capacity*f.expansion, errorRate)
+		f.layers = append(f.layers, last)
+	}
+	last.add(a, b)
+	return true, nil
+}
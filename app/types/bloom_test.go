@@ -0,0 +1,54 @@
+package types_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestBloomFilter(t *testing.T) {
+	f := types.NewBloomFilter(0.01, 100, 2)
+	for i := 0; i < 1000; i++ {
+		if _, err := f.Add([]byte(fmt.Sprint("item", i))); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		if !f.Exists([]byte(fmt.Sprint("item", i))) {
+			t.Fatalf("Expected item%d to exist", i)
+		}
+	}
+	if added, _ := f.Add([]byte("item0")); added {
+		t.Fatalf("Expected item0 not to be added twice")
+	}
+
+	// The filter grew to keep its error rate below 2%
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if f.Exists([]byte(fmt.Sprint("other", i))) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 200 {
+		t.Fatalf("Expected at most 200 false positives, got %d", falsePositives)
+	}
+
+	c := f.Copy().(*types.BloomFilter)
+	c.Add([]byte("copy"))
+	if f.Exists([]byte("copy")) {
+		t.Fatalf("Expected the copy not to share bits with the original")
+	}
+}
+
+func TestBloomFilterNonScaling(t *testing.T) {
+	f := types.NewBloomFilter(0.01, 10, 0)
+	var err error
+	for i := 0; i < 20 && err == nil; i++ {
+		_, err = f.Add([]byte(fmt.Sprint("item", i)))
+	}
+	if !errors.Is(err, types.ErrBloomFull) {
+		t.Fatalf("Expected the filter to fill up, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+var lastClientID atomic.Int64
+
+// Client wraps a connection with the state that is kept per connection
+type Client struct {
+	net.Conn
+	ID       int64
+	Protocol int    // RESP protocol version used for replies (2 or 3), switched with HELLO
+	Name     string // Name set with HELLO SETNAME
+	IsMaster bool   // Connection to our master, over which the replication stream is received
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewClient(conn net.Conn) *Client {
+	return &Client{
+		Conn:     conn,
+		ID:       lastClientID.Add(1),
+		Protocol: 2,
+		closed:   make(chan struct{}),
+	}
+}
+
+// Closed returns a channel that is closed once the connection stops delivering
+// commands, which lets a blocked command give up when the client goes away
+func (c *Client) Closed() <-chan struct{} {
+	return c.closed
+}
+
+// MarkClosed records that the connection stops delivering commands
+func (c *Client) MarkClosed() {
+	c.closeOnce.Do(func() { close(c.closed) })
+}
+
+// Write sends a reply to the client. Replies to the master are discarded, since the
+// replication stream is one way (apart from REPLCONF ACK, which is written directly to Conn).
+func (c *Client) Write(b []byte) (int, error) {
+	if c.IsMaster {
+		return len(b), nil
+	}
+	return c.Conn.Write(b)
+}
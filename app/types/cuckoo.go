@@ -0,0 +1,214 @@
+package types
+
+import "errors"
+
+// A cuckoo filter stores an 8 bit fingerprint of each item in one of two buckets,
+// the second derived from the first and the fingerprint, so that fingerprints can be
+// moved between their buckets to make room, and removed. When room cannot be made,
+// a filter of the same size is added, as in RedisBloom with its default expansion.
+const (
+	CuckooDefaultCapacity = 1024
+
+	cuckooBucketSize    = 2
+	cuckooMaxIterations = 20
+)
+
+type cuckooLayer struct {
+	buckets    [][cuckooBucketSize]uint8 // 0 for empty slots
+	numBuckets uint64                    // A power of 2
+}
+
+func newCuckooLayer(capacity uint64) *cuckooLayer {
+	numBuckets := uint64(1)
+	for numBuckets*cuckooBucketSize < capacity {
+		numBuckets <<= 1
+	}
+	return &cuckooLayer{buckets: make([][cuckooBucketSize]uint8, numBuckets), numBuckets: numBuckets}
+}
+
+// altIndex returns the other bucket of a fingerprint. The mapping is its own
+// inverse, so a fingerprint can be moved back and forth knowing only its bucket.
+func altIndex(i uint64, fp uint8) uint64 {
+	return i ^ (uint64(fp) * 0x5bd1e995)
+}
+
+func (l *cuckooLayer) bucket(i uint64) *[cuckooBucketSize]uint8 {
+	return &l.buckets[i&(l.numBuckets-1)]
+}
+
+func (l *cuckooLayer) contains(i1, i2 uint64, fp uint8) bool {
+	for _, i := range []uint64{i1, i2} {
+		for _, slot := range l.bucket(i) {
+			if slot == fp {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// insertFree stores a fingerprint in a free slot of either of its buckets
+func (l *cuckooLayer) insertFree(i1, i2 uint64, fp uint8) bool {
+	for _, i := range []uint64{i1, i2} {
+		b := l.bucket(i)
+		for j, slot := range b {
+			if slot == 0 {
+				b[j] = fp
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// insertEvicting stores a fingerprint by moving the ones in its way to their other
+// bucket, and so on. If no room is found within cuckooMaxIterations moves, they are
+// undone. Slots are picked in turn rather than at random, so that replicas applying
+// the same additions end up with the same filter.
+func (l *cuckooLayer) insertEvicting(i uint64, fp uint8) bool {
+	type move struct {
+		bucket uint64
+		slot   int
+	}
+	moves := []move{}
+	for n := 0; n < cuckooMaxIterations; n++ {
+		slot := n % cuckooBucketSize
+		b := l.bucket(i)
+		fp, b[slot] = b[slot], fp
+		moves = append(moves, move{i, slot})
+
+		i = altIndex(i, fp)
+		if l.insertFree(i, i, fp) {
+			return true
+		}
+	}
+
+	for n := len(moves) - 1; n >= 0; n-- {
+		b := l.bucket(moves[n].bucket)
+		fp, b[moves[n].slot] = b[moves[n].slot], fp
+	}
+	return false
+}
+
+func (l *cuckooLayer) delete(i1, i2 uint64, fp uint8) bool {
+	for _, i := range []uint64{i1, i2} {
+		b := l.bucket(i)
+		for j, slot := range b {
+			if slot == fp {
+				b[j] = 0
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type CuckooFilter struct {
+	layers   []*cuckooLayer
+	capacity uint64
+}
+
+func NewCuckooFilter(capacity uint64) *CuckooFilter {
+	return &CuckooFilter{layers: []*cuckooLayer{newCuckooLayer(capacity)}, capacity: capacity}
+}
+
+func (f *CuckooFilter) Type() string {
+	return "MBbloomCF"
+}
+
+func (f *CuckooFilter) Copy() Object {
+	c := &CuckooFilter{capacity: f.capacity}
+	for _, l := range f.layers {
+		c.layers = append(c.layers, &cuckooLayer{
+			buckets:    append([][cuckooBucketSize]uint8(nil), l.buckets...),
+			numBuckets: l.numBuckets,
+		})
+	}
+	return c
+}
+
+func (f *CuckooFilter) Capacity() uint64 {
+	return f.capacity
+}
+
+// Layers returns the buckets of each filter, from the first one added, as
+// cuckooBucketSize fingerprints per bucket
+func (f *CuckooFilter) Layers() [][]byte {
+	layers := make([][]byte, len(f.layers))
+	for i, l := range f.layers {
+		layers[i] = make([]byte, 0, len(l.buckets)*cuckooBucketSize)
+		for _, b := range l.buckets {
+			layers[i] = append(layers[i], b[:]...)
+		}
+	}
+	return layers
+}
+
+// RestoreCuckooFilter rebuilds a filter saved with Layers
+func RestoreCuckooFilter(capacity uint64, layers [][]byte) (*CuckooFilter, error) {
+	if len(layers) == 0 {
+		return nil, errors.New("cuckoo filter without layers")
+	}
+	f := &CuckooFilter{capacity: capacity}
+	for _, data := range layers {
+		numBuckets := uint64(len(data) / cuckooBucketSize)
+		if len(data)%cuckooBucketSize != 0 || numBuckets == 0 || numBuckets&(numBuckets-1) != 0 {
+			return nil, errors.New("invalid cuckoo filter layer")
+		}
+		l := &cuckooLayer{buckets: make([][cuckooBucketSize]uint8, numBuckets), numBuckets: numBuckets}
+		for i := range l.buckets {
+			copy(l.buckets[i][:], data[i*cuckooBucketSize:])
+		}
+		f.layers = append(f.layers, l)
+	}
+	return f, nil
+}
+
+// cuckooHash returns the fingerprint of an item and its first bucket
+func cuckooHash(item []byte) (uint64, uint8) {
+	h := murmurHash64A(item, 0)
+	return h, uint8(h%255 + 1)
+}
+
+// Add adds an item, even if it may be in the filter already, in which case deleting
+// it once leaves it in the filter
+func (f *CuckooFilter) Add(item []byte) {
+	i1, fp := cuckooHash(item)
+	i2 := altIndex(i1, fp)
+	for n := len(f.layers) - 1; n >= 0; n-- {
+		if f.layers[n].insertFree(i1, i2, fp) {
+			return
+		}
+	}
+	if f.layers[len(f.layers)-1].insertEvicting(i1, fp) {
+		return
+	}
+	l := newCuckooLayer(f.capacity)
+	l.insertFree(i1, i2, fp)
+	f.layers = append(f.layers, l)
+}
+
+// Exists reports whether an item may be in the filter
+func (f *CuckooFilter) Exists(item []byte) bool {
+	i1, fp := cuckooHash(item)
+	i2 := altIndex(i1, fp)
+	for _, l := range f.layers {
+		if l.contains(i1, i2, fp) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete removes an item added before, and reports whether it may have been in the
+// filter. Deleting an item that was not added may remove another one instead.
+func (f *CuckooFilter) Delete(item []byte) bool {
+	i1, fp := cuckooHash(item)
+	i2 := altIndex(i1, fp)
+	for n := len(f.layers) - 1; n >= 0; n-- {
+		if f.layers[n].delete(i1, i2, fp) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package types_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestCuckooFilter(t *testing.T) {
+	// Adding far more than the capacity makes the filter evict fingerprints and grow
+	f := types.NewCuckooFilter(64)
+	for i := 0; i < 500; i++ {
+		f.Add([]byte(fmt.Sprint("item", i)))
+	}
+	for i := 0; i < 500; i++ {
+		if !f.Exists([]byte(fmt.Sprint("item", i))) {
+			t.Fatalf("Expected item%d to exist", i)
+		}
+	}
+
+	c := f.Copy().(*types.CuckooFilter)
+	for i := 0; i < 500; i++ {
+		if !f.Delete([]byte(fmt.Sprint("item", i))) {
+			t.Fatalf("Expected item%d to be deleted", i)
+		}
+	}
+	for i := 0; i < 500; i++ {
+		if f.Exists([]byte(fmt.Sprint("item", i))) {
+			t.Fatalf("Expected item%d not to exist once deleted", i)
+		}
+		if !c.Exists([]byte(fmt.Sprint("item", i))) {
+			t.Fatalf("Expected item%d to exist in the copy", i)
+		}
+	}
+
+	// Duplicates are counted, and need deleting as many times
+	f.Add([]byte("dup"))
+	f.Add([]byte("dup"))
+	f.Delete([]byte("dup"))
+	if !f.Exists([]byte("dup")) {
+		t.Fatalf("Expected dup to exist after a single deletion")
+	}
+	f.Delete([]byte("dup"))
+	if f.Exists([]byte("dup")) || f.Delete([]byte("dup")) {
+		t.Fatalf("Expected dup not to exist after two deletions")
+	}
+}
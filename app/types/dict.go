@@ -0,0 +1,301 @@
+package types
+
+import (
+	"hash/maphash"
+	"math/bits"
+	"math/rand"
+)
+
+const (
+	dictMinSize          = 4
+	dictRehashEmptyVisit = 10 // Most empty buckets skipped by a single rehash step
+)
+
+var dictSeed = maphash.MakeSeed()
+
+type dictEntry[V any] struct {
+	key   string
+	value V
+}
+
+// Dict is a hash table modelled after the one in Redis. It differs from a Go map in
+// two ways that matter for a keyspace: it can be walked with a cursor that stays
+// valid while the table grows or shrinks between calls, and it is resized
+// incrementally, a few buckets at a time, so no single write pays for a full rehash.
+type Dict[V any] struct {
+	tables    [2][][]dictEntry[V] // tables[1] is only in use while rehashing
+	used      [2]int
+	rehashIdx int // Next bucket of tables[0] to move to tables[1], -1 when not rehashing
+}
+
+func NewDict[V any]() *Dict[V] {
+	return &Dict[V]{rehashIdx: -1}
+}
+
+func (d *Dict[V]) Len() int {
+	return d.used[0] + d.used[1]
+}
+
+func (d *Dict[V]) isRehashing() bool {
+	return d.rehashIdx != -1
+}
+
+func hashKey(key string) uint64 {
+	return maphash.String(dictSeed, key)
+}
+
+func (d *Dict[V]) Get(key string) (V, bool) {
+	if entry := d.find(key); entry != nil {
+		return entry.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+func (d *Dict[V]) find(key string) *dictEntry[V] {
+	if d.Len() == 0 {
+		return nil
+	}
+	d.rehashStep()
+
+	h := hashKey(key)
+	for t := 0; t <= 1; t++ {
+		table := d.tables[t]
+		if len(table) == 0 {
+			continue
+		}
+		bucket := table[h&uint64(len(table)-1)]
+		for i := range bucket {
+			if bucket[i].key == key {
+				return &bucket[i]
+			}
+		}
+		if !d.isRehashing() {
+			break
+		}
+	}
+	return nil
+}
+
+// Set stores value at key and reports whether the key was added, rather than updated
+func (d *Dict[V]) Set(key string, value V) bool {
+	if entry := d.find(key); entry != nil {
+		entry.value = value
+		return false
+	}
+
+	d.expandIfNeeded()
+
+	// While rehashing, new keys always go to the new table
+	t := 0
+	if d.isRehashing() {
+		t = 1
+	}
+	table := d.tables[t]
+	idx := hashKey(key) & uint64(len(table)-1)
+	table[idx] = append(table[idx], dictEntry[V]{key: key, value: value})
+	d.used[t]++
+	return true
+}
+
+// Delete removes key and reports whether it was present
+func (d *Dict[V]) Delete(key string) bool {
+	if d.Len() == 0 {
+		return false
+	}
+	d.rehashStep()
+
+	h := hashKey(key)
+	for t := 0; t <= 1; t++ {
+		table := d.tables[t]
+		if len(table) == 0 {
+			continue
+		}
+		idx := h & uint64(len(table)-1)
+		bucket := table[idx]
+		for i := range bucket {
+			if bucket[i].key == key {
+				last := len(bucket) - 1
+				bucket[i] = bucket[last]
+				bucket[last] = dictEntry[V]{}
+				table[idx] = bucket[:last]
+				d.used[t]--
+				d.shrinkIfNeeded()
+				return true
+			}
+		}
+		if !d.isRehashing() {
+			break
+		}
+	}
+	return false
+}
+
+func (d *Dict[V]) expandIfNeeded() {
+	if d.isRehashing() {
+		return
+	}
+	if len(d.tables[0]) == 0 {
+		d.tables[0] = make([][]dictEntry[V], dictMinSize)
+		return
+	}
+	if d.used[0] >= len(d.tables[0]) {
+		d.resize(d.used[0] + 1)
+	}
+}
+
+func (d *Dict[V]) shrinkIfNeeded() {
+	if d.isRehashing() || len(d.tables[0]) <= dictMinSize {
+		return
+	}
+	if d.used[0]*8 < len(d.tables[0]) {
+		d.resize(d.used[0])
+	}
+}
+
+// resize starts rehashing into a table large enough for size entries
+func (d *Dict[V]) resize(size int) {
+	newSize := dictMinSize
+	for newSize < size {
+		newSize *= 2
+	}
+	if newSize == len(d.tables[0]) {
+		return
+	}
+
+	d.tables[1] = make([][]dictEntry[V], newSize)
+	d.used[1] = 0
+	d.rehashIdx = 0
+}
+
+// rehashStep moves one bucket from the old table to the new one, visiting at most
+// a few empty buckets on the way
+func (d *Dict[V]) rehashStep() {
+	if !d.isRehashing() {
+		return
+	}
+
+	emptyVisits := dictRehashEmptyVisit
+	for d.rehashIdx < len(d.tables[0]) && len(d.tables[0][d.rehashIdx]) == 0 {
+		d.rehashIdx++
+		emptyVisits--
+		if emptyVisits == 0 {
+			return
+		}
+	}
+
+	if d.rehashIdx < len(d.tables[0]) {
+		newTable := d.tables[1]
+		for _, entry := range d.tables[0][d.rehashIdx] {
+			idx := hashKey(entry.key) & uint64(len(newTable)-1)
+			newTable[idx] = append(newTable[idx], entry)
+			d.used[0]--
+			d.used[1]++
+		}
+		d.tables[0][d.rehashIdx] = nil
+		d.rehashIdx++
+	}
+
+	if d.used[0] == 0 && d.rehashIdx >= len(d.tables[0]) {
+		d.tables[0], d.tables[1] = d.tables[1], nil
+		d.used[0], d.used[1] = d.used[1], 0
+		d.rehashIdx = -1
+	}
+}
+
+// Scan calls fn for the entries of the buckets at cursor and returns the cursor to
+// pass to the next call, which is 0 once the whole table has been visited. Starting
+// from cursor 0, every key present for the whole walk is visited at least once, even
+// if the table is resized between calls; keys may be visited more than once.
+// fn must not modify the dict.
+//
+// The cursor is advanced by incrementing its bits in reverse order. This visits the
+// buckets of a small table in an order where all the buckets that a bucket splits
+// into in a larger table (or that merge into it in a smaller one) are contiguous,
+// which is what makes the guarantee hold across resizes.
+func (d *Dict[V]) Scan(cursor uint64, fn func(key string, value V)) uint64 {
+	if d.Len() == 0 {
+		return 0
+	}
+
+	emit := func(bucket []dictEntry[V]) {
+		for _, entry := range bucket {
+			fn(entry.key, entry.value)
+		}
+	}
+
+	if !d.isRehashing() {
+		m0 := uint64(len(d.tables[0]) - 1)
+		emit(d.tables[0][cursor&m0])
+		return nextCursor(cursor, m0)
+	}
+
+	small, large := d.tables[0], d.tables[1]
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+	m0, m1 := uint64(len(small)-1), uint64(len(large)-1)
+
+	emit(small[cursor&m0])
+	// Visit the buckets of the larger table that the bucket of the smaller one expands to
+	for {
+		emit(large[cursor&m1])
+		cursor = nextCursor(cursor, m1)
+		if cursor&(m0^m1) == 0 {
+			break
+		}
+	}
+	return cursor
+}
+
+// nextCursor increments the bits of the cursor covered by mask in reverse order
+func nextCursor(cursor uint64, mask uint64) uint64 {
+	cursor |= ^mask
+	cursor = bits.Reverse64(cursor)
+	cursor++
+	return bits.Reverse64(cursor)
+}
+
+// Range calls fn for every entry until it returns false. fn must not modify the dict.
+func (d *Dict[V]) Range(fn func(key string, value V) bool) {
+	for t := 0; t <= 1; t++ {
+		for _, bucket := range d.tables[t] {
+			for _, entry := range bucket {
+				if !fn(entry.key, entry.value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Random returns a random entry. Like in Redis, entries in sparsely populated
+// buckets are somewhat more likely to be picked.
+func (d *Dict[V]) Random() (string, V, bool) {
+	if d.Len() == 0 {
+		var zero V
+		return "", zero, false
+	}
+	d.rehashStep()
+
+	for {
+		var bucket []dictEntry[V]
+		if d.isRehashing() {
+			// Buckets of the old table below rehashIdx are known to be empty
+			n := len(d.tables[0]) + len(d.tables[1]) - d.rehashIdx
+			idx := d.rehashIdx + rand.Intn(n)
+			if idx < len(d.tables[0]) {
+				bucket = d.tables[0][idx]
+			} else {
+				bucket = d.tables[1][idx-len(d.tables[0])]
+			}
+		} else {
+			bucket = d.tables[0][rand.Intn(len(d.tables[0]))]
+		}
+
+		if len(bucket) > 0 {
+			entry := bucket[rand.Intn(len(bucket))]
+			return entry.key, entry.value, true
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package types_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestDictSetGetDelete(t *testing.T) {
+	d := types.NewDict[int]()
+	for i := 0; i < 1000; i++ {
+		if !d.Set(strconv.Itoa(i), i) {
+			t.Fatalf("Expected key %d to be added", i)
+		}
+	}
+	if d.Set("7", 70) {
+		t.Fatalf("Expected key 7 to be updated")
+	}
+
+	for i := 0; i < 1000; i += 2 {
+		if !d.Delete(strconv.Itoa(i)) {
+			t.Fatalf("Expected key %d to be deleted", i)
+		}
+	}
+
+	if d.Len() != 500 {
+		t.Fatalf("Expected 500 keys, got %d", d.Len())
+	}
+	for i := 0; i < 1000; i++ {
+		value, ok := d.Get(strconv.Itoa(i))
+		expected := i
+		if i == 7 {
+			expected = 70
+		}
+		if ok != (i%2 == 1) || (ok && value != expected) {
+			t.Fatalf("Unexpected lookup of key %d: %v, %v", i, value, ok)
+		}
+	}
+}
+
+func TestDictScan(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		initial      int // Keys present for the whole scan
+		added        int // Keys added while scanning
+		deleted      int // Initial keys deleted while scanning, from the last one down
+	}{
+		{testCaseName: "Stable table", initial: 1000},
+		{testCaseName: "Table growing", initial: 100, added: 10000},
+		{testCaseName: "Table shrinking", initial: 10000, deleted: 9900},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			d := types.NewDict[struct{}]()
+			for i := 0; i < tc.initial; i++ {
+				d.Set("key:"+strconv.Itoa(i), struct{}{})
+			}
+
+			seen := map[string]bool{}
+			added, deleted := 0, 0
+			cursor := uint64(0)
+			for {
+				cursor = d.Scan(cursor, func(key string, _ struct{}) {
+					seen[key] = true
+				})
+				if cursor == 0 {
+					break
+				}
+
+				for i := 0; i < 50 && added < tc.added; i++ {
+					d.Set("new:"+strconv.Itoa(added), struct{}{})
+					added++
+				}
+				for i := 0; i < 50 && deleted < tc.deleted; i++ {
+					d.Delete("key:" + strconv.Itoa(tc.initial-1-deleted))
+					deleted++
+				}
+			}
+
+			for i := 0; i < tc.initial-tc.deleted; i++ {
+				if !seen["key:"+strconv.Itoa(i)] {
+					t.Fatalf("Key %d was never returned", i)
+				}
+			}
+		})
+	}
+}
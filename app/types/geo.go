@@ -0,0 +1,318 @@
+package types
+
+import (
+	"math"
+	"strings"
+)
+
+// Geospatial indexes are sorted sets whose scores are 52 bit geohashes of the
+// positions of their members, as in Redis. A geohash interleaves the bits of the
+// longitude, in the odd bits, and of the latitude, in the even bits, each locating
+// the position within its range with 26 bits. Positions close to each other tend to
+// have close geohashes, so an area can be searched with a few ranges of scores.
+const (
+	GeoLonMin = -180.0
+	GeoLonMax = 180.0
+	// Latitudes are limited to the range EPSG:3857 maps cover, as in Redis
+	GeoLatMin = -85.05112878
+	GeoLatMax = 85.05112878
+
+	geoStep       = 26 // Bits of each coordinate in a geohash
+	earthRadius   = 6372797.560856
+	mercatorMax   = 20037726.37
+	geoAlphabet   = "0123456789bcdefghjkmnpqrstuvwxyz"
+	geoHashLength = 11
+)
+
+// ValidGeoCoords reports whether a position can be stored in a geospatial index
+func ValidGeoCoords(lon, lat float64) bool {
+	return lon >= GeoLonMin && lon <= GeoLonMax && lat >= GeoLatMin && lat <= GeoLatMax
+}
+
+// geoHash is a geohash of step bits per coordinate, which locates an area rather
+// than a position unless step is geoStep
+type geoHash struct {
+	bits uint64
+	step uint
+}
+
+// interleave spreads the bits of x over the even bits and those of y over the odd bits
+func interleave(x, y uint32) uint64 {
+	spread := func(v uint64) uint64 {
+		v = (v | v<<16) & 0x0000ffff0000ffff
+		v = (v | v<<8) & 0x00ff00ff00ff00ff
+		v = (v | v<<4) & 0x0f0f0f0f0f0f0f0f
+		v = (v | v<<2) & 0x3333333333333333
+		v = (v | v<<1) & 0x5555555555555555
+		return v
+	}
+	return spread(uint64(x)) | spread(uint64(y))<<1
+}
+
+// deinterleave is the inverse of interleave
+func deinterleave(v uint64) (uint32, uint32) {
+	squash := func(v uint64) uint32 {
+		v &= 0x5555555555555555
+		v = (v | v>>1) & 0x3333333333333333
+		v = (v | v>>2) & 0x0f0f0f0f0f0f0f0f
+		v = (v | v>>4) & 0x00ff00ff00ff00ff
+		v = (v | v>>8) & 0x0000ffff0000ffff
+		v = (v | v>>16) & 0x00000000ffffffff
+		return uint32(v)
+	}
+	return squash(v), squash(v >> 1)
+}
+
+// encodeGeoHash returns the geohash of step bits per coordinate of the area holding
+// a position, within the given ranges of coordinates
+func encodeGeoHash(lon, lat float64, step uint, lonMin, lonMax, latMin, latMax float64) geoHash {
+	latOffset := (lat - latMin) / (latMax - latMin) * float64(uint64(1)<<step)
+	lonOffset := (lon - lonMin) / (lonMax - lonMin) * float64(uint64(1)<<step)
+	return geoHash{bits: interleave(uint32(latOffset), uint32(lonOffset)), step: step}
+}
+
+// geoArea is the area a geohash locates
+type geoArea struct {
+	lonMin, lonMax, latMin, latMax float64
+}
+
+func (h geoHash) area() geoArea {
+	latBits, lonBits := deinterleave(h.bits)
+	scale := float64(uint64(1) << h.step)
+	latRange, lonRange := GeoLatMax-GeoLatMin, GeoLonMax-GeoLonMin
+	return geoArea{
+		lonMin: GeoLonMin + float64(lonBits)/scale*lonRange,
+		lonMax: GeoLonMin + float64(lonBits+1)/scale*lonRange,
+		latMin: GeoLatMin + float64(latBits)/scale*latRange,
+		latMax: GeoLatMin + float64(latBits+1)/scale*latRange,
+	}
+}
+
+// GeoEncode returns the 52 bit geohash of a position, stored as its score
+func GeoEncode(lon, lat float64) uint64 {
+	return encodeGeoHash(lon, lat, geoStep, GeoLonMin, GeoLonMax, GeoLatMin, GeoLatMax).bits
+}
+
+// GeoDecode returns the position at the center of the area a 52 bit geohash locates
+func GeoDecode(hash uint64) (float64, float64) {
+	a := geoHash{bits: hash, step: geoStep}.area()
+	lon := min(max((a.lonMin+a.lonMax)/2, GeoLonMin), GeoLonMax)
+	lat := min(max((a.latMin+a.latMax)/2, GeoLatMin), GeoLatMax)
+	return lon, lat
+}
+
+// GeoHashString returns the standard 11 character geohash of the position a 52 bit
+// geohash score locates. Unlike scores, standard geohashes cover latitudes from -90
+// to 90, and have 55 bits, the last 3 of which are always 0 here.
+func GeoHashString(hash uint64) string {
+	lon, lat := GeoDecode(hash)
+	bits := encodeGeoHash(lon, lat, geoStep, -180, 180, -90, 90).bits
+	var sb strings.Builder
+	for i := 0; i < geoHashLength; i++ {
+		idx := 0
+		if i < geoHashLength-1 {
+			idx = int(bits>>(52-(i+1)*5)) & 0x1f
+		}
+		sb.WriteByte(geoAlphabet[idx])
+	}
+	return sb.String()
+}
+
+func degToRad(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func radToDeg(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+func geoLatDistance(lat1, lat2 float64) float64 {
+	return earthRadius * math.Abs(degToRad(lat2)-degToRad(lat1))
+}
+
+// GeoDistance returns the distance in meters between two positions, with the
+// haversine formula
+func GeoDistance(lon1, lat1, lon2, lat2 float64) float64 {
+	v := math.Sin((degToRad(lon2) - degToRad(lon1)) / 2)
+	if v == 0 {
+		return geoLatDistance(lat1, lat2)
+	}
+	lat1r, lat2r := degToRad(lat1), degToRad(lat2)
+	u := math.Sin((lat2r - lat1r) / 2)
+	a := u*u + math.Cos(lat1r)*math.Cos(lat2r)*v*v
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// GeoShape is the area GEOSEARCH looks into: a circle of Radius meters, or unless
+// Box is false, a box of Width by Height meters, centered on Lon, Lat
+type GeoShape struct {
+	Lon, Lat      float64
+	Radius        float64
+	Box           bool
+	Width, Height float64
+}
+
+// Contains returns the distance in meters from the center of the shape to a
+// position, and reports whether the position is within the shape
+func (s GeoShape) Contains(lon, lat float64) (float64, bool) {
+	if !s.Box {
+		d := GeoDistance(s.Lon, s.Lat, lon, lat)
+		return d, d <= s.Radius
+	}
+	// The latitude distance is the cheaper one to rule a position out with
+	if geoLatDistance(lat, s.Lat) > s.Height/2 {
+		return 0, false
+	}
+	if GeoDistance(lon, lat, s.Lon, lat) > s.Width/2 {
+		return 0, false
+	}
+	return GeoDistance(s.Lon, s.Lat, lon, lat), true
+}
+
+// boundingBox returns the area covering the shape
+func (s GeoShape) boundingBox() geoArea {
+	width, height := s.Radius, s.Radius
+	if s.Box {
+		width, height = s.Width/2, s.Height/2
+	}
+	latDelta := radToDeg(height / earthRadius)
+	lonDeltaTop := radToDeg(width / earthRadius / math.Cos(degToRad(s.Lat+latDelta)))
+	lonDeltaBottom := radToDeg(width / earthRadius / math.Cos(degToRad(s.Lat-latDelta)))
+
+	// The box is widest on the side closer to the equator
+	lonDelta := lonDeltaTop
+	if s.Lat < 0 {
+		lonDelta = lonDeltaBottom
+	}
+	return geoArea{
+		lonMin: s.Lon - lonDelta,
+		lonMax: s.Lon + lonDelta,
+		latMin: s.Lat - latDelta,
+		latMax: s.Lat + latDelta,
+	}
+}
+
+// geoStepsForRadius returns the largest step whose areas are about as large as a
+// radius, smaller towards the poles where areas are narrower
+func geoStepsForRadius(radius, lat float64) uint {
+	if radius == 0 {
+		return geoStep
+	}
+	step := 1
+	for radius < mercatorMax {
+		radius *= 2
+		step++
+	}
+	step -= 2
+	if lat > 66 || lat < -66 {
+		step--
+		if lat > 80 || lat < -80 {
+			step--
+		}
+	}
+	return uint(min(max(step, 1), geoStep))
+}
+
+// move returns the geohash of the neighboring area, dx areas east and dy areas north
+func (h geoHash) move(dx, dy int) geoHash {
+	shift := 64 - h.step*2
+	lon, lat := h.bits&0xaaaaaaaaaaaaaaaa, h.bits&0x5555555555555555
+	if dx != 0 {
+		zz := uint64(0x5555555555555555) >> shift
+		if dx > 0 {
+			lon += zz + 1
+		} else {
+			lon = (lon | zz) - (zz + 1)
+		}
+		lon &= 0xaaaaaaaaaaaaaaaa >> shift
+	}
+	if dy != 0 {
+		zz := uint64(0xaaaaaaaaaaaaaaaa) >> shift
+		if dy > 0 {
+			lat += zz + 1
+		} else {
+			lat = (lat | zz) - (zz + 1)
+		}
+		lat &= 0x5555555555555555 >> shift
+	}
+	return geoHash{bits: lon | lat, step: h.step}
+}
+
+// searchAreas returns the areas to look into for the positions within the shape:
+// the area holding its center and those around it, unless they lie outside the
+// bounding box of the shape. Areas are as large as possible while the nine of them
+// cover the shape, as in Redis.
+func (s GeoShape) searchAreas() []geoHash {
+	radius := s.Radius
+	if s.Box {
+		radius = math.Sqrt(s.Width*s.Width/4 + s.Height*s.Height/4)
+	}
+	bounds := s.boundingBox()
+	step := geoStepsForRadius(radius, s.Lat)
+
+	center := encodeGeoHash(s.Lon, s.Lat, step, GeoLonMin, GeoLonMax, GeoLatMin, GeoLatMax)
+	if step > 1 && (center.move(0, 1).area().latMax < bounds.latMax ||
+		center.move(0, -1).area().latMin > bounds.latMin ||
+		center.move(1, 0).area().lonMax < bounds.lonMax ||
+		center.move(-1, 0).area().lonMin > bounds.lonMin) {
+		step--
+		center = encodeGeoHash(s.Lon, s.Lat, step, GeoLonMin, GeoLonMax, GeoLatMin, GeoLatMax)
+	}
+
+	area := center.area()
+	areas := []geoHash{center}
+	for _, d := range [][2]int{{0, 1}, {0, -1}, {1, 0}, {-1, 0}, {1, 1}, {-1, 1}, {1, -1}, {-1, -1}} {
+		if step >= 2 && ((d[1] < 0 && area.latMin < bounds.latMin) ||
+			(d[1] > 0 && area.latMax > bounds.latMax) ||
+			(d[0] < 0 && area.lonMin < bounds.lonMin) ||
+			(d[0] > 0 && area.lonMax > bounds.lonMax)) {
+			continue
+		}
+		// Neighbors wrap around at low steps, and may be the same area
+		neighbor := center.move(d[0], d[1])
+		duplicate := false
+		for _, a := range areas {
+			duplicate = duplicate || a == neighbor
+		}
+		if !duplicate {
+			areas = append(areas, neighbor)
+		}
+	}
+	return areas
+}
+
+// GeoPoint is a member of a geospatial index found by GeoSearch
+type GeoPoint struct {
+	Member   string
+	Hash     uint64
+	Lon, Lat float64
+	Dist     float64 // Distance in meters from the center of the shape
+}
+
+// GeoSearch returns the members of a geospatial index within a shape, stopping
+// after limit of them unless limit is 0. They are in no particular order.
+func (z *ZSet) GeoSearch(shape GeoShape, limit int) []GeoPoint {
+	points := []GeoPoint{}
+	for _, area := range shape.searchAreas() {
+		// The geohashes within an area all start with the bits of the area
+		shift := 2 * (geoStep - area.step)
+		r := ScoreRange{
+			Min:          float64(area.bits << shift),
+			Max:          float64((area.bits + 1) << shift),
+			MaxExclusive: true,
+		}
+		for _, e := range z.RangeByScore(r, false, 0, -1) {
+			hash := uint64(e.Score)
+			lon, lat := GeoDecode(hash)
+			dist, ok := shape.Contains(lon, lat)
+			if !ok {
+				continue
+			}
+			points = append(points, GeoPoint{Member: e.Member, Hash: hash, Lon: lon, Lat: lat, Dist: dist})
+			if len(points) == limit {
+				return points
+			}
+		}
+	}
+	return points
+}
@@ -0,0 +1,83 @@
+package types_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// The expected values are those Redis gives for the examples of its documentation
+func TestGeoHash(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		lon, lat     float64
+		hash         uint64
+		geohash      string
+	}{
+		{testCaseName: "Palermo", lon: 13.361389, lat: 38.115556, hash: 3479099956230698, geohash: "sqc8b49rny0"},
+		{testCaseName: "Catania", lon: 15.087269, lat: 37.502669, hash: 3479447370796909, geohash: "sqdtr74hyu0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			hash := types.GeoEncode(tc.lon, tc.lat)
+			if hash != tc.hash {
+				t.Fatalf("Expected %d, got %d", tc.hash, hash)
+			}
+			if s := types.GeoHashString(hash); s != tc.geohash {
+				t.Fatalf("Expected %s, got %s", tc.geohash, s)
+			}
+			lon, lat := types.GeoDecode(hash)
+			if math.Abs(lon-tc.lon) > 1e-5 || math.Abs(lat-tc.lat) > 1e-5 {
+				t.Fatalf("Expected about %f,%f, got %f,%f", tc.lon, tc.lat, lon, lat)
+			}
+		})
+	}
+
+	lon1, lat1 := types.GeoDecode(tests[0].hash)
+	lon2, lat2 := types.GeoDecode(tests[1].hash)
+	if d := types.GeoDistance(lon1, lat1, lon2, lat2); math.Abs(d-166274.1516) > 1e-4 {
+		t.Fatalf("Expected 166274.1516, got %.4f", d)
+	}
+}
+
+func TestGeoSearch(t *testing.T) {
+	z := types.NewZSet()
+	z.Add("Palermo", float64(types.GeoEncode(13.361389, 38.115556)))
+	z.Add("Catania", float64(types.GeoEncode(15.087269, 37.502669)))
+	z.Add("edge1", float64(types.GeoEncode(12.758489, 38.788135)))
+	z.Add("edge2", float64(types.GeoEncode(17.241510, 38.788135)))
+
+	tests := []struct {
+		testCaseName string
+		shape        types.GeoShape
+		expected     []string
+	}{
+		{testCaseName: "Radius", shape: types.GeoShape{Lon: 15, Lat: 37, Radius: 200000}, expected: []string{"Catania", "Palermo"}},
+		{testCaseName: "Small radius", shape: types.GeoShape{Lon: 15, Lat: 37, Radius: 100000}, expected: []string{"Catania"}},
+		{testCaseName: "Box", shape: types.GeoShape{Lon: 15, Lat: 37, Box: true, Width: 400000, Height: 400000}, expected: []string{"Catania", "Palermo", "edge1", "edge2"}},
+		{testCaseName: "Nothing around", shape: types.GeoShape{Lon: -70, Lat: 40, Radius: 500000}, expected: []string{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			found := map[string]bool{}
+			for _, p := range z.GeoSearch(tc.shape, 0) {
+				found[p.Member] = true
+			}
+			if len(found) != len(tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, found)
+			}
+			for _, member := range tc.expected {
+				if !found[member] {
+					t.Fatalf("Expected %v, got %v", tc.expected, found)
+				}
+			}
+		})
+	}
+
+	if points := z.GeoSearch(types.GeoShape{Lon: 15, Lat: 37, Radius: 200000}, 1); len(points) != 1 {
+		t.Fatalf("Expected a single member, got %d", len(points))
+	}
+}
@@ -0,0 +1,266 @@
+package types
+
+import (
+	"math/rand"
+	"time"
+)
+
+// A hash stays in its compact encoding as long as it has at most hashMaxCompactEntries
+// fields and no field or value is longer than hashMaxCompactValue, like Redis'
+// hash-max-listpack-entries and hash-max-listpack-value.
+const (
+	hashMaxCompactEntries = 128
+	hashMaxCompactValue   = 64
+)
+
+type hashField struct {
+	value  []byte
+	expiry int64 // Unix time in milliseconds at which the field expires, -1 if it never does
+}
+
+type hashEntry struct {
+	field string
+	hashField
+}
+
+// Hash is the value stored in the keyspace for hash keys. Small hashes are stored
+// as a slice of fields that is searched linearly, which takes far less memory than
+// a hash table and is just as fast at that size. Once a hash grows past the limits
+// of the compact encoding, it is converted to a Dict for good.
+//
+// Fields may have an expiry. Expired fields are not hidden by Hash itself: the
+// caller removes them with DeleteExpired before accessing the hash.
+type Hash struct {
+	compact    []hashEntry      // Fields while the hash uses the compact encoding
+	dict       *Dict[hashField] // Fields once the hash was converted, nil before
+	nextExpiry int64            // No field expires before this time, -1 if no field has an expiry
+}
+
+func NewHash() *Hash {
+	return &Hash{nextExpiry: -1}
+}
+
+func (h *Hash) Type() string {
+	return "hash"
+}
+
+func (h *Hash) Copy() Object {
+	c := NewHash()
+	h.rangeFields(func(field string, f hashField) bool {
+		c.Set(field, append([]byte{}, f.value...))
+		if f.expiry != -1 {
+			c.SetExpiry(field, f.expiry)
+		}
+		return true
+	})
+	return c
+}
+
+// Encoding returns the name Redis gives the encoding in use: listpack while the
+// hash is compact, hashtable once it was converted
+func (h *Hash) Encoding() string {
+	if h.dict != nil {
+		return "hashtable"
+	}
+	return "listpack"
+}
+
+func (h *Hash) Len() int {
+	if h.dict != nil {
+		return h.dict.Len()
+	}
+	return len(h.compact)
+}
+
+func (h *Hash) Get(field string) ([]byte, bool) {
+	f, ok := h.getField(field)
+	return f.value, ok
+}
+
+func (h *Hash) getField(field string) (hashField, bool) {
+	if h.dict != nil {
+		return h.dict.Get(field)
+	}
+	if i := h.compactIndex(field); i != -1 {
+		return h.compact[i].hashField, true
+	}
+	return hashField{}, false
+}
+
+func (h *Hash) compactIndex(field string) int {
+	for i := range h.compact {
+		if h.compact[i].field == field {
+			return i
+		}
+	}
+	return -1
+}
+
+// Set stores value in field, removing any expiry the field had, and reports whether
+// the field was added rather than updated
+func (h *Hash) Set(field string, value []byte) bool {
+	return h.setField(field, hashField{value: value, expiry: -1})
+}
+
+func (h *Hash) setField(field string, f hashField) bool {
+	if h.dict == nil && (len(field) > hashMaxCompactValue || len(f.value) > hashMaxCompactValue) {
+		h.convert()
+	}
+
+	if h.dict != nil {
+		return h.dict.Set(field, f)
+	}
+
+	if i := h.compactIndex(field); i != -1 {
+		h.compact[i].hashField = f
+		return false
+	}
+	h.compact = append(h.compact, hashEntry{field: field, hashField: f})
+	if len(h.compact) > hashMaxCompactEntries {
+		h.convert()
+	}
+	return true
+}
+
+// convert switches the hash from the compact encoding to a Dict
+func (h *Hash) convert() {
+	h.dict = NewDict[hashField]()
+	for _, entry := range h.compact {
+		h.dict.Set(entry.field, entry.hashField)
+	}
+	h.compact = nil
+}
+
+// Delete removes field and reports whether it was present
+func (h *Hash) Delete(field string) bool {
+	if h.dict != nil {
+		return h.dict.Delete(field)
+	}
+
+	i := h.compactIndex(field)
+	if i == -1 {
+		return false
+	}
+	last := len(h.compact) - 1
+	h.compact[i] = h.compact[last]
+	h.compact[last] = hashEntry{}
+	h.compact = h.compact[:last]
+	return true
+}
+
+// Range calls fn for every field until it returns false. fn must not modify the hash.
+func (h *Hash) Range(fn func(field string, value []byte) bool) {
+	h.rangeFields(func(field string, f hashField) bool {
+		return fn(field, f.value)
+	})
+}
+
+func (h *Hash) rangeFields(fn func(field string, f hashField) bool) {
+	if h.dict != nil {
+		h.dict.Range(fn)
+		return
+	}
+	for _, entry := range h.compact {
+		if !fn(entry.field, entry.hashField) {
+			return
+		}
+	}
+}
+
+// Scan walks the hash with a cursor, like Dict.Scan. A compact hash is returned
+// whole in the first call.
+func (h *Hash) Scan(cursor uint64, fn func(field string, value []byte)) uint64 {
+	if h.dict != nil {
+		return h.dict.Scan(cursor, func(field string, f hashField) {
+			fn(field, f.value)
+		})
+	}
+	for _, entry := range h.compact {
+		fn(entry.field, entry.value)
+	}
+	return 0
+}
+
+// Random returns a random field of a non-empty hash
+func (h *Hash) Random() (string, []byte) {
+	if h.dict != nil {
+		field, f, _ := h.dict.Random()
+		return field, f.value
+	}
+	entry := h.compact[rand.Intn(len(h.compact))]
+	return entry.field, entry.value
+}
+
+// Expiry returns the Unix time in milliseconds at which field expires, or -1 if it
+// never does. It reports whether the field exists.
+func (h *Hash) Expiry(field string) (int64, bool) {
+	f, ok := h.getField(field)
+	if !ok {
+		return 0, false
+	}
+	return f.expiry, true
+}
+
+// SetExpiry sets the time at which an existing field expires, -1 to never expire
+func (h *Hash) SetExpiry(field string, expiry int64) {
+	f, ok := h.getField(field)
+	if !ok {
+		return
+	}
+	f.expiry = expiry
+	h.setField(field, f)
+
+	if expiry != -1 && (h.nextExpiry == -1 || expiry < h.nextExpiry) {
+		h.nextExpiry = expiry
+	}
+}
+
+// HasExpiringFields reports whether any field may have an expiry
+func (h *Hash) HasExpiringFields() bool {
+	return h.nextExpiry != -1
+}
+
+// DeleteExpired deletes the fields that have expired at the given Unix time in
+// milliseconds and returns their names. It only walks the fields when the earliest
+// expiry has been reached.
+func (h *Hash) DeleteExpired(now int64) []string {
+	if h.nextExpiry == -1 || now < h.nextExpiry {
+		return nil
+	}
+
+	expired := []string{}
+	next := int64(-1)
+	h.rangeFields(func(field string, f hashField) bool {
+		switch {
+		case f.expiry == -1:
+		case now >= f.expiry:
+			expired = append(expired, field)
+		case next == -1 || f.expiry < next:
+			next = f.expiry
+		}
+		return true
+	})
+
+	for _, field := range expired {
+		h.Delete(field)
+	}
+	h.nextExpiry = next
+	return expired
+}
+
+// ExpireHashFields deletes the expired fields of the hash stored at key, and the
+// key itself once no field remains. The deletions are propagated as HDEL. It
+// reports whether the key still exists. It must be called with DBMutex held.
+func (s *ServerState) ExpireHashFields(key string, hash *Hash) bool {
+	expired := hash.DeleteExpired(time.Now().UnixMilli())
+	if len(expired) == 0 {
+		return true
+	}
+
+	s.Propagate(append([]string{"HDEL", key}, expired...)...)
+	if hash.Len() == 0 {
+		s.DeleteItem(key)
+		return false
+	}
+	return true
+}
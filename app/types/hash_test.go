@@ -0,0 +1,77 @@
+package types_test
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestHashEncoding(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		fields       int
+		valueLen     int
+		expected     string
+	}{
+		{testCaseName: "Small hash", fields: 128, valueLen: 64, expected: "listpack"},
+		{testCaseName: "Too many fields", fields: 129, valueLen: 1, expected: "hashtable"},
+		{testCaseName: "Value too long", fields: 1, valueLen: 65, expected: "hashtable"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			h := types.NewHash()
+			value := []byte(strings.Repeat("v", tc.valueLen))
+			for i := 0; i < tc.fields; i++ {
+				h.Set(strconv.Itoa(i), value)
+			}
+
+			if h.Encoding() != tc.expected {
+				t.Fatalf("Expected encoding %s, got %s", tc.expected, h.Encoding())
+			}
+			if h.Len() != tc.fields {
+				t.Fatalf("Expected %d fields, got %d", tc.fields, h.Len())
+			}
+			for i := 0; i < tc.fields; i++ {
+				if res, ok := h.Get(strconv.Itoa(i)); !ok || string(res) != string(value) {
+					t.Fatalf("Unexpected value of field %d: %q", i, res)
+				}
+			}
+		})
+	}
+}
+
+func TestHashDeleteExpired(t *testing.T) {
+	h := types.NewHash()
+	for _, field := range []string{"a", "b", "c", "d"} {
+		h.Set(field, []byte("v"))
+	}
+	h.SetExpiry("a", 100)
+	h.SetExpiry("b", 200)
+	h.SetExpiry("c", 300)
+
+	if res := h.DeleteExpired(50); len(res) != 0 {
+		t.Fatalf("Expected no expired fields, got %v", res)
+	}
+
+	res := h.DeleteExpired(200)
+	sort.Strings(res)
+	if !reflect.DeepEqual(res, []string{"a", "b"}) {
+		t.Fatalf("Expected fields a and b to expire, got %v", res)
+	}
+
+	h.SetExpiry("c", -1)
+	if res := h.DeleteExpired(1000); len(res) != 0 {
+		t.Fatalf("Expected no expired fields after persisting, got %v", res)
+	}
+	if h.HasExpiringFields() {
+		t.Fatalf("Expected no expiring fields to be left")
+	}
+	if h.Len() != 2 {
+		t.Fatalf("Expected 2 fields, got %d", h.Len())
+	}
+}
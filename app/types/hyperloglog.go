@@ -0,0 +1,379 @@
+package types
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// HyperLogLogs are string values laid out exactly as in Redis, so that they can be
+// exchanged with it: a 16 byte header followed by 16384 registers of 6 bits. The
+// header holds the magic "HYLL", the encoding, 3 unused bytes and the cached
+// cardinality as a little endian integer, whose most significant bit is set when
+// the cache is stale.
+//
+// The dense encoding packs the registers, least significant bits first. The sparse
+// encoding run length encodes them with three opcodes, which is much smaller while
+// most registers are 0:
+//
+//	00xxxxxx           ZERO, xxxxxx+1 registers set to 0
+//	01xxxxxx yyyyyyyy  XZERO, xxxxxxyyyyyyyy+1 registers set to 0
+//	1vvvvvxx           VAL, xx+1 registers set to vvvvv+1
+const (
+	hllP         = 14 // Bits of the hash that select a register
+	hllQ         = 64 - hllP
+	hllRegisters = 1 << hllP
+	hllBits      = 6
+	hllMaxValue  = 1<<hllBits - 1
+	hllHeaderLen = 16
+	hllDenseLen  = hllHeaderLen + (hllRegisters*hllBits+7)/8
+
+	hllDense  = 0
+	hllSparse = 1
+
+	// hllSparseMaxLen is the largest a sparse HyperLogLog grows to before it is
+	// converted to the dense encoding, like Redis' hll-sparse-max-bytes
+	hllSparseMaxLen = 3000
+
+	hllSparseMaxValue = 32 // Largest register value the VAL opcode can hold
+	hllZeroMaxLen     = 64
+	hllXZeroMaxLen    = 16384
+	hllValMaxLen      = 4
+
+	hllAlphaInf = 0.721347520444481703680 // Constant of the estimator, for m → ∞
+	hllSeed     = 0xadc83b19
+)
+
+// NewHLL returns an empty HyperLogLog, in the sparse encoding
+func NewHLL() []byte {
+	b := hllHeader(hllSparse)
+	return appendSparseZeros(b, hllRegisters)
+}
+
+func hllHeader(encoding byte) []byte {
+	b := make([]byte, hllHeaderLen, hllHeaderLen+8)
+	copy(b, "HYLL")
+	b[4] = encoding
+	return b
+}
+
+// IsHLL reports whether b has a valid HyperLogLog header. Sparse registers are only
+// checked when they are decoded.
+func IsHLL(b []byte) bool {
+	if len(b) < hllHeaderLen || string(b[:4]) != "HYLL" {
+		return false
+	}
+	switch b[4] {
+	case hllDense:
+		return len(b) == hllDenseLen
+	case hllSparse:
+		return true
+	}
+	return false
+}
+
+// IsDenseHLL reports whether the HyperLogLog uses the dense encoding
+func IsDenseHLL(b []byte) bool {
+	return b[4] == hllDense
+}
+
+// murmurHash64A is the 64 bit MurmurHash2 by Austin Appleby, which Redis hashes the
+// elements of HyperLogLogs with
+func murmurHash64A(key []byte, seed uint64) uint64 {
+	const m = 0xc6a4a7935bd1e995
+	const r = 47
+
+	h := seed ^ uint64(len(key))*m
+	for len(key) >= 8 {
+		k := binary.LittleEndian.Uint64(key)
+		k *= m
+		k ^= k >> r
+		k *= m
+		h ^= k
+		h *= m
+		key = key[8:]
+	}
+	if len(key) > 0 {
+		for i := len(key) - 1; i >= 0; i-- {
+			h ^= uint64(key[i]) << (8 * i)
+		}
+		h *= m
+	}
+
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+	return h
+}
+
+// hllPatternLen returns the register an element maps to, and the length of the run
+// of zero bits in its hash that the register records the longest of, plus one
+func hllPatternLen(elem []byte) (int, uint8) {
+	hash := murmurHash64A(elem, hllSeed)
+	index := int(hash & (hllRegisters - 1))
+	hash >>= hllP
+	hash |= 1 << hllQ // Bounds the run, so that the count is at most Q+1
+	count := uint8(1)
+	for bit := uint64(1); hash&bit == 0; bit <<= 1 {
+		count++
+	}
+	return index, count
+}
+
+func denseRegister(b []byte, i int) uint8 {
+	regs := b[hllHeaderLen:]
+	byteIndex, shift := i*hllBits/8, uint(i*hllBits%8)
+	v := uint(regs[byteIndex]) >> shift
+	if byteIndex+1 < len(regs) {
+		v |= uint(regs[byteIndex+1]) << (8 - shift)
+	}
+	return uint8(v & hllMaxValue)
+}
+
+func setDenseRegister(b []byte, i int, v uint8) {
+	regs := b[hllHeaderLen:]
+	byteIndex, shift := i*hllBits/8, uint(i*hllBits%8)
+	regs[byteIndex] &^= hllMaxValue << shift
+	regs[byteIndex] |= v << shift
+	if byteIndex+1 < len(regs) {
+		regs[byteIndex+1] &^= hllMaxValue >> (8 - shift)
+		regs[byteIndex+1] |= v >> (8 - shift)
+	}
+}
+
+// decodeSparse calls fn for each run of registers holding the same value. It reports
+// false if the registers are corrupted.
+func decodeSparse(b []byte, fn func(start, n int, v uint8)) bool {
+	i := 0
+	for p := hllHeaderLen; p < len(b); p++ {
+		op := b[p]
+		var n int
+		var v uint8
+		switch {
+		case op&0xc0 == 0x00: // ZERO
+			n = int(op&0x3f) + 1
+		case op&0xc0 == 0x40: // XZERO
+			if p+1 == len(b) {
+				return false
+			}
+			n = (int(op&0x3f)<<8 | int(b[p+1])) + 1
+			p++
+		default: // VAL
+			n, v = int(op&0x03)+1, (op>>2)&0x1f+1
+		}
+		if i+n > hllRegisters {
+			return false
+		}
+		fn(i, n, v)
+		i += n
+	}
+	return i == hllRegisters
+}
+
+func appendSparseZeros(b []byte, n int) []byte {
+	for n > 0 {
+		if n > hllZeroMaxLen {
+			l := min(n, hllXZeroMaxLen)
+			b = append(b, 0x40|byte((l-1)>>8), byte(l-1))
+			n -= l
+		} else {
+			b = append(b, byte(n-1))
+			n = 0
+		}
+	}
+	return b
+}
+
+// registers returns the registers of a HyperLogLog, one per byte. It reports false
+// if they are corrupted.
+func registers(b []byte) ([]uint8, bool) {
+	regs := make([]uint8, hllRegisters)
+	if IsDenseHLL(b) {
+		for i := range regs {
+			regs[i] = denseRegister(b, i)
+		}
+		return regs, true
+	}
+	ok := decodeSparse(b, func(start, n int, v uint8) {
+		for i := start; i < start+n; i++ {
+			regs[i] = v
+		}
+	})
+	return regs, ok
+}
+
+// HLLFromRegisters returns a HyperLogLog holding the given registers, with a stale
+// cache. It uses the sparse encoding unless dense is set, or the registers do not fit it.
+func HLLFromRegisters(regs []uint8, dense bool) []byte {
+	if !dense {
+		if b, ok := encodeSparse(regs); ok {
+			return b
+		}
+	}
+	b := hllHeader(hllDense)
+	b = append(b, make([]byte, hllDenseLen-hllHeaderLen)...)
+	for i, v := range regs {
+		if v != 0 {
+			setDenseRegister(b, i, v)
+		}
+	}
+	invalidateHLLCache(b)
+	return b
+}
+
+// encodeSparse encodes the registers in the sparse encoding. It reports false if
+// a register holds a value VAL cannot hold, or the result would be too large.
+func encodeSparse(regs []uint8) ([]byte, bool) {
+	b := hllHeader(hllSparse)
+	for i := 0; i < len(regs); {
+		v, n := regs[i], 1
+		for i+n < len(regs) && regs[i+n] == v {
+			n++
+		}
+		i += n
+
+		if v == 0 {
+			b = appendSparseZeros(b, n)
+			continue
+		}
+		if v > hllSparseMaxValue {
+			return nil, false
+		}
+		for ; n > 0; n -= hllValMaxLen {
+			b = append(b, 0x80|(v-1)<<2|byte(min(n, hllValMaxLen)-1))
+		}
+		if len(b) > hllSparseMaxLen {
+			return nil, false
+		}
+	}
+	invalidateHLLCache(b)
+	return b, true
+}
+
+func invalidateHLLCache(b []byte) {
+	b[15] |= 0x80
+}
+
+// HLLAdd adds elements to a HyperLogLog and returns the updated value, which is b
+// itself if it is dense. It reports whether any register changed, and returns false
+// as well if the registers are corrupted.
+func HLLAdd(b []byte, elems [][]byte) ([]byte, bool, bool) {
+	if IsDenseHLL(b) {
+		changed := false
+		for _, elem := range elems {
+			i, count := hllPatternLen(elem)
+			if count > denseRegister(b, i) {
+				setDenseRegister(b, i, count)
+				changed = true
+			}
+		}
+		if changed {
+			invalidateHLLCache(b)
+		}
+		return b, changed, true
+	}
+
+	// Sparse registers are decoded and encoded again, which is cheap at the size
+	// they are kept to
+	regs, ok := registers(b)
+	if !ok {
+		return b, false, false
+	}
+	changed := false
+	for _, elem := range elems {
+		i, count := hllPatternLen(elem)
+		if count > regs[i] {
+			regs[i] = count
+			changed = true
+		}
+	}
+	if !changed {
+		return b, false, true
+	}
+	return HLLFromRegisters(regs, false), true, true
+}
+
+// HLLCount returns the estimated cardinality of a HyperLogLog, from its cache if it
+// is fresh, and otherwise updates the cache. It reports whether b was updated, and
+// returns false as well if the registers are corrupted.
+func HLLCount(b []byte) (uint64, bool, bool) {
+	cached := binary.LittleEndian.Uint64(b[8:hllHeaderLen])
+	if b[15]&0x80 == 0 {
+		return cached, false, true
+	}
+
+	regs, ok := registers(b)
+	if !ok {
+		return 0, false, false
+	}
+	n := HLLEstimate(regs)
+	binary.LittleEndian.PutUint64(b[8:hllHeaderLen], n)
+	return n, true, true
+}
+
+// HLLMerge sets each of regs to the largest of its value and the value of the same
+// register in the HyperLogLog b. It reports false if the registers of b are corrupted.
+func HLLMerge(regs []uint8, b []byte) bool {
+	other, ok := registers(b)
+	if !ok {
+		return false
+	}
+	for i, v := range other {
+		regs[i] = max(regs[i], v)
+	}
+	return true
+}
+
+// NewHLLRegisters returns registers for HLLMerge, all set to 0
+func NewHLLRegisters() []uint8 {
+	return make([]uint8, hllRegisters)
+}
+
+// HLLEstimate estimates the cardinality of a set from the registers of its
+// HyperLogLog, with the improved estimator by Otmar Ertl that Redis uses
+func HLLEstimate(regs []uint8) uint64 {
+	var histogram [64]int
+	for _, v := range regs {
+		histogram[v]++
+	}
+
+	m := float64(hllRegisters)
+	z := m * hllTau((m-float64(histogram[hllQ+1]))/m)
+	for j := hllQ; j >= 1; j-- {
+		z += float64(histogram[j])
+		z *= 0.5
+	}
+	z += m * hllSigma(float64(histogram[0])/m)
+	return uint64(math.Round(hllAlphaInf * m * m / z))
+}
+
+func hllSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y, z := 1.0, x
+	for {
+		x *= x
+		zPrime := z
+		z += x * y
+		y += y
+		if zPrime == z {
+			return z
+		}
+	}
+}
+
+func hllTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y, z := 1.0, 1-x
+	for {
+		x = math.Sqrt(x)
+		zPrime := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+		if zPrime == z {
+			return z / 3
+		}
+	}
+}
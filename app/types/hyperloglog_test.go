@@ -0,0 +1,82 @@
+package types_test
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// addHLL adds the elements "prefix0" to "prefix<n-1>" to a HyperLogLog
+func addHLL(t *testing.T, b []byte, prefix string, n int) []byte {
+	elems := make([][]byte, n)
+	for i := range elems {
+		elems[i] = []byte(prefix + strconv.Itoa(i))
+	}
+	b, _, ok := types.HLLAdd(b, elems)
+	if !ok {
+		t.Fatalf("Unexpected corrupted HyperLogLog")
+	}
+	return b
+}
+
+func TestHLLCount(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		n            int
+		dense        bool
+	}{
+		{testCaseName: "Empty", n: 0, dense: false},
+		{testCaseName: "Few elements", n: 10, dense: false},
+		{testCaseName: "Largest sparse", n: 700, dense: false},
+		{testCaseName: "Dense", n: 100000, dense: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			b := addHLL(t, types.NewHLL(), "elem", tc.n)
+			if !types.IsHLL(b) || types.IsDenseHLL(b) != tc.dense {
+				t.Fatalf("Expected a valid HyperLogLog, dense %t", tc.dense)
+			}
+
+			n, updated, ok := types.HLLCount(b)
+			if !ok || (tc.n > 0 && !updated) {
+				t.Fatalf("Expected the cache to be updated")
+			}
+			if diff := math.Abs(float64(n) - float64(tc.n)); diff > float64(tc.n)*0.02 {
+				t.Fatalf("Expected about %d, got %d", tc.n, n)
+			}
+			if cached, updated, _ := types.HLLCount(b); cached != n || updated {
+				t.Fatalf("Expected %d from the cache, got %d", n, cached)
+			}
+		})
+	}
+}
+
+func TestHLLMerge(t *testing.T) {
+	sparse := addHLL(t, types.NewHLL(), "a", 100)
+	dense := addHLL(t, types.NewHLL(), "b", 5000)
+
+	regs := types.NewHLLRegisters()
+	if !types.HLLMerge(regs, sparse) || !types.HLLMerge(regs, dense) {
+		t.Fatalf("Unexpected corrupted HyperLogLog")
+	}
+	if n := types.HLLEstimate(regs); math.Abs(float64(n)-5100) > 5100*0.02 {
+		t.Fatalf("Expected about 5100, got %d", n)
+	}
+
+	// Encoding the registers again loses nothing
+	for _, dense := range []bool{false, true} {
+		b := types.HLLFromRegisters(regs, dense)
+		other := types.NewHLLRegisters()
+		types.HLLMerge(other, b)
+		if string(other) != string(regs) {
+			t.Fatalf("Expected the same registers, dense %t", dense)
+		}
+	}
+
+	if _, _, ok := types.HLLAdd([]byte("HYLL\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x7f"), nil); ok {
+		t.Fatalf("Expected truncated sparse registers to be reported as corrupted")
+	}
+}
@@ -0,0 +1,292 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// JSON is the value stored in the keyspace for JSON documents. The document is kept
+// parsed, as a tree of nil, bool, int64, float64, string, *JSONArray and *JSONObject
+// values, so that updates modify the nodes they target in place rather than the
+// serialized document.
+type JSON struct {
+	root any
+}
+
+// JSONArray is an array of a JSON document
+type JSONArray struct {
+	Elems []any
+}
+
+// JSONObject is an object of a JSON document, which keeps its keys in the order
+// they were added, as RedisJSON does
+type JSONObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func NewJSONObject() *JSONObject {
+	return &JSONObject{values: map[string]any{}}
+}
+
+func (o *JSONObject) Len() int {
+	return len(o.keys)
+}
+
+// Keys returns the keys of the object, in order
+func (o *JSONObject) Keys() []string {
+	return o.keys
+}
+
+func (o *JSONObject) Get(key string) (any, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// Set sets the value of a key, which is added after the others if it is new
+func (o *JSONObject) Set(key string, v any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+// Delete deletes a key and reports whether it existed
+func (o *JSONObject) Delete(key string) bool {
+	if _, ok := o.values[key]; !ok {
+		return false
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func NewJSON(root any) *JSON {
+	return &JSON{root: root}
+}
+
+func (j *JSON) Type() string {
+	return "ReJSON-RL"
+}
+
+func (j *JSON) Copy() Object {
+	return &JSON{root: CopyJSONValue(j.root)}
+}
+
+func (j *JSON) Root() any {
+	return j.root
+}
+
+// CopyJSONValue returns a deep copy of a value of a JSON document
+func CopyJSONValue(v any) any {
+	switch v := v.(type) {
+	case *JSONArray:
+		c := &JSONArray{Elems: make([]any, len(v.Elems))}
+		for i, elem := range v.Elems {
+			c.Elems[i] = CopyJSONValue(elem)
+		}
+		return c
+	case *JSONObject:
+		c := NewJSONObject()
+		for _, key := range v.keys {
+			c.Set(key, CopyJSONValue(v.values[key]))
+		}
+		return c
+	}
+	return v
+}
+
+// JSONTypeName returns the name of the type of a value, as reported by JSON.TYPE
+func JSONTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int64:
+		return "integer"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *JSONArray:
+		return "array"
+	}
+	return "object"
+}
+
+// ParseJSON parses a JSON value. Numbers without a fraction or an exponent that fit
+// 64 bits are integers, as in RedisJSON, and other numbers are floats.
+func ParseJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := parseJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("trailing characters after the JSON value")
+	}
+	return v, nil
+}
+
+func parseJSONValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, errors.New("EOF while parsing a value")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '[':
+			arr := &JSONArray{Elems: []any{}}
+			for dec.More() {
+				elem, err := parseJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr.Elems = append(arr.Elems, elem)
+			}
+			_, err := dec.Token() // ]
+			return arr, err
+		case '{':
+			obj := NewJSONObject()
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := parseJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj.Set(keyTok.(string), value)
+			}
+			_, err := dec.Token() // }
+			return obj, err
+		}
+		return nil, errors.New("unexpected " + tok.String())
+	case json.Number:
+		return parseJSONNumber(tok.String())
+	}
+	return tok, nil // nil, bool or string
+}
+
+func parseJSONNumber(s string) (any, error) {
+	if !strings.ContainsAny(s, ".eE") {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, errors.New("number out of range")
+	}
+	return f, nil
+}
+
+// JSONFormat is how a JSON value is serialized: Indent is written once per level of
+// nesting at the start of each line, Newline after each element of arrays and
+// objects, and Space after the colon of each key. All empty is the compact form.
+type JSONFormat struct {
+	Indent, Newline, Space string
+}
+
+// MarshalJSON serializes a value compactly
+func MarshalJSON(v any) string {
+	return JSONFormat{}.Marshal(v)
+}
+
+func (f JSONFormat) Marshal(v any) string {
+	var sb strings.Builder
+	f.write(&sb, v, 0)
+	return sb.String()
+}
+
+func (f JSONFormat) newline(sb *strings.Builder, level int) {
+	sb.WriteString(f.Newline)
+	for i := 0; i < level; i++ {
+		sb.WriteString(f.Indent)
+	}
+}
+
+func (f JSONFormat) write(sb *strings.Builder, v any, level int) {
+	switch v := v.(type) {
+	case nil:
+		sb.WriteString("null")
+	case bool:
+		sb.WriteString(strconv.FormatBool(v))
+	case int64:
+		sb.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		sb.WriteString(FormatJSONFloat(v))
+	case string:
+		b, _ := json.Marshal(v)
+		sb.Write(b)
+	case *JSONArray:
+		if len(v.Elems) == 0 {
+			sb.WriteString("[]")
+			return
+		}
+		sb.WriteByte('[')
+		for i, elem := range v.Elems {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			f.newline(sb, level+1)
+			f.write(sb, elem, level+1)
+		}
+		f.newline(sb, level)
+		sb.WriteByte(']')
+	case *JSONObject:
+		if len(v.keys) == 0 {
+			sb.WriteString("{}")
+			return
+		}
+		sb.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			f.newline(sb, level+1)
+			f.write(sb, key, level+1)
+			sb.WriteByte(':')
+			sb.WriteString(f.Space)
+			f.write(sb, v.values[key], level+1)
+		}
+		f.newline(sb, level)
+		sb.WriteByte('}')
+	}
+}
+
+// FormatJSONFloat formats a float the way RedisJSON does, always with a fraction or
+// an exponent so that it reads back as a float
+func FormatJSONFloat(f float64) string {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "null" // Not representable in JSON
+	}
+	abs := math.Abs(f)
+	if abs != 0 && (abs < 1e-5 || abs >= 1e16) {
+		s := strconv.FormatFloat(f, 'e', -1, 64)
+		return strings.Replace(strings.Replace(s, "e+", "e", 1), "e-0", "e-", 1)
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		input        string
+		expected     string
+		invalid      bool
+	}{
+		{testCaseName: "Object order", input: `{"b": 1, "a": [true, null]}`, expected: `{"b":1,"a":[true,null]}`},
+		{testCaseName: "Floats", input: `[1.0, 2.5e3, 1e-7, 1e20]`, expected: `[1.0,2500.0,1e-7,1e20]`},
+		{testCaseName: "Large integer", input: `[9223372036854775807, 9223372036854775808]`, expected: `[9223372036854775807,9.223372036854776e18]`},
+		{testCaseName: "String escapes", input: `"a\"bé"`, expected: `"a\"bé"`},
+		{testCaseName: "Trailing data", input: `{} {}`, invalid: true},
+		{testCaseName: "Unterminated", input: `[1, 2`, invalid: true},
+		{testCaseName: "Empty", input: ``, invalid: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			v, err := types.ParseJSON([]byte(tc.input))
+			if tc.invalid {
+				if err == nil {
+					t.Fatalf("Expected an error, got %v", v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if s := types.MarshalJSON(v); s != tc.expected {
+				t.Fatalf("Expected %s, got %s", tc.expected, s)
+			}
+		})
+	}
+}
+
+func TestJSONQuery(t *testing.T) {
+	root, err := types.ParseJSON([]byte(`{"a": {"b": [1, 2, 3, {"b": "x"}]}, "c": [{"p": 5, "q": "s"}, {"p": 15}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := types.NewJSON(root)
+
+	tests := []struct {
+		testCaseName string
+		path         string
+		expected     string
+		paths        string
+	}{
+		{testCaseName: "Legacy root", path: ".", expected: types.MarshalJSON(root)},
+		{testCaseName: "Legacy child", path: "a.b[1]", expected: `2`, paths: `$["a"]["b"][1]`},
+		{testCaseName: "Negative index", path: "$.a.b[-1].b", expected: `["x"]`},
+		{testCaseName: "Slice", path: "$.a.b[1:3]", expected: `[2,3]`},
+		{testCaseName: "Slice with step", path: "$.a.b[::2]", expected: `[1,3]`},
+		{testCaseName: "Union", path: `$['c', "a"]`, expected: `[[{"p":5,"q":"s"},{"p":15}],{"b":[1,2,3,{"b":"x"}]}]`},
+		{testCaseName: "Recursive descent", path: "$..b", expected: `[[1,2,3,{"b":"x"}],"x"]`},
+		{testCaseName: "Wildcard", path: "$.c[*].p", expected: `[5,15]`},
+		{testCaseName: "Filter", path: "$.c[?(@.p > 10)]", expected: `[{"p":15}]`},
+		{testCaseName: "Filter existence", path: "$.c[?(@.q)].p", expected: `[5]`},
+		{testCaseName: "Filter logic", path: `$.c[?(@.p < 10 && @.q == "s" || !(@.p))].p`, expected: `[5]`},
+		{testCaseName: "Filter against root", path: `$.a.b[?(@ < $.c[0].p)]`, expected: `[1,2,3]`},
+		{testCaseName: "No match", path: "$.x.y", expected: `[]`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			path, err := types.ParseJSONPath(tc.path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			matches := doc.Query(path)
+			values := &types.JSONArray{Elems: []any{}}
+			for _, m := range matches {
+				values.Elems = append(values.Elems, m.Value)
+			}
+			got := types.MarshalJSON(values)
+			// Legacy paths stand for the first value they match
+			if path.Legacy() {
+				got = types.MarshalJSON(matches[0].Value)
+			}
+			if got != tc.expected {
+				t.Fatalf("Expected %s, got %s", tc.expected, got)
+			}
+			if tc.paths != "" && matches[0].Path != tc.paths {
+				t.Fatalf("Expected path %s, got %s", tc.paths, matches[0].Path)
+			}
+		})
+	}
+}
+
+func TestJSONUpdate(t *testing.T) {
+	root, _ := types.ParseJSON([]byte(`{"a": [1, 2, 3, 4], "o": {"x": 1}}`))
+	doc := types.NewJSON(root)
+	path := func(s string) *types.JSONPath {
+		p, err := types.ParseJSONPath(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	if !doc.Set(path("$.o.y"), "new", false, false) {
+		t.Fatalf("Expected a missing key to be added")
+	}
+	if doc.Set(path("$.o.x"), int64(2), true, false) {
+		t.Fatalf("Expected NX not to replace a value")
+	}
+	if doc.Set(path("$.o.z"), int64(2), false, true) {
+		t.Fatalf("Expected XX not to add a value")
+	}
+	if doc.Set(path("$.p.q"), int64(2), false, false) {
+		t.Fatalf("Expected nothing to be added under a missing key")
+	}
+	if n := doc.Delete(doc.Query(path("$.a[3,0,1]"))); n != 3 {
+		t.Fatalf("Expected 3 deleted, got %d", n)
+	}
+
+	expected := `{"a":[3],"o":{"x":1,"y":"new"}}`
+	if s := types.MarshalJSON(doc.Root()); s != expected {
+		t.Fatalf("Expected %s, got %s", expected, s)
+	}
+
+	// Copies do not share nodes with the original
+	c := doc.Copy().(*types.JSON)
+	c.Set(path("$.o.x"), int64(5), false, false)
+	if s := types.MarshalJSON(doc.Root()); s != expected {
+		t.Fatalf("Expected %s, got %s", expected, s)
+	}
+}
@@ -0,0 +1,740 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// JSONPath is a path into a JSON document. JSONPath expressions start with "$", and
+// support child names (.a, ['a']), wildcards (.*, [*]), indexes and unions ([0],
+// [-1], [0,2]), slices ([1:3], [::2]), recursive descent (..a) and filters
+// ([?(@.price < 10 && @.tags)]). Paths in the legacy syntax of RedisJSON v1, such as
+// "." or ".a[0]", are JSONPath expressions without the "$", but commands reply with
+// the first value they match rather than with all of them.
+type JSONPath struct {
+	legacy bool
+	steps  []jsonPathStep
+}
+
+type jsonPathStep struct {
+	recursive bool // Selects from the node and all its descendants, after ".."
+	wildcard  bool
+	names     []string
+	indices   []int
+	slice     *jsonSlice
+	filter    jsonExpr
+}
+
+type jsonSlice struct {
+	start, end *int
+	step       int
+}
+
+// JSONMatch is a value a path matched in a document, along with where it is
+type JSONMatch struct {
+	Value  any
+	Path   string // Path matching only this value, such as $["a"][0]
+	parent any    // *JSONArray or *JSONObject holding the value, nil for the root
+	key    string
+	index  int
+}
+
+// ParseJSONPath parses a path, in either the JSONPath or the legacy syntax
+func ParseJSONPath(s string) (*JSONPath, error) {
+	p := &JSONPath{}
+	switch {
+	case strings.HasPrefix(s, "$"):
+		s = s[1:]
+	case s == ".":
+		p.legacy, s = true, ""
+	case strings.HasPrefix(s, ".") || strings.HasPrefix(s, "["):
+		p.legacy = true
+	default:
+		p.legacy, s = true, "."+s
+	}
+
+	parser := &jsonPathParser{s: s}
+	steps, err := parser.steps()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos != len(s) {
+		return nil, errors.New("unexpected character at position " + strconv.Itoa(parser.pos+1))
+	}
+	p.steps = steps
+	return p, nil
+}
+
+// Legacy reports whether the path is in the legacy syntax
+func (p *JSONPath) Legacy() bool {
+	return p.legacy
+}
+
+// IsRoot reports whether the path is the root of documents
+func (p *JSONPath) IsRoot() bool {
+	return len(p.steps) == 0
+}
+
+// Query returns the values the path matches, in document order
+func (j *JSON) Query(p *JSONPath) []JSONMatch {
+	return queryJSON(j.root, p.steps)
+}
+
+func queryJSON(root any, steps []jsonPathStep) []JSONMatch {
+	matches := []JSONMatch{{Value: root, Path: "$"}}
+	for _, step := range steps {
+		next := []JSONMatch{}
+		for _, m := range matches {
+			if !step.recursive {
+				next = append(next, step.selectChildren(root, m)...)
+				continue
+			}
+			for _, d := range descendants(m) {
+				next = append(next, step.selectChildren(root, d)...)
+			}
+		}
+		matches = next
+	}
+	return matches
+}
+
+// children returns the elements of an array or the values of an object
+func children(m JSONMatch) []JSONMatch {
+	res := []JSONMatch{}
+	switch v := m.Value.(type) {
+	case *JSONArray:
+		for i, elem := range v.Elems {
+			res = append(res, JSONMatch{Value: elem, Path: m.Path + "[" + strconv.Itoa(i) + "]", parent: v, index: i})
+		}
+	case *JSONObject:
+		for _, key := range v.keys {
+			res = append(res, childMatch(m, v, key))
+		}
+	}
+	return res
+}
+
+func childMatch(m JSONMatch, obj *JSONObject, key string) JSONMatch {
+	quoted, _ := json.Marshal(key)
+	return JSONMatch{Value: obj.values[key], Path: m.Path + "[" + string(quoted) + "]", parent: obj, key: key}
+}
+
+// descendants returns a match and all the values nested in it, in document order
+func descendants(m JSONMatch) []JSONMatch {
+	res := []JSONMatch{m}
+	for _, c := range children(m) {
+		res = append(res, descendants(c)...)
+	}
+	return res
+}
+
+func (step jsonPathStep) selectChildren(root any, m JSONMatch) []JSONMatch {
+	switch {
+	case step.wildcard:
+		return children(m)
+	case step.filter != nil:
+		res := []JSONMatch{}
+		for _, c := range children(m) {
+			if step.filter.eval(root, c.Value) {
+				res = append(res, c)
+			}
+		}
+		return res
+	}
+
+	res := []JSONMatch{}
+	switch v := m.Value.(type) {
+	case *JSONObject:
+		for _, name := range step.names {
+			if _, ok := v.values[name]; ok {
+				res = append(res, childMatch(m, v, name))
+			}
+		}
+	case *JSONArray:
+		all := children(m)
+		for _, i := range step.indices {
+			if i < 0 {
+				i += len(all)
+			}
+			if i >= 0 && i < len(all) {
+				res = append(res, all[i])
+			}
+		}
+		if step.slice != nil {
+			for _, i := range step.slice.indices(len(all)) {
+				res = append(res, all[i])
+			}
+		}
+	}
+	return res
+}
+
+// indices returns the indexes the slice selects in an array of length n
+func (s *jsonSlice) indices(n int) []int {
+	bound := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += n
+		}
+		return min(max(i, 0), n)
+	}
+	res := []int{}
+	if s.step > 0 {
+		for i := bound(s.start, 0); i < bound(s.end, n); i += s.step {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// Set sets the values the path matches to v, or if there are none and the path ends
+// with a child name, adds that key to the objects the rest of the path matches. NX
+// only adds keys, and XX only sets values. It reports whether anything was set.
+func (j *JSON) Set(p *JSONPath, v any, nx, xx bool) bool {
+	matches := j.Query(p)
+	if len(matches) > 0 {
+		if nx {
+			return false
+		}
+		for i, m := range matches {
+			if i > 0 {
+				v = CopyJSONValue(v) // Every match gets its own copy
+			}
+			j.Replace(m, v)
+			if p.legacy {
+				break
+			}
+		}
+		return true
+	}
+
+	last := len(p.steps) - 1
+	if xx || last < 0 || !p.steps[last].addsKey() {
+		return false
+	}
+	set := false
+	for _, m := range queryJSON(j.root, p.steps[:last]) {
+		if obj, ok := m.Value.(*JSONObject); ok {
+			if set {
+				v = CopyJSONValue(v)
+			}
+			obj.Set(p.steps[last].names[0], v)
+			set = true
+		}
+	}
+	return set
+}
+
+// addsKey reports whether a step names a single key, which Set may add
+func (step jsonPathStep) addsKey() bool {
+	return !step.recursive && len(step.names) == 1 && len(step.indices) == 0 && step.slice == nil
+}
+
+// Replace sets the value of a match
+func (j *JSON) Replace(m JSONMatch, v any) {
+	switch parent := m.parent.(type) {
+	case nil:
+		j.root = v
+	case *JSONArray:
+		parent.Elems[m.index] = v
+	case *JSONObject:
+		parent.values[m.key] = v
+	}
+}
+
+// jsonDeleted marks the elements of arrays that Delete removes
+type jsonDeleted struct{}
+
+// Delete removes the values matched from the document and returns how many there
+// were. Deleting the root leaves the document empty, as nil.
+func (j *JSON) Delete(matches []JSONMatch) int {
+	// Elements are marked first and removed at the end, so that the indexes of the
+	// other matches hold whatever order they are in
+	deleted := 0
+	arrays := []*JSONArray{}
+	for _, m := range matches {
+		switch parent := m.parent.(type) {
+		case nil:
+			j.root = nil
+			deleted++
+		case *JSONArray:
+			if _, ok := parent.Elems[m.index].(jsonDeleted); !ok {
+				parent.Elems[m.index] = jsonDeleted{}
+				arrays = append(arrays, parent)
+				deleted++
+			}
+		case *JSONObject:
+			if parent.Delete(m.key) {
+				deleted++
+			}
+		}
+	}
+
+	for _, arr := range arrays {
+		elems := arr.Elems[:0]
+		for _, elem := range arr.Elems {
+			if _, ok := elem.(jsonDeleted); !ok {
+				elems = append(elems, elem)
+			}
+		}
+		arr.Elems = elems
+	}
+	return deleted
+}
+
+// jsonPathParser parses the steps of a path, after the "$"
+type jsonPathParser struct {
+	s   string
+	pos int
+}
+
+func (p *jsonPathParser) errorf(msg string) error {
+	return errors.New(msg + " at position " + strconv.Itoa(p.pos+1))
+}
+
+func (p *jsonPathParser) peek(prefix string) bool {
+	return strings.HasPrefix(p.s[p.pos:], prefix)
+}
+
+// steps parses steps until the end of the path, or anything that cannot continue
+// one, such as an operator in a filter
+func (p *jsonPathParser) steps() ([]jsonPathStep, error) {
+	steps := []jsonPathStep{}
+	for p.pos < len(p.s) {
+		var step jsonPathStep
+		var err error
+		switch {
+		case p.peek(".."):
+			p.pos += 2
+			if p.peek("[") {
+				step, err = p.bracket()
+			} else {
+				step, err = p.dotted()
+			}
+			step.recursive = true
+		case p.peek("."):
+			p.pos++
+			step, err = p.dotted()
+		case p.peek("["):
+			step, err = p.bracket()
+		default:
+			return steps, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func isJSONPathNameChar(r rune) bool {
+	return r == '_' || r == '-' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r) || r > unicode.MaxASCII
+}
+
+// dotted parses a name or a wildcard after a dot
+func (p *jsonPathParser) dotted() (jsonPathStep, error) {
+	if p.peek("*") {
+		p.pos++
+		return jsonPathStep{wildcard: true}, nil
+	}
+	start := p.pos
+	for _, r := range p.s[p.pos:] {
+		if !isJSONPathNameChar(r) {
+			break
+		}
+		p.pos += len(string(r))
+	}
+	if p.pos == start {
+		return jsonPathStep{}, p.errorf("expected a name")
+	}
+	return jsonPathStep{names: []string{p.s[start:p.pos]}}, nil
+}
+
+func (p *jsonPathParser) skipSpaces() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// bracket parses a bracketed selector
+func (p *jsonPathParser) bracket() (jsonPathStep, error) {
+	p.pos++ // [
+	p.skipSpaces()
+	var step jsonPathStep
+	switch {
+	case p.peek("*"):
+		p.pos++
+		step.wildcard = true
+	case p.peek("?"):
+		p.pos++
+		p.skipSpaces()
+		parens := p.peek("(")
+		if parens {
+			p.pos++
+		}
+		expr, err := p.orExpr()
+		if err != nil {
+			return step, err
+		}
+		p.skipSpaces()
+		if parens {
+			if !p.peek(")") {
+				return step, p.errorf("expected ')'")
+			}
+			p.pos++
+		}
+		step.filter = expr
+	default:
+		for {
+			p.skipSpaces()
+			switch {
+			case p.peek("'") || p.peek("\""):
+				name, err := p.quoted()
+				if err != nil {
+					return step, err
+				}
+				step.names = append(step.names, name)
+			default:
+				if err := p.indexOrSlice(&step); err != nil {
+					return step, err
+				}
+			}
+			p.skipSpaces()
+			if !p.peek(",") {
+				break
+			}
+			p.pos++
+		}
+	}
+
+	p.skipSpaces()
+	if !p.peek("]") {
+		return step, p.errorf("expected ']'")
+	}
+	p.pos++
+	return step, nil
+}
+
+// quoted parses a string in single or double quotes
+func (p *jsonPathParser) quoted() (string, error) {
+	quote := p.s[p.pos]
+	var sb strings.Builder
+	for i := p.pos + 1; i < len(p.s); i++ {
+		switch c := p.s[i]; {
+		case c == '\\' && i+1 < len(p.s):
+			i++
+			sb.WriteByte(p.s[i])
+		case c == quote:
+			p.pos = i + 1
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *jsonPathParser) integer() (*int, error) {
+	start := p.pos
+	if p.peek("-") {
+		p.pos++
+	}
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(p.s[start:p.pos])
+	if err != nil {
+		return nil, p.errorf("invalid index")
+	}
+	return &n, nil
+}
+
+// indexOrSlice parses an index, or a slice of the form start:end:step
+func (p *jsonPathParser) indexOrSlice(step *jsonPathStep) error {
+	start, err := p.integer()
+	if err != nil {
+		return err
+	}
+	if !p.peek(":") {
+		if start == nil {
+			return p.errorf("expected an index")
+		}
+		step.indices = append(step.indices, *start)
+		return nil
+	}
+
+	slice := &jsonSlice{start: start, step: 1}
+	p.pos++
+	if slice.end, err = p.integer(); err != nil {
+		return err
+	}
+	if p.peek(":") {
+		p.pos++
+		n, err := p.integer()
+		if err != nil {
+			return err
+		}
+		if n != nil {
+			slice.step = *n
+		}
+	}
+	step.slice = slice
+	return nil
+}
+
+// jsonExpr is a filter expression, evaluated for each candidate value
+type jsonExpr interface {
+	eval(root, current any) bool
+}
+
+type jsonOr struct{ left, right jsonExpr }
+type jsonAnd struct{ left, right jsonExpr }
+type jsonNot struct{ expr jsonExpr }
+
+// jsonExists holds if a path matches anything
+type jsonExists struct{ operand jsonOperand }
+
+type jsonCompare struct {
+	op          string
+	left, right jsonOperand
+}
+
+func (e jsonOr) eval(root, current any) bool {
+	return e.left.eval(root, current) || e.right.eval(root, current)
+}
+
+func (e jsonAnd) eval(root, current any) bool {
+	return e.left.eval(root, current) && e.right.eval(root, current)
+}
+
+func (e jsonNot) eval(root, current any) bool {
+	return !e.expr.eval(root, current)
+}
+
+func (e jsonExists) eval(root, current any) bool {
+	_, ok := e.operand.value(root, current)
+	return ok
+}
+
+// jsonOperand is a literal, or a path relative to the candidate (@) or the root ($)
+type jsonOperand struct {
+	literal  any
+	relative bool
+	steps    []jsonPathStep // nil for literals
+}
+
+func (o jsonOperand) value(root, current any) (any, bool) {
+	if o.steps == nil {
+		return o.literal, true
+	}
+	start := root
+	if o.relative {
+		start = current
+	}
+	matches := queryJSON(start, o.steps)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	return matches[0].Value, true
+}
+
+func (e jsonCompare) eval(root, current any) bool {
+	left, ok1 := e.left.value(root, current)
+	right, ok2 := e.right.value(root, current)
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	if e.op == "=~" {
+		s, ok1 := left.(string)
+		pattern, ok2 := right.(string)
+		if !ok1 || !ok2 {
+			return false
+		}
+		re, err := regexp.Compile(pattern)
+		return err == nil && re.MatchString(s)
+	}
+
+	c, comparable := compareJSON(left, right)
+	switch e.op {
+	case "==":
+		return comparable && c == 0
+	case "!=":
+		return !comparable || c != 0
+	case "<":
+		return comparable && c < 0
+	case "<=":
+		return comparable && c <= 0
+	case ">":
+		return comparable && c > 0
+	case ">=":
+		return comparable && c >= 0
+	}
+	return false
+}
+
+func jsonNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// compareJSON compares numbers and strings by value, and other values by equality
+// only. It reports false if the values cannot be compared.
+func compareJSON(a, b any) (int, bool) {
+	if x, ok := jsonNumber(a); ok {
+		y, ok := jsonNumber(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	if x, ok := a.(string); ok {
+		y, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	}
+	if JSONTypeName(a) != JSONTypeName(b) {
+		return 0, false
+	}
+	if MarshalJSON(a) == MarshalJSON(b) {
+		return 0, true
+	}
+	return 1, true
+}
+
+func (p *jsonPathParser) orExpr() (jsonExpr, error) {
+	left, err := p.andExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpaces(); p.peek("||"); p.skipSpaces() {
+		p.pos += 2
+		right, err := p.andExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = jsonOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *jsonPathParser) andExpr() (jsonExpr, error) {
+	left, err := p.unaryExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpaces(); p.peek("&&"); p.skipSpaces() {
+		p.pos += 2
+		right, err := p.unaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		left = jsonAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *jsonPathParser) unaryExpr() (jsonExpr, error) {
+	p.skipSpaces()
+	switch {
+	case p.peek("!") && !p.peek("!="):
+		p.pos++
+		expr, err := p.unaryExpr()
+		return jsonNot{expr}, err
+	case p.peek("("):
+		p.pos++
+		expr, err := p.orExpr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if !p.peek(")") {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return expr, nil
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "=~", "<", ">"} {
+		if p.peek(op) {
+			p.pos += len(op)
+			right, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return jsonCompare{op: op, left: left, right: right}, nil
+		}
+	}
+	if left.steps == nil {
+		return nil, p.errorf("expected a comparison")
+	}
+	return jsonExists{left}, nil
+}
+
+func (p *jsonPathParser) operand() (jsonOperand, error) {
+	p.skipSpaces()
+	switch {
+	case p.peek("@") || p.peek("$"):
+		relative := p.peek("@")
+		p.pos++
+		steps, err := p.steps()
+		if err != nil {
+			return jsonOperand{}, err
+		}
+		return jsonOperand{relative: relative, steps: steps}, nil
+	case p.peek("'") || p.peek("\""):
+		s, err := p.quoted()
+		return jsonOperand{literal: s}, err
+	case p.peek("true"):
+		p.pos += 4
+		return jsonOperand{literal: true}, nil
+	case p.peek("false"):
+		p.pos += 5
+		return jsonOperand{literal: false}, nil
+	case p.peek("null"):
+		p.pos += 4
+		return jsonOperand{literal: nil}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-0123456789.eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	if p.pos == start {
+		return jsonOperand{}, p.errorf("expected an operand")
+	}
+	n, err := parseJSONNumber(p.s[start:p.pos])
+	if err != nil {
+		return jsonOperand{}, p.errorf("invalid number")
+	}
+	return jsonOperand{literal: n}, nil
+}
@@ -0,0 +1,332 @@
+package types
+
+import "bytes"
+
+// listNodeMaxSize is the most elements a single node of a list holds
+const listNodeMaxSize = 128
+
+type listNode struct {
+	elems      [][]byte
+	prev, next *listNode
+}
+
+// List is the value stored in the keyspace for list keys. Like the quicklist in
+// Redis, it is a doubly linked list of small chunks of elements: pushing and popping
+// at either end is cheap, and reaching an index only walks over chunks rather than
+// over every element.
+type List struct {
+	head, tail *listNode
+	length     int
+}
+
+func NewList() *List {
+	return &List{}
+}
+
+func (l *List) Type() string {
+	return "list"
+}
+
+func (l *List) Copy() Object {
+	c := NewList()
+	l.Iterate(false, func(_ int, elem []byte) bool {
+		c.PushBack(append([]byte{}, elem...))
+		return true
+	})
+	return c
+}
+
+func (l *List) Len() int {
+	return l.length
+}
+
+func (l *List) PushFront(elem []byte) {
+	if l.head == nil || len(l.head.elems) >= listNodeMaxSize {
+		l.insertNodeAfter(nil, &listNode{})
+	}
+	l.head.elems = append([][]byte{elem}, l.head.elems...)
+	l.length++
+}
+
+func (l *List) PushBack(elem []byte) {
+	if l.tail == nil || len(l.tail.elems) >= listNodeMaxSize {
+		l.insertNodeAfter(l.tail, &listNode{})
+	}
+	l.tail.elems = append(l.tail.elems, elem)
+	l.length++
+}
+
+func (l *List) PopFront() ([]byte, bool) {
+	if l.length == 0 {
+		return nil, false
+	}
+	node := l.head
+	elem := node.elems[0]
+	node.elems[0] = nil
+	node.elems = node.elems[1:]
+	l.elementRemoved(node)
+	return elem, true
+}
+
+func (l *List) PopBack() ([]byte, bool) {
+	if l.length == 0 {
+		return nil, false
+	}
+	node := l.tail
+	last := len(node.elems) - 1
+	elem := node.elems[last]
+	node.elems[last] = nil
+	node.elems = node.elems[:last]
+	l.elementRemoved(node)
+	return elem, true
+}
+
+// Index returns the element at index i, where negative indexes count from the end
+func (l *List) Index(i int) ([]byte, bool) {
+	node, offset, ok := l.locate(i)
+	if !ok {
+		return nil, false
+	}
+	return node.elems[offset], true
+}
+
+// Set replaces the element at index i, where negative indexes count from the end.
+// It reports whether the index was in range.
+func (l *List) Set(i int, elem []byte) bool {
+	node, offset, ok := l.locate(i)
+	if !ok {
+		return false
+	}
+	node.elems[offset] = elem
+	return true
+}
+
+// Range returns the elements from start to end, both inclusive, which must be
+// valid indexes
+func (l *List) Range(start, end int) [][]byte {
+	elems := make([][]byte, 0, end-start+1)
+	node, offset, _ := l.locate(start)
+	for node != nil && len(elems) < cap(elems) {
+		n := min(len(node.elems)-offset, cap(elems)-len(elems))
+		elems = append(elems, node.elems[offset:offset+n]...)
+		node, offset = node.next, 0
+	}
+	return elems
+}
+
+// Iterate calls fn for every element with its index, from the head or from the
+// tail, until fn returns false. fn must not modify the list.
+func (l *List) Iterate(reverse bool, fn func(i int, elem []byte) bool) {
+	if !reverse {
+		i := 0
+		for node := l.head; node != nil; node = node.next {
+			for _, elem := range node.elems {
+				if !fn(i, elem) {
+					return
+				}
+				i++
+			}
+		}
+		return
+	}
+
+	i := l.length - 1
+	for node := l.tail; node != nil; node = node.prev {
+		for j := len(node.elems) - 1; j >= 0; j-- {
+			if !fn(i, node.elems[j]) {
+				return
+			}
+			i--
+		}
+	}
+}
+
+// Trim keeps only the elements from start to end, both inclusive, which must be
+// valid indexes
+func (l *List) Trim(start, end int) {
+	dropFront, dropBack := start, l.length-1-end
+
+	for dropFront > 0 {
+		node := l.head
+		if len(node.elems) <= dropFront {
+			dropFront -= len(node.elems)
+			l.length -= len(node.elems)
+			l.unlinkNode(node)
+			continue
+		}
+		clear(node.elems[:dropFront])
+		node.elems = node.elems[dropFront:]
+		l.length -= dropFront
+		dropFront = 0
+	}
+
+	for dropBack > 0 {
+		node := l.tail
+		if len(node.elems) <= dropBack {
+			dropBack -= len(node.elems)
+			l.length -= len(node.elems)
+			l.unlinkNode(node)
+			continue
+		}
+		keep := len(node.elems) - dropBack
+		clear(node.elems[keep:])
+		node.elems = node.elems[:keep]
+		l.length -= dropBack
+		dropBack = 0
+	}
+}
+
+// Remove removes the first count occurrences of elem, scanning from the tail if
+// count is negative, or all of them if count is 0. It returns how many were removed.
+func (l *List) Remove(count int, elem []byte) int {
+	reverse := count < 0
+	limit := count
+	if reverse {
+		limit = -count
+	}
+
+	removed := 0
+	node := l.head
+	if reverse {
+		node = l.tail
+	}
+	for node != nil && (limit == 0 || removed < limit) {
+		next := node.next
+		if reverse {
+			next = node.prev
+		}
+
+		drop := make([]bool, len(node.elems))
+		for k := range node.elems {
+			j := k
+			if reverse {
+				j = len(node.elems) - 1 - k
+			}
+			if limit != 0 && removed == limit {
+				break
+			}
+			if bytes.Equal(node.elems[j], elem) {
+				drop[j] = true
+				removed++
+			}
+		}
+
+		kept := node.elems[:0]
+		for j, e := range node.elems {
+			if !drop[j] {
+				kept = append(kept, e)
+			}
+		}
+		clear(node.elems[len(kept):])
+		l.length -= len(node.elems) - len(kept)
+		node.elems = kept
+		if len(node.elems) == 0 {
+			l.unlinkNode(node)
+		}
+
+		node = next
+	}
+	return removed
+}
+
+// Insert inserts elem before or after the first occurrence of pivot. It reports
+// whether pivot was found.
+func (l *List) Insert(pivot, elem []byte, before bool) bool {
+	for node := l.head; node != nil; node = node.next {
+		for j, e := range node.elems {
+			if !bytes.Equal(e, pivot) {
+				continue
+			}
+			if !before {
+				j++
+			}
+			l.insertAt(node, j, elem)
+			return true
+		}
+	}
+	return false
+}
+
+// insertAt inserts elem at offset j of node, splitting the node if it is full
+func (l *List) insertAt(node *listNode, j int, elem []byte) {
+	if len(node.elems) >= listNodeMaxSize {
+		half := len(node.elems) / 2
+		split := &listNode{elems: append([][]byte{}, node.elems[half:]...)}
+		clear(node.elems[half:])
+		node.elems = node.elems[:half]
+		l.insertNodeAfter(node, split)
+		if j > half {
+			node, j = split, j-half
+		}
+	}
+
+	node.elems = append(node.elems, nil)
+	copy(node.elems[j+1:], node.elems[j:])
+	node.elems[j] = elem
+	l.length++
+}
+
+// locate returns the node holding index i and the offset of the element within it
+func (l *List) locate(i int) (*listNode, int, bool) {
+	if i < 0 {
+		i += l.length
+	}
+	if i < 0 || i >= l.length {
+		return nil, 0, false
+	}
+
+	// Walk from whichever end is closer
+	if i < l.length/2 {
+		for node := l.head; ; node = node.next {
+			if i < len(node.elems) {
+				return node, i, true
+			}
+			i -= len(node.elems)
+		}
+	}
+	i = l.length - 1 - i
+	for node := l.tail; ; node = node.prev {
+		if i < len(node.elems) {
+			return node, len(node.elems) - 1 - i, true
+		}
+		i -= len(node.elems)
+	}
+}
+
+func (l *List) elementRemoved(node *listNode) {
+	l.length--
+	if len(node.elems) == 0 {
+		l.unlinkNode(node)
+	}
+}
+
+// insertNodeAfter links node after prev, or at the head if prev is nil
+func (l *List) insertNodeAfter(prev, node *listNode) {
+	node.prev = prev
+	if prev == nil {
+		node.next = l.head
+		l.head = node
+	} else {
+		node.next = prev.next
+		prev.next = node
+	}
+	if node.next == nil {
+		l.tail = node
+	} else {
+		node.next.prev = node
+	}
+}
+
+func (l *List) unlinkNode(node *listNode) {
+	if node.prev == nil {
+		l.head = node.next
+	} else {
+		node.prev.next = node.next
+	}
+	if node.next == nil {
+		l.tail = node.prev
+	} else {
+		node.next.prev = node.prev
+	}
+	node.prev, node.next = nil, nil
+}
@@ -0,0 +1,125 @@
+package types_test
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// listContents returns the elements of a list as strings
+func listContents(l *types.List) []string {
+	elems := []string{}
+	l.Iterate(false, func(_ int, elem []byte) bool {
+		elems = append(elems, string(elem))
+		return true
+	})
+	return elems
+}
+
+func TestList(t *testing.T) {
+	// Every list starts as 0..n-1, large enough to span several nodes
+	const n = 1000
+
+	tests := []struct {
+		testCaseName string
+		op           func(l *types.List)
+		expected     func(elems []string) []string
+	}{
+		{
+			testCaseName: "Trim to a range inside one node",
+			op:           func(l *types.List) { l.Trim(300, 310) },
+			expected:     func(elems []string) []string { return elems[300:311] },
+		},
+		{
+			testCaseName: "Trim across nodes",
+			op:           func(l *types.List) { l.Trim(100, 899) },
+			expected:     func(elems []string) []string { return elems[100:900] },
+		},
+		{
+			testCaseName: "Pop from both ends",
+			op: func(l *types.List) {
+				for i := 0; i < 200; i++ {
+					l.PopFront()
+					l.PopBack()
+				}
+			},
+			expected: func(elems []string) []string { return elems[200:800] },
+		},
+		{
+			testCaseName: "Insert into a full node",
+			op: func(l *types.List) {
+				l.Insert([]byte("500"), []byte("x"), true)
+				l.Insert([]byte("500"), []byte("y"), false)
+			},
+			expected: func(elems []string) []string {
+				res := append([]string{}, elems[:500]...)
+				res = append(res, "x", "500", "y")
+				return append(res, elems[501:]...)
+			},
+		},
+		{
+			testCaseName: "Set by negative index",
+			op:           func(l *types.List) { l.Set(-1, []byte("last")) },
+			expected: func(elems []string) []string {
+				return append(append([]string{}, elems[:n-1]...), "last")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			l := types.NewList()
+			elems := []string{}
+			for i := 0; i < n; i++ {
+				l.PushBack([]byte(strconv.Itoa(i)))
+				elems = append(elems, strconv.Itoa(i))
+			}
+
+			tc.op(l)
+
+			expected := tc.expected(elems)
+			if res := listContents(l); !reflect.DeepEqual(res, expected) {
+				t.Fatalf("Expected %d elements %v..., got %d elements %v...", len(expected), expected[:3], len(res), res[:min(3, len(res))])
+			}
+			if l.Len() != len(expected) {
+				t.Fatalf("Expected length %d, got %d", len(expected), l.Len())
+			}
+			for i := range expected {
+				if elem, _ := l.Index(i); string(elem) != expected[i] {
+					t.Fatalf("Expected %q at index %d, got %q", expected[i], i, elem)
+				}
+			}
+		})
+	}
+}
+
+func TestListRemove(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		count        int
+		removed      int
+		expected     []string
+	}{
+		{testCaseName: "All occurrences", count: 0, removed: 3, expected: []string{"b", "c"}},
+		{testCaseName: "From the head", count: 2, removed: 2, expected: []string{"b", "c", "a"}},
+		{testCaseName: "From the tail", count: -2, removed: 2, expected: []string{"a", "b", "c"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			l := types.NewList()
+			for _, elem := range []string{"a", "b", "a", "c", "a"} {
+				l.PushBack([]byte(elem))
+			}
+
+			if removed := l.Remove(tc.count, []byte("a")); removed != tc.removed {
+				t.Fatalf("Expected %d removed, got %d", tc.removed, removed)
+			}
+			if res := listContents(l); !reflect.DeepEqual(res, tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, res)
+			}
+		})
+	}
+}
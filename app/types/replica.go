@@ -3,27 +3,158 @@ package types
 import (
 	"fmt"
 	"net"
+	"slices"
+	"sync"
 
 	"github.com/codecrafters-io/redis-starter-go/resp"
 )
 
+// replicaOutputLimit caps the bytes queued for a replica that does not keep up, like
+// the replica class of Redis' client-output-buffer-limit. Past it the replica is dropped.
+const replicaOutputLimit = 256 * 1024 * 1024
+
 type Replica struct {
 	Conn              net.Conn
-	BytesAcknowledged int
+	BytesAcknowledged int // Guarded by DBMutex
+
+	server  *ServerState
+	mu      sync.Mutex
+	pending []byte        // Bytes queued for the writer goroutine
+	ready   chan struct{} // Signalled when bytes are queued
+	stopped bool          // Set once the replica is dropped, after which nothing is queued
 }
 
-func (r *Replica) GetAcknowlegment() error {
-	respHandler := resp.RESPHandler{}
+// NewReplica returns a replica of server streaming to conn, and starts the goroutine
+// that writes the queued bytes to it. Once the replica stops, because a write failed
+// or it was dropped, the goroutine removes it from the replicas of server.
+func NewReplica(conn net.Conn, server *ServerState) *Replica {
+	r := &Replica{Conn: conn, server: server, ready: make(chan struct{}, 1)}
+	go r.writeLoop()
+	return r
+}
 
-	// Send the GETACK command to the replica
-	messageBytes,err := respHandler.Array.Encode([]string{"REPLCONF", "GETACK", "*"})
-	if err != nil {
-		return fmt.Errorf("failed to encode GETACK command: %v", err)
+// stop makes the replica drop everything queued and sent from now on, and closes
+// the connection, which also ends a write in progress
+func (r *Replica) stop() {
+	r.mu.Lock()
+	if !r.stopped {
+		r.stopped, r.pending = true, nil
+		close(r.ready)
 	}
-	_, err = r.Conn.Write(messageBytes)
-	if err != nil {
-		return fmt.Errorf("failed to write GETACK command to replica connection: %v", err)
+	r.mu.Unlock()
+	r.Conn.Close()
+}
+
+// Send queues bytes to be written to the replica. It never waits on the connection,
+// so a slow replica does not hold up the clients while DBMutex is held.
+func (r *Replica) Send(b []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return
+	}
+	if len(r.pending)+len(b) > replicaOutputLimit {
+		fmt.Printf("Replica %s exceeded the output buffer limit, dropping it\n", r.Conn.RemoteAddr())
+		r.stopped, r.pending = true, nil
+		close(r.ready)
+		r.Conn.Close()
+		return
 	}
 
+	r.pending = append(r.pending, b...)
+	select {
+	case r.ready <- struct{}{}:
+	default: // The writer has yet to pick up the bytes queued earlier
+	}
+}
+
+// writeLoop writes the queued bytes to the connection in the order they were sent,
+// swapping buffers so that Send can keep queueing during the writes. It runs until
+// the replica stops, and then removes it from the replicas.
+func (r *Replica) writeLoop() {
+	defer func() {
+		r.server.DBMutex.Lock()
+		r.server.removeReplica(r)
+		r.server.DBMutex.Unlock()
+	}()
+
+	var buf []byte
+	for range r.ready {
+		r.mu.Lock()
+		buf, r.pending = r.pending, buf[:0]
+		r.mu.Unlock()
+
+		if _, err := r.Conn.Write(buf); err != nil {
+			fmt.Printf("Failed to stream to replica %s: %s\n", r.Conn.RemoteAddr(), err.Error())
+			r.stop()
+			return
+		}
+	}
+}
+
+// removeReplica removes r from the replicas. It must be called with DBMutex held.
+func (s *ServerState) removeReplica(r *Replica) {
+	s.Replicas = slices.DeleteFunc(s.Replicas, func(other *Replica) bool { return other == r })
+}
+
+// Replica returns the replica streaming to conn, if conn is the connection of one.
+// It must be called with DBMutex held.
+func (s *ServerState) Replica(conn net.Conn) (*Replica, bool) {
+	for _, r := range s.Replicas {
+		if r.Conn == conn {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// DropReplica stops streaming to the replica on conn, if conn is the connection of
+// one, and removes it from the replicas. It must be called with DBMutex held.
+func (s *ServerState) DropReplica(conn net.Conn) {
+	if r, ok := s.Replica(conn); ok {
+		s.removeReplica(r)
+		r.stop()
+	}
+}
+
+func (r *Replica) GetAcknowlegment() error {
+	// Send the GETACK command to the replica
+	r.Send(resp.BulkStrings("REPLCONF", "GETACK", "*").EncodeRESP2())
+
 	return nil
-}
\ No newline at end of file
+}
+
+// Propagate streams a write command to all the replicas. It must be called with
+// DBMutex held, right after the command is applied, so that replicas receive the
+// writes in the same order as they were applied on the master. The command is only
+// queued on each replica, whose own goroutine writes it to the connection.
+func (s *ServerState) Propagate(args ...string) {
+	if s.Role != "master" {
+		return
+	}
+
+	s.propagate(resp.BulkStrings(args...).EncodeRESP2())
+}
+
+// PropagateArgs streams a write command to all the replicas with the arguments it
+// was received with, as Propagate does
+func (s *ServerState) PropagateArgs(command string, args [][]byte) {
+	if s.Role != "master" {
+		return
+	}
+
+	elems := make([]resp.Value, 0, len(args)+1)
+	elems = append(elems, resp.BulkString(command))
+	for _, arg := range args {
+		elems = append(elems, resp.BulkBytes(arg))
+	}
+	s.propagate(resp.Array(elems...).EncodeRESP2())
+}
+
+func (s *ServerState) propagate(buff []byte) {
+	s.BytesSent += len(buff)
+	for _, r := range s.Replicas {
+		r.Send(buff)
+	}
+}
@@ -0,0 +1,83 @@
+package types_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestReplicaSend(t *testing.T) {
+	master, replica := net.Pipe()
+	defer master.Close()
+	defer replica.Close()
+
+	// Nothing reads from the pipe yet, so a write on the connection would block
+	r := types.NewReplica(master, &types.ServerState{})
+	expected := []byte{}
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			b := []byte(fmt.Sprintf("*1\r\n$%d\r\n%d\r\n", len(fmt.Sprint(i)), i))
+			r.Send(b)
+			expected = append(expected, b...)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Send not to wait for the replica to read")
+	}
+
+	received := make([]byte, len(expected))
+	replica.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := io.ReadFull(replica, received); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !bytes.Equal(received, expected) {
+		t.Fatalf("Expected the bytes in the order they were sent, got %q", received)
+	}
+}
+
+func TestReplicaRemovedOnceStopped(t *testing.T) {
+	server := &types.ServerState{Role: "master"}
+	removed := func() bool {
+		server.DBMutex.Lock()
+		defer server.DBMutex.Unlock()
+		return len(server.Replicas) == 0
+	}
+	waitRemoved := func() {
+		t.Helper()
+		for deadline := time.Now().Add(time.Second); !removed(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the replica to be removed")
+			}
+		}
+	}
+
+	// A write that fails, as the replica went away
+	master, replica := net.Pipe()
+	server.Replicas = append(server.Replicas, types.NewReplica(master, server))
+	replica.Close()
+	server.DBMutex.Lock()
+	server.Propagate("SET", "k", "v")
+	server.DBMutex.Unlock()
+	waitRemoved()
+
+	// A replica whose connection is dropped
+	master, replica = net.Pipe()
+	defer replica.Close()
+	server.Replicas = append(server.Replicas, types.NewReplica(master, server))
+	server.DBMutex.Lock()
+	server.DropReplica(master)
+	server.DBMutex.Unlock()
+	waitRemoved()
+	if _, err := master.Write([]byte("x")); err == nil {
+		t.Fatalf("Expected the connection to be closed")
+	}
+}
@@ -0,0 +1,170 @@
+package types
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+)
+
+// setMaxIntsetEntries is the most members a set of integers holds before it is
+// converted to a Dict, like Redis' set-max-intset-entries
+const setMaxIntsetEntries = 512
+
+// Set is the value stored in the keyspace for set keys. As long as every member is
+// an integer, and there are not too many of them, the members are kept as a sorted
+// slice of integers, like the intset in Redis: 8 bytes per member rather than a
+// string and a hash table entry. Adding any other member converts the set to a Dict
+// for good.
+type Set struct {
+	intset []int64         // Members while the set is an intset, in ascending order
+	dict   *Dict[struct{}] // Members once the set was converted, nil before
+}
+
+func NewSet() *Set {
+	return &Set{}
+}
+
+func (s *Set) Type() string {
+	return "set"
+}
+
+func (s *Set) Copy() Object {
+	if s.dict == nil {
+		return &Set{intset: slices.Clone(s.intset)}
+	}
+	c := NewSet()
+	s.dict.Range(func(member string, _ struct{}) bool {
+		c.Add(member)
+		return true
+	})
+	return c
+}
+
+// Encoding returns the name Redis gives the encoding in use: intset while every
+// member is an integer, hashtable once it was converted
+func (s *Set) Encoding() string {
+	if s.dict != nil {
+		return "hashtable"
+	}
+	return "intset"
+}
+
+func (s *Set) Len() int {
+	if s.dict != nil {
+		return s.dict.Len()
+	}
+	return len(s.intset)
+}
+
+// parseSetInt parses member as an integer if it is in canonical form, so that it
+// is written back exactly as it was given
+func parseSetInt(member string) (int64, bool) {
+	n, err := strconv.ParseInt(member, 10, 64)
+	if err != nil || strconv.FormatInt(n, 10) != member {
+		return 0, false
+	}
+	return n, true
+}
+
+func (s *Set) Contains(member string) bool {
+	if s.dict != nil {
+		_, ok := s.dict.Get(member)
+		return ok
+	}
+	n, ok := parseSetInt(member)
+	if !ok {
+		return false
+	}
+	_, found := slices.BinarySearch(s.intset, n)
+	return found
+}
+
+// Add adds member and reports whether it was not already in the set
+func (s *Set) Add(member string) bool {
+	if s.dict == nil {
+		n, ok := parseSetInt(member)
+		if ok {
+			i, found := slices.BinarySearch(s.intset, n)
+			if found {
+				return false
+			}
+			if len(s.intset) < setMaxIntsetEntries {
+				s.intset = slices.Insert(s.intset, i, n)
+				return true
+			}
+		}
+		s.convert()
+	}
+	return s.dict.Set(member, struct{}{})
+}
+
+// convert switches the set from an intset to a Dict
+func (s *Set) convert() {
+	s.dict = NewDict[struct{}]()
+	for _, n := range s.intset {
+		s.dict.Set(strconv.FormatInt(n, 10), struct{}{})
+	}
+	s.intset = nil
+}
+
+// Remove removes member and reports whether it was in the set
+func (s *Set) Remove(member string) bool {
+	if s.dict != nil {
+		return s.dict.Delete(member)
+	}
+	n, ok := parseSetInt(member)
+	if !ok {
+		return false
+	}
+	i, found := slices.BinarySearch(s.intset, n)
+	if found {
+		s.intset = slices.Delete(s.intset, i, i+1)
+	}
+	return found
+}
+
+// Range calls fn for every member until it returns false. fn must not modify the set.
+func (s *Set) Range(fn func(member string) bool) {
+	if s.dict != nil {
+		s.dict.Range(func(member string, _ struct{}) bool {
+			return fn(member)
+		})
+		return
+	}
+	for _, n := range s.intset {
+		if !fn(strconv.FormatInt(n, 10)) {
+			return
+		}
+	}
+}
+
+// Members returns all members, in no particular order
+func (s *Set) Members() []string {
+	members := make([]string, 0, s.Len())
+	s.Range(func(member string) bool {
+		members = append(members, member)
+		return true
+	})
+	return members
+}
+
+// Scan walks the set with a cursor, like Dict.Scan. An intset is returned whole in
+// the first call.
+func (s *Set) Scan(cursor uint64, fn func(member string, _ struct{})) uint64 {
+	if s.dict != nil {
+		return s.dict.Scan(cursor, fn)
+	}
+	for _, n := range s.intset {
+		fn(strconv.FormatInt(n, 10), struct{}{})
+	}
+	return 0
+}
+
+// Random returns a random member of a non-empty set
+func (s *Set) Random() string {
+	if s.dict != nil {
+		member, _, _ := s.dict.Random()
+		return member
+	}
+	return strconv.FormatInt(s.intset[rand.Intn(len(s.intset))], 10)
+}
@@ -0,0 +1,75 @@
+package types_test
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestSetEncoding(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		members      []string
+		expected     string
+	}{
+		{testCaseName: "Integers", members: []string{"3", "-1", "20", "3"}, expected: "intset"},
+		{testCaseName: "Integer not in canonical form", members: []string{"1", "01"}, expected: "hashtable"},
+		{testCaseName: "Non-integer member", members: []string{"1", "a"}, expected: "hashtable"},
+		{testCaseName: "Too many integers", members: intMembers(513), expected: "hashtable"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			s := types.NewSet()
+			unique := map[string]bool{}
+			for _, member := range tc.members {
+				if s.Add(member) == unique[member] {
+					t.Fatalf("Unexpected result adding %q", member)
+				}
+				unique[member] = true
+			}
+
+			if s.Encoding() != tc.expected {
+				t.Fatalf("Expected encoding %s, got %s", tc.expected, s.Encoding())
+			}
+			if s.Len() != len(unique) {
+				t.Fatalf("Expected %d members, got %d", len(unique), s.Len())
+			}
+			for member := range unique {
+				if !s.Contains(member) {
+					t.Fatalf("Expected %q to be a member", member)
+				}
+			}
+			if s.Contains("1.0") {
+				t.Fatalf("Unexpected member 1.0")
+			}
+		})
+	}
+}
+
+func TestSetIntsetOrder(t *testing.T) {
+	s := types.NewSet()
+	for _, member := range []string{"5", "-3", "100", "0"} {
+		s.Add(member)
+	}
+	s.Remove("100")
+
+	res := s.Members()
+	if !sort.SliceIsSorted(res, func(i, j int) bool {
+		a, _ := strconv.Atoi(res[i])
+		b, _ := strconv.Atoi(res[j])
+		return a < b
+	}) || len(res) != 3 {
+		t.Fatalf("Expected 3 members in ascending order, got %v", res)
+	}
+}
+
+func intMembers(n int) []string {
+	members := make([]string, n)
+	for i := range members {
+		members[i] = strconv.Itoa(i)
+	}
+	return members
+}
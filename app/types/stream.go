@@ -0,0 +1,398 @@
+package types
+
+import (
+	"cmp"
+	"encoding/binary"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// A node holds at most streamNodeMaxEntries entries and streamNodeMaxBytes bytes
+// before a new one is started, like Redis' stream-node-max-entries and
+// stream-node-max-bytes
+const (
+	streamNodeMaxEntries = 100
+	streamNodeMaxBytes   = 4096
+)
+
+// StreamID identifies an entry of a stream: the Unix time in milliseconds at which
+// it was added, and a sequence number for entries added in the same millisecond
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+// MaxStreamID is the largest possible ID, used for the "+" bound of a range
+var MaxStreamID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+func (id StreamID) Compare(other StreamID) int {
+	if id.Ms != other.Ms {
+		return cmp.Compare(id.Ms, other.Ms)
+	}
+	return cmp.Compare(id.Seq, other.Seq)
+}
+
+// Next returns the smallest ID greater than id. It reports false if id is the
+// largest possible one.
+func (id StreamID) Next() (StreamID, bool) {
+	switch {
+	case id.Seq < math.MaxUint64:
+		return StreamID{Ms: id.Ms, Seq: id.Seq + 1}, true
+	case id.Ms < math.MaxUint64:
+		return StreamID{Ms: id.Ms + 1}, true
+	}
+	return id, false
+}
+
+// Prev returns the largest ID smaller than id. It reports false if id is 0-0.
+func (id StreamID) Prev() (StreamID, bool) {
+	switch {
+	case id.Seq > 0:
+		return StreamID{Ms: id.Ms, Seq: id.Seq - 1}, true
+	case id.Ms > 0:
+		return StreamID{Ms: id.Ms - 1, Seq: math.MaxUint64}, true
+	}
+	return id, false
+}
+
+// StreamEntry is an entry of a stream, with its fields in the order they were given
+type StreamEntry struct {
+	ID     StreamID
+	Fields [][]byte // Field names and values, alternating
+}
+
+// streamNode holds consecutive entries packed in a single byte slice, like a
+// listpack in Redis. Each entry is a flags byte, the difference between its ID and
+// the master ID, and its fields, all prefixed with their length. Deleting an entry
+// only flags it as deleted: the node is dropped once all its entries are.
+type streamNode struct {
+	master StreamID // ID of the first entry added to the node
+	last   StreamID // ID of the last entry added to the node
+	data   []byte
+	count  int // Entries in data, including deleted ones
+	live   int // Entries not deleted
+}
+
+const streamEntryDeleted = 1
+
+func (n *streamNode) append(e StreamEntry) {
+	n.data = append(n.data, 0)
+	n.data = binary.AppendUvarint(n.data, e.ID.Ms-n.master.Ms)
+	n.data = binary.AppendUvarint(n.data, e.ID.Seq)
+	n.data = binary.AppendUvarint(n.data, uint64(len(e.Fields)))
+	for _, f := range e.Fields {
+		n.data = binary.AppendUvarint(n.data, uint64(len(f)))
+		n.data = append(n.data, f...)
+	}
+	n.last = e.ID
+	n.count++
+	n.live++
+}
+
+// iterate calls fn for every entry that is not deleted, in ID order, with the
+// offset of the entry in data, until it returns false
+func (n *streamNode) iterate(fn func(offset int, e StreamEntry) bool) {
+	for p := 0; p < len(n.data); {
+		offset, deleted := p, n.data[p]&streamEntryDeleted != 0
+		p++
+		msDelta, k := binary.Uvarint(n.data[p:])
+		p += k
+		seq, k := binary.Uvarint(n.data[p:])
+		p += k
+		numFields, k := binary.Uvarint(n.data[p:])
+		p += k
+
+		e := StreamEntry{ID: StreamID{Ms: n.master.Ms + msDelta, Seq: seq}}
+		if !deleted {
+			e.Fields = make([][]byte, numFields)
+		}
+		for i := range int(numFields) {
+			length, k := binary.Uvarint(n.data[p:])
+			p += k
+			if !deleted {
+				e.Fields[i] = n.data[p : p+int(length) : p+int(length)]
+			}
+			p += int(length)
+		}
+
+		if !deleted && !fn(offset, e) {
+			return
+		}
+	}
+}
+
+// entries returns the entries of the node that are not deleted
+func (n *streamNode) entries() []StreamEntry {
+	entries := make([]StreamEntry, 0, n.live)
+	n.iterate(func(_ int, e StreamEntry) bool {
+		entries = append(entries, e)
+		return true
+	})
+	return entries
+}
+
+func (n *streamNode) markDeleted(offset int) {
+	n.data[offset] |= streamEntryDeleted
+	n.live--
+}
+
+// Stream is the value stored in the keyspace for stream keys. Entries are stored in
+// nodes of up to streamNodeMaxEntries entries, ordered by ID. Redis indexes the
+// nodes with a radix tree keyed by their master ID; a sorted slice gives the same
+// logarithmic lookups, as nodes are only ever appended or removed.
+type Stream struct {
+	nodes        []*streamNode
+	length       int
+	lastID       StreamID // ID of the last entry ever added, which new IDs must be greater than
+	entriesAdded int64    // Number of entries ever added
+	maxDeletedID StreamID // Greatest ID deleted with XDEL, 0-0 if none was
+	groups       map[string]*StreamGroup
+}
+
+func NewStream() *Stream {
+	return &Stream{groups: map[string]*StreamGroup{}}
+}
+
+func (s *Stream) Type() string {
+	return "stream"
+}
+
+func (s *Stream) Copy() Object {
+	c := &Stream{
+		nodes:        make([]*streamNode, len(s.nodes)),
+		length:       s.length,
+		lastID:       s.lastID,
+		entriesAdded: s.entriesAdded,
+		maxDeletedID: s.maxDeletedID,
+		groups:       make(map[string]*StreamGroup, len(s.groups)),
+	}
+	for i, n := range s.nodes {
+		node := *n
+		node.data = slices.Clone(n.data)
+		c.nodes[i] = &node
+	}
+	for name, g := range s.groups {
+		c.groups[name] = g.copy()
+	}
+	return c
+}
+
+func (s *Stream) Len() int {
+	return s.length
+}
+
+// LastID returns the ID of the last entry ever added, even if it was deleted since
+func (s *Stream) LastID() StreamID {
+	return s.lastID
+}
+
+// EntriesAdded returns the number of entries ever added, including deleted ones
+func (s *Stream) EntriesAdded() int64 {
+	return s.entriesAdded
+}
+
+// MaxDeletedID returns the greatest ID deleted with Delete, or 0-0 if none was.
+// Entries removed by trimming do not count.
+func (s *Stream) MaxDeletedID() StreamID {
+	return s.maxDeletedID
+}
+
+// SetID sets the IDs and count that new entries are checked against, like XSETID.
+// It is used to restore a stream, whose entries were added again.
+func (s *Stream) SetID(lastID StreamID, entriesAdded int64, maxDeletedID StreamID) {
+	s.lastID = lastID
+	s.entriesAdded = entriesAdded
+	s.maxDeletedID = maxDeletedID
+}
+
+// NodeCount returns the number of nodes the entries are stored in
+func (s *Stream) NodeCount() int {
+	return len(s.nodes)
+}
+
+// Add appends an entry, whose ID must be greater than LastID
+func (s *Stream) Add(id StreamID, fields [][]byte) {
+	var n *streamNode
+	if len(s.nodes) > 0 {
+		n = s.nodes[len(s.nodes)-1]
+	}
+	if n == nil || n.count >= streamNodeMaxEntries || len(n.data) >= streamNodeMaxBytes {
+		n = &streamNode{master: id}
+		s.nodes = append(s.nodes, n)
+	}
+	n.append(StreamEntry{ID: id, Fields: fields})
+	s.length++
+	s.lastID = id
+	s.entriesAdded++
+}
+
+// nodeIndex returns the index of the first node that may hold entries from id on
+func (s *Stream) nodeIndex(id StreamID) int {
+	return sort.Search(len(s.nodes), func(i int) bool { return s.nodes[i].last.Compare(id) >= 0 })
+}
+
+// Range returns the entries with IDs from start to end inclusive, from the end if
+// reverse is set, up to count entries, or all of them if count is negative
+func (s *Stream) Range(start, end StreamID, reverse bool, count int) []StreamEntry {
+	entries := []StreamEntry{}
+	if start.Compare(end) > 0 || count == 0 {
+		return entries
+	}
+
+	if !reverse {
+		for i := s.nodeIndex(start); i < len(s.nodes); i++ {
+			done := false
+			s.nodes[i].iterate(func(_ int, e StreamEntry) bool {
+				if e.ID.Compare(start) < 0 {
+					return true
+				}
+				if e.ID.Compare(end) > 0 {
+					done = true
+					return false
+				}
+				entries = append(entries, e)
+				done = len(entries) == count
+				return !done
+			})
+			if done {
+				break
+			}
+		}
+		return entries
+	}
+
+	for i := min(s.nodeIndex(end), len(s.nodes)-1); i >= 0; i-- {
+		nodeEntries := s.nodes[i].entries()
+		for j := len(nodeEntries) - 1; j >= 0; j-- {
+			e := nodeEntries[j]
+			if e.ID.Compare(end) > 0 {
+				continue
+			}
+			if e.ID.Compare(start) < 0 {
+				return entries
+			}
+			entries = append(entries, e)
+			if len(entries) == count {
+				return entries
+			}
+		}
+	}
+	return entries
+}
+
+// First returns the entry with the smallest ID. It reports false if the stream is empty.
+func (s *Stream) First() (StreamEntry, bool) {
+	entries := s.Range(StreamID{}, MaxStreamID, false, 1)
+	if len(entries) == 0 {
+		return StreamEntry{}, false
+	}
+	return entries[0], true
+}
+
+// Last returns the entry with the greatest ID. It reports false if the stream is empty.
+func (s *Stream) Last() (StreamEntry, bool) {
+	entries := s.Range(StreamID{}, MaxStreamID, true, 1)
+	if len(entries) == 0 {
+		return StreamEntry{}, false
+	}
+	return entries[0], true
+}
+
+// Get returns the entry with the given ID. It reports false if there is none.
+func (s *Stream) Get(id StreamID) (StreamEntry, bool) {
+	entries := s.Range(id, id, false, 1)
+	if len(entries) == 0 {
+		return StreamEntry{}, false
+	}
+	return entries[0], true
+}
+
+// Delete removes the entry with the given ID and reports whether it existed
+func (s *Stream) Delete(id StreamID) bool {
+	i := s.nodeIndex(id)
+	if i == len(s.nodes) {
+		return false
+	}
+
+	n, found := s.nodes[i], false
+	n.iterate(func(offset int, e StreamEntry) bool {
+		if e.ID == id {
+			n.markDeleted(offset)
+			found = true
+		}
+		return e.ID.Compare(id) < 0
+	})
+	if !found {
+		return false
+	}
+
+	s.length--
+	if n.live == 0 {
+		s.nodes = slices.Delete(s.nodes, i, i+1)
+	}
+	if id.Compare(s.maxDeletedID) > 0 {
+		s.maxDeletedID = id
+	}
+	return true
+}
+
+// TrimMaxLen removes the oldest entries until at most maxLen remain, and returns
+// how many were removed. With approx, only whole nodes are removed, so that more
+// than maxLen entries may remain. limit is the most entries removed, 0 for no limit.
+func (s *Stream) TrimMaxLen(maxLen int, approx bool, limit int) int {
+	return s.trim(
+		func(n *streamNode) bool { return s.length-n.live >= maxLen },
+		func(StreamID) bool { return s.length > maxLen },
+		approx, limit,
+	)
+}
+
+// TrimMinID removes the entries with an ID smaller than minID, like TrimMaxLen
+func (s *Stream) TrimMinID(minID StreamID, approx bool, limit int) int {
+	return s.trim(
+		func(n *streamNode) bool { return n.last.Compare(minID) < 0 },
+		func(id StreamID) bool { return id.Compare(minID) < 0 },
+		approx, limit,
+	)
+}
+
+// trim removes the nodes at the start of the stream for which removeNode is true,
+// and unless approx is set, the entries of the next node for which removeEntry is
+// true
+func (s *Stream) trim(removeNode func(n *streamNode) bool, removeEntry func(id StreamID) bool, approx bool, limit int) int {
+	removed := 0
+	for len(s.nodes) > 0 && removeNode(s.nodes[0]) {
+		n := s.nodes[0]
+		if limit > 0 && removed+n.live > limit {
+			return removed
+		}
+		removed += n.live
+		s.length -= n.live
+		s.nodes[0] = nil
+		s.nodes = s.nodes[1:]
+	}
+	if approx || len(s.nodes) == 0 {
+		return removed
+	}
+
+	n := s.nodes[0]
+	n.iterate(func(offset int, e StreamEntry) bool {
+		if !removeEntry(e.ID) || (limit > 0 && removed >= limit) {
+			return false
+		}
+		n.markDeleted(offset)
+		removed++
+		s.length--
+		return true
+	})
+	if n.live == 0 {
+		s.nodes = s.nodes[1:]
+	}
+	return removed
+}
@@ -0,0 +1,280 @@
+package types
+
+import (
+	"slices"
+	"sort"
+)
+
+// PendingEntry is an entry delivered to a consumer of a group that the consumer has
+// not acknowledged yet
+type PendingEntry struct {
+	ID            StreamID
+	Consumer      string
+	DeliveryTime  int64 // Unix time in milliseconds of the last delivery
+	DeliveryCount int64
+}
+
+// PendingList holds pending entries ordered by ID. Redis keeps them in a radix tree.
+// Entries are delivered in ID order, so they are nearly always appended to the
+// sorted slice here, while lookups by ID go through the map.
+type PendingList struct {
+	ids     []StreamID
+	entries map[StreamID]*PendingEntry
+}
+
+func newPendingList() *PendingList {
+	return &PendingList{entries: map[StreamID]*PendingEntry{}}
+}
+
+func (l *PendingList) Len() int {
+	return len(l.ids)
+}
+
+func (l *PendingList) Get(id StreamID) (*PendingEntry, bool) {
+	pe, ok := l.entries[id]
+	return pe, ok
+}
+
+func (l *PendingList) add(pe *PendingEntry) {
+	i, found := slices.BinarySearchFunc(l.ids, pe.ID, StreamID.Compare)
+	if !found {
+		l.ids = slices.Insert(l.ids, i, pe.ID)
+	}
+	l.entries[pe.ID] = pe
+}
+
+func (l *PendingList) remove(id StreamID) bool {
+	i, found := slices.BinarySearchFunc(l.ids, id, StreamID.Compare)
+	if !found {
+		return false
+	}
+	l.ids = slices.Delete(l.ids, i, i+1)
+	delete(l.entries, id)
+	return true
+}
+
+// Range calls fn for the pending entries with IDs from start to end inclusive, in
+// ID order, until it returns false. fn must not modify the list.
+func (l *PendingList) Range(start, end StreamID, fn func(pe *PendingEntry) bool) {
+	i, _ := slices.BinarySearchFunc(l.ids, start, StreamID.Compare)
+	for ; i < len(l.ids) && l.ids[i].Compare(end) <= 0; i++ {
+		if !fn(l.entries[l.ids[i]]) {
+			return
+		}
+	}
+}
+
+// StreamConsumer is a consumer of a group, created the first time it reads from the group
+type StreamConsumer struct {
+	Name       string
+	SeenTime   int64 // Unix time in milliseconds of the last attempted interaction
+	ActiveTime int64 // Unix time in milliseconds of the last successful interaction, -1 if none
+	Pending    *PendingList
+}
+
+// StreamGroup is a consumer group of a stream. Every entry read through the group is
+// delivered to a single consumer, and stays pending for that consumer until it is
+// acknowledged.
+type StreamGroup struct {
+	LastID      StreamID // ID of the last entry delivered to the group
+	EntriesRead int64    // Number of entries delivered to the group, -1 if it is unknown
+	Pending     *PendingList
+	consumers   map[string]*StreamConsumer
+}
+
+func (g *StreamGroup) copy() *StreamGroup {
+	c := &StreamGroup{
+		LastID:      g.LastID,
+		EntriesRead: g.EntriesRead,
+		Pending:     newPendingList(),
+		consumers:   make(map[string]*StreamConsumer, len(g.consumers)),
+	}
+	for name, consumer := range g.consumers {
+		c.consumers[name] = &StreamConsumer{
+			Name:       name,
+			SeenTime:   consumer.SeenTime,
+			ActiveTime: consumer.ActiveTime,
+			Pending:    newPendingList(),
+		}
+	}
+	g.Pending.Range(StreamID{}, MaxStreamID, func(pe *PendingEntry) bool {
+		entry := *pe
+		c.Pending.add(&entry)
+		c.consumers[entry.Consumer].Pending.add(&entry)
+		return true
+	})
+	return c
+}
+
+func (g *StreamGroup) Consumer(name string) (*StreamConsumer, bool) {
+	consumer, ok := g.consumers[name]
+	return consumer, ok
+}
+
+// CreateConsumer returns the consumer with the given name, creating it if it does
+// not exist yet, and reports whether it was created
+func (g *StreamGroup) CreateConsumer(name string, now int64) (*StreamConsumer, bool) {
+	if consumer, ok := g.consumers[name]; ok {
+		return consumer, false
+	}
+	consumer := &StreamConsumer{Name: name, SeenTime: now, ActiveTime: -1, Pending: newPendingList()}
+	g.consumers[name] = consumer
+	return consumer, true
+}
+
+// DeleteConsumer deletes a consumer along with its pending entries, and returns the
+// number of entries that were pending. It reports whether the consumer existed.
+func (g *StreamGroup) DeleteConsumer(name string) (int, bool) {
+	consumer, ok := g.consumers[name]
+	if !ok {
+		return 0, false
+	}
+	for _, id := range consumer.Pending.ids {
+		g.Pending.remove(id)
+	}
+	delete(g.consumers, name)
+	return consumer.Pending.Len(), true
+}
+
+// Consumers returns the consumers of the group, ordered by name
+func (g *StreamGroup) Consumers() []*StreamConsumer {
+	consumers := make([]*StreamConsumer, 0, len(g.consumers))
+	for _, consumer := range g.consumers {
+		consumers = append(consumers, consumer)
+	}
+	sort.Slice(consumers, func(i, j int) bool { return consumers[i].Name < consumers[j].Name })
+	return consumers
+}
+
+// Claim makes the entry with the given ID pending for consumer, taking it over from
+// the consumer it was pending for if any, and sets its delivery time and count
+func (g *StreamGroup) Claim(id StreamID, consumer *StreamConsumer, deliveryTime, deliveryCount int64) *PendingEntry {
+	pe, ok := g.Pending.Get(id)
+	if !ok {
+		pe = &PendingEntry{ID: id}
+		g.Pending.add(pe)
+	} else if pe.Consumer != consumer.Name {
+		g.consumers[pe.Consumer].Pending.remove(id)
+	}
+	pe.Consumer = consumer.Name
+	pe.DeliveryTime = deliveryTime
+	pe.DeliveryCount = deliveryCount
+	consumer.Pending.add(pe)
+	return pe
+}
+
+// Ack removes the entry with the given ID from the pending entries, and reports
+// whether it was pending
+func (g *StreamGroup) Ack(id StreamID) bool {
+	pe, ok := g.Pending.Get(id)
+	if !ok {
+		return false
+	}
+	g.Pending.remove(id)
+	g.consumers[pe.Consumer].Pending.remove(id)
+	return true
+}
+
+func (s *Stream) Group(name string) (*StreamGroup, bool) {
+	g, ok := s.groups[name]
+	return g, ok
+}
+
+// CreateGroup creates a group that has read the entries up to lastID, and reports
+// false if a group with that name already exists
+func (s *Stream) CreateGroup(name string, lastID StreamID, entriesRead int64) bool {
+	if _, ok := s.groups[name]; ok {
+		return false
+	}
+	s.groups[name] = &StreamGroup{
+		LastID:      lastID,
+		EntriesRead: entriesRead,
+		Pending:     newPendingList(),
+		consumers:   map[string]*StreamConsumer{},
+	}
+	return true
+}
+
+// DestroyGroup deletes a group and reports whether it existed
+func (s *Stream) DestroyGroup(name string) bool {
+	if _, ok := s.groups[name]; !ok {
+		return false
+	}
+	delete(s.groups, name)
+	return true
+}
+
+// GroupNames returns the names of the groups, in order
+func (s *Stream) GroupNames() []string {
+	names := make([]string, 0, len(s.groups))
+	for name := range s.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasTombstonesFrom reports whether an entry with an ID from start on may have been
+// deleted, in which case counting entries from start is not possible
+func (s *Stream) hasTombstonesFrom(start StreamID) bool {
+	if s.length == 0 || s.maxDeletedID == (StreamID{}) {
+		return false
+	}
+	return start.Compare(s.maxDeletedID) <= 0
+}
+
+// EntriesReadAt estimates how many entries were added up to and including the one
+// with the given ID, as Redis does to track how far groups have read. It returns -1
+// if deletions make that impossible to tell.
+func (s *Stream) EntriesReadAt(id StreamID) int64 {
+	if s.entriesAdded == 0 {
+		return 0
+	}
+	if s.length == 0 && id.Compare(s.lastID) <= 0 {
+		return s.entriesAdded
+	}
+	switch c := id.Compare(s.lastID); {
+	case c == 0:
+		return s.entriesAdded
+	case c > 0:
+		return -1
+	}
+
+	// Without deletions past the first entry, the entries before it were all trimmed
+	first, _ := s.First()
+	if s.maxDeletedID == (StreamID{}) || s.maxDeletedID.Compare(first.ID) < 0 {
+		switch c := id.Compare(first.ID); {
+		case c < 0:
+			return s.entriesAdded - int64(s.length)
+		case c == 0:
+			return s.entriesAdded - int64(s.length) + 1
+		}
+	}
+	return -1
+}
+
+// Advance records that the entry with the given ID was delivered to the group, as
+// the next one after its last delivered entry
+func (s *Stream) Advance(g *StreamGroup, id StreamID) {
+	if g.EntriesRead != -1 && !s.hasTombstonesFrom(id) {
+		g.EntriesRead++
+	} else if s.entriesAdded > 0 {
+		g.EntriesRead = s.EntriesReadAt(id)
+	}
+	g.LastID = id
+}
+
+// Lag returns the number of entries not delivered to the group yet. It reports
+// false if deletions make that impossible to tell.
+func (s *Stream) Lag(g *StreamGroup) (int64, bool) {
+	if s.entriesAdded == 0 {
+		return 0, true
+	}
+	if g.EntriesRead != -1 && !s.hasTombstonesFrom(g.LastID) {
+		return s.entriesAdded - g.EntriesRead, true
+	}
+	if read := s.EntriesReadAt(g.LastID); read != -1 {
+		return s.entriesAdded - read, true
+	}
+	return 0, false
+}
@@ -0,0 +1,84 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+func TestStreamGroupPending(t *testing.T) {
+	s := newTestStream(10)
+	s.CreateGroup("g", types.StreamID{}, 0)
+	g, _ := s.Group("g")
+	alice, _ := g.CreateConsumer("alice", 0)
+	bob, _ := g.CreateConsumer("bob", 0)
+
+	for _, e := range s.Range(types.StreamID{}, types.MaxStreamID, false, 4) {
+		s.Advance(g, e.ID)
+		g.Claim(e.ID, alice, 1, 1)
+	}
+	g.Claim(types.StreamID{Ms: 2}, bob, 2, 2)
+
+	if g.Pending.Len() != 4 || alice.Pending.Len() != 3 || bob.Pending.Len() != 1 {
+		t.Fatalf("Expected 4 entries pending, 3 for alice and 1 for bob, got %d, %d and %d", g.Pending.Len(), alice.Pending.Len(), bob.Pending.Len())
+	}
+	if pe, _ := g.Pending.Get(types.StreamID{Ms: 2}); pe.Consumer != "bob" || pe.DeliveryCount != 2 {
+		t.Fatalf("Expected 2-0 to be pending for bob with 2 deliveries, got %+v", pe)
+	}
+
+	if !g.Ack(types.StreamID{Ms: 1}) || g.Ack(types.StreamID{Ms: 1}) {
+		t.Fatalf("Expected 1-0 to be acknowledged once")
+	}
+	got := []uint64{}
+	alice.Pending.Range(types.StreamID{}, types.MaxStreamID, func(pe *types.PendingEntry) bool {
+		got = append(got, pe.ID.Ms)
+		return true
+	})
+	if len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Fatalf("Expected 3-0 and 4-0 pending for alice, got %v", got)
+	}
+
+	if pending, ok := g.DeleteConsumer("alice"); !ok || pending != 2 {
+		t.Fatalf("Expected alice to be deleted with 2 entries pending, got %d", pending)
+	}
+	if g.Pending.Len() != 1 {
+		t.Fatalf("Expected 1 entry pending, got %d", g.Pending.Len())
+	}
+
+	c := s.Copy().(*types.Stream)
+	cg, _ := c.Group("g")
+	cg.Ack(types.StreamID{Ms: 2})
+	if g.Pending.Len() != 1 || cg.Pending.Len() != 0 {
+		t.Fatalf("Expected the copy to have its own pending entries")
+	}
+}
+
+func TestStreamGroupLag(t *testing.T) {
+	s := newTestStream(10)
+	s.CreateGroup("g", types.StreamID{}, 0)
+	g, _ := s.Group("g")
+
+	for _, e := range s.Range(types.StreamID{}, types.MaxStreamID, false, 4) {
+		s.Advance(g, e.ID)
+	}
+	if g.EntriesRead != 4 {
+		t.Fatalf("Expected 4 entries read, got %d", g.EntriesRead)
+	}
+	if lag, ok := s.Lag(g); !ok || lag != 6 {
+		t.Fatalf("Expected a lag of 6, got %d", lag)
+	}
+
+	// A deletion after the last delivered entry makes the lag unknown
+	s.Delete(types.StreamID{Ms: 7})
+	if _, ok := s.Lag(g); ok {
+		t.Fatalf("Expected the lag to be unknown")
+	}
+
+	// Once the group reads past the deletion it is known again
+	for _, e := range s.Range(types.StreamID{Ms: 5}, types.MaxStreamID, false, -1) {
+		s.Advance(g, e.ID)
+	}
+	if lag, ok := s.Lag(g); !ok || lag != 0 {
+		t.Fatalf("Expected a lag of 0, got %d", lag)
+	}
+}
@@ -0,0 +1,103 @@
+package types_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// newTestStream returns a stream with n entries, with IDs 1-0 to n-0 and one field
+// holding the entry number
+func newTestStream(n int) *types.Stream {
+	s := types.NewStream()
+	for i := 1; i <= n; i++ {
+		s.Add(types.StreamID{Ms: uint64(i)}, [][]byte{[]byte("n"), []byte(strconv.Itoa(i))})
+	}
+	return s
+}
+
+// ids returns the Ms part of the IDs of entries
+func ids(entries []types.StreamEntry) []uint64 {
+	res := []uint64{}
+	for _, e := range entries {
+		res = append(res, e.ID.Ms)
+	}
+	return res
+}
+
+func TestStreamRange(t *testing.T) {
+	s := newTestStream(250)
+	s.Delete(types.StreamID{Ms: 100})
+	s.Delete(types.StreamID{Ms: 101})
+
+	tests := []struct {
+		testCaseName string
+		start, end   uint64
+		reverse      bool
+		count        int
+		expected     []uint64
+	}{
+		{testCaseName: "Across nodes", start: 98, end: 103, count: -1, expected: []uint64{98, 99, 102, 103}},
+		{testCaseName: "Count", start: 1, end: 250, count: 3, expected: []uint64{1, 2, 3}},
+		{testCaseName: "Reverse", start: 98, end: 102, reverse: true, count: -1, expected: []uint64{102, 99, 98}},
+		{testCaseName: "Reverse count", start: 1, end: 1000, reverse: true, count: 2, expected: []uint64{250, 249}},
+		{testCaseName: "Deleted only", start: 100, end: 101, count: -1, expected: []uint64{}},
+		{testCaseName: "Past the end", start: 300, end: 400, reverse: true, count: -1, expected: []uint64{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			res := ids(s.Range(types.StreamID{Ms: tc.start}, types.StreamID{Ms: tc.end}, tc.reverse, tc.count))
+			if len(res) != len(tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, res)
+			}
+			for i := range res {
+				if res[i] != tc.expected[i] {
+					t.Fatalf("Expected %v, got %v", tc.expected, res)
+				}
+			}
+		})
+	}
+
+	if s.Len() != 248 {
+		t.Fatalf("Expected 248 entries, got %d", s.Len())
+	}
+	entries := s.Range(types.StreamID{Ms: 7}, types.StreamID{Ms: 7}, false, -1)
+	if len(entries) != 1 || string(entries[0].Fields[1]) != "7" {
+		t.Fatalf("Unexpected entry %v", entries)
+	}
+}
+
+func TestStreamTrim(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		trim         func(s *types.Stream) int
+		removed      int
+		first        uint64
+	}{
+		{testCaseName: "MAXLEN", trim: func(s *types.Stream) int { return s.TrimMaxLen(90, false, 0) }, removed: 160, first: 161},
+		{testCaseName: "Approximate MAXLEN", trim: func(s *types.Stream) int { return s.TrimMaxLen(90, true, 0) }, removed: 100, first: 101},
+		{testCaseName: "MAXLEN with limit", trim: func(s *types.Stream) int { return s.TrimMaxLen(0, true, 150) }, removed: 100, first: 101},
+		{testCaseName: "MINID", trim: func(s *types.Stream) int { return s.TrimMinID(types.StreamID{Ms: 120}, false, 0) }, removed: 119, first: 120},
+		{testCaseName: "Approximate MINID", trim: func(s *types.Stream) int { return s.TrimMinID(types.StreamID{Ms: 120}, true, 0) }, removed: 100, first: 101},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			s := newTestStream(250)
+			if removed := tc.trim(s); removed != tc.removed {
+				t.Fatalf("Expected %d entries removed, got %d", tc.removed, removed)
+			}
+			if s.Len() != 250-tc.removed {
+				t.Fatalf("Expected %d entries, got %d", 250-tc.removed, s.Len())
+			}
+			if first, _ := s.First(); first.ID.Ms != tc.first {
+				t.Fatalf("Expected the first entry to be %d, got %d", tc.first, first.ID.Ms)
+			}
+			if s.LastID().Ms != 250 {
+				t.Fatalf("Expected the last ID to be kept, got %v", s.LastID())
+			}
+		})
+	}
+}
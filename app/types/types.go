@@ -4,31 +4,79 @@ import (
 	"sync"
 )
 
+// DBItem is a value of any type stored in the keyspace. Strings are stored in Value,
+// while values of every other type are stored in Object.
 type DBItem struct {
-	Value  string
-	Expiry int64
+	Value  []byte // Binary safe value, if the item is a string
+	Object Object // Value of any other type, nil if the item is a string
+	Expiry int64  // Unix time in milliseconds at which the item expires, -1 if it never does
 }
 
-type StreamEntry struct {
-	ID  string
-	KVs map[string]string
+// Object is implemented by every value type other than strings
+type Object interface {
+	Type() string // Name of the type, as reported by TYPE
+	Copy() Object // Deep copy of the value
+}
+
+// Type returns the name of the type of the item, as reported by TYPE
+func (item DBItem) Type() string {
+	if item.Object == nil {
+		return "string"
+	}
+	return item.Object.Type()
+}
+
+// Copy returns a deep copy of the item, which shares no memory with the original
+func (item DBItem) Copy() DBItem {
+	if item.Object != nil {
+		return DBItem{Object: item.Object.Copy(), Expiry: item.Expiry}
+	}
+	return DBItem{Value: append([]byte{}, item.Value...), Expiry: item.Expiry}
+}
+
+// IsExpired reports whether the item has expired at the given Unix time in milliseconds
+func (item DBItem) IsExpired(now int64) bool {
+	return item.Expiry != -1 && now >= item.Expiry
 }
 
 type ServerState struct {
-	DB      map[string]DBItem
-	Streams map[string][]StreamEntry
-	DBMutex sync.Mutex
-	Port    int
+	DB           *Dict[DBItem]       // Keyspace, holding values of every type
+	Expires      map[string]struct{} // Keys that may have an expiry, sampled by the active expire cycle
+	FieldExpires map[string]struct{} // Hash keys that may have fields with an expiry, sampled likewise
+	DBMutex      sync.Mutex
+	Port         int
 
 	DBDir      string // Directory in which to store the database files
 	DBFilename string // Name of the database file
 
-	Role             string    // master | slave
-	MasterReplID     string    // Replication ID of the master (own replication ID if master)
-	MasterReplOffset int       // Offset of the master (0 if master)
-	MasterHost       string    // Host of the master (empty if master)
-	MasterPort       string    // Port of the master (empty if master)
-	Replicas         []Replica // Connections to replicas (empty if slave)
-	AckOffset        int       // Offset of the last acknowledged replication message (only for slaves)
-	BytesSent        int       // Number of bytes sent to replicas (only for masters)
-}
\ No newline at end of file
+	Role             string     // master | slave
+	MasterReplID     string     // Replication ID of the master (own replication ID if master)
+	MasterReplOffset int        // Offset of the master (0 if master)
+	MasterHost       string     // Host of the master (empty if master)
+	MasterPort       string     // Port of the master (empty if master)
+	Replicas         []*Replica // Connections to replicas (empty if slave)
+	AckOffset        int        // Offset of the last acknowledged replication message (only for slaves)
+	BytesSent        int        // Number of bytes sent to replicas (only for masters)
+
+	Blocked       map[string][]*BlockedClient // Clients blocked on each key, in the order they blocked
+	readyKeys     map[string]struct{}         // Keys signalled as ready since blocked clients were last served
+	readyKeyOrder []string                    // readyKeys, in the order they were signalled
+}
+// SetItem stores an item in the keyspace. It must be called with DBMutex held.
+func (s *ServerState) SetItem(key string, item DBItem) {
+	s.DB.Set(key, item)
+	if item.Expiry != -1 {
+		s.Expires[key] = struct{}{}
+	}
+	if hash, ok := item.Object.(*Hash); ok && hash.HasExpiringFields() {
+		s.FieldExpires[key] = struct{}{}
+	}
+	s.SignalKeyReady(key)
+}
+
+// DeleteItem removes an item from the keyspace. It must be called with DBMutex held.
+func (s *ServerState) DeleteItem(key string) {
+	s.DB.Delete(key)
+	delete(s.Expires, key)
+	delete(s.FieldExpires, key)
+}
@@ -0,0 +1,458 @@
+package types
+
+import (
+	"math/rand"
+)
+
+// The skiplist parameters are the ones Redis uses: a node has a level above n with
+// probability zskiplistP^n, capped at zskiplistMaxLevel levels
+const (
+	zskiplistMaxLevel = 32
+	zskiplistP        = 0.25
+)
+
+// ZEntry is a member of a sorted set together with its score
+type ZEntry struct {
+	Member string
+	Score  float64
+}
+
+type zskiplistLevel struct {
+	forward *zskiplistNode
+	span    int // Number of nodes skipped by following forward, used to compute ranks
+}
+
+type zskiplistNode struct {
+	ZEntry
+	backward *zskiplistNode
+	level    []zskiplistLevel
+}
+
+// zskiplist keeps the entries of a sorted set ordered by score, then by member. Like
+// the one in Redis, every level records how many nodes its forward pointer skips,
+// so that the rank of a node and the node at a rank are found in O(log n).
+type zskiplist struct {
+	header *zskiplistNode
+	tail   *zskiplistNode
+	length int
+	level  int
+}
+
+func newZskiplist() *zskiplist {
+	return &zskiplist{
+		header: &zskiplistNode{level: make([]zskiplistLevel, zskiplistMaxLevel)},
+		level:  1,
+	}
+}
+
+func zslRandomLevel() int {
+	level := 1
+	for level < zskiplistMaxLevel && rand.Float64() < zskiplistP {
+		level++
+	}
+	return level
+}
+
+// before reports whether n sorts before the entry with the given score and member
+func (n *zskiplistNode) before(score float64, member string) bool {
+	return n.Score < score || (n.Score == score && n.Member < member)
+}
+
+// insert adds an entry that must not already be in the list
+func (zsl *zskiplist) insert(score float64, member string) {
+	var update [zskiplistMaxLevel]*zskiplistNode
+	var rank [zskiplistMaxLevel]int
+
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		if i < zsl.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for x.level[i].forward != nil && x.level[i].forward.before(score, member) {
+			rank[i] += x.level[i].span
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	level := zslRandomLevel()
+	if level > zsl.level {
+		for i := zsl.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = zsl.header
+			update[i].level[i].span = zsl.length
+		}
+		zsl.level = level
+	}
+
+	x = &zskiplistNode{ZEntry: ZEntry{Member: member, Score: score}, level: make([]zskiplistLevel, level)}
+	for i := 0; i < level; i++ {
+		x.level[i].forward = update[i].level[i].forward
+		update[i].level[i].forward = x
+		x.level[i].span = update[i].level[i].span - (rank[0] - rank[i])
+		update[i].level[i].span = rank[0] - rank[i] + 1
+	}
+	for i := level; i < zsl.level; i++ {
+		update[i].level[i].span++
+	}
+
+	if update[0] != zsl.header {
+		x.backward = update[0]
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x
+	} else {
+		zsl.tail = x
+	}
+	zsl.length++
+}
+
+// delete removes the entry with the given score and member and reports whether it
+// was found
+func (zsl *zskiplist) delete(score float64, member string) bool {
+	var update [zskiplistMaxLevel]*zskiplistNode
+
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && x.level[i].forward.before(score, member) {
+			x = x.level[i].forward
+		}
+		update[i] = x
+	}
+
+	x = x.level[0].forward
+	if x == nil || x.Score != score || x.Member != member {
+		return false
+	}
+
+	for i := 0; i < zsl.level; i++ {
+		if update[i].level[i].forward == x {
+			update[i].level[i].span += x.level[i].span - 1
+			update[i].level[i].forward = x.level[i].forward
+		} else {
+			update[i].level[i].span--
+		}
+	}
+	if x.level[0].forward != nil {
+		x.level[0].forward.backward = x.backward
+	} else {
+		zsl.tail = x.backward
+	}
+	for zsl.level > 1 && zsl.header.level[zsl.level-1].forward == nil {
+		zsl.level--
+	}
+	zsl.length--
+	return true
+}
+
+// rank returns the 1-based rank of the entry with the given score and member, or
+// 0 if it is not in the list
+func (zsl *zskiplist) rank(score float64, member string) int {
+	rank := 0
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && (x.level[i].forward.before(score, member) || x.level[i].forward.Member == member) {
+			rank += x.level[i].span
+			x = x.level[i].forward
+		}
+		if x != zsl.header && x.Member == member {
+			return rank
+		}
+	}
+	return 0
+}
+
+// byRank returns the node at the given 1-based rank, or nil if it is out of range
+func (zsl *zskiplist) byRank(rank int) *zskiplistNode {
+	traversed := 0
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && traversed+x.level[i].span <= rank {
+			traversed += x.level[i].span
+			x = x.level[i].forward
+		}
+		if traversed == rank {
+			return x
+		}
+	}
+	return nil
+}
+
+// first returns the first node for which below is false, or nil if there is none.
+// below must be true for a prefix of the list and false for the rest of it.
+func (zsl *zskiplist) first(below func(n *zskiplistNode) bool) *zskiplistNode {
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && below(x.level[i].forward) {
+			x = x.level[i].forward
+		}
+	}
+	return x.level[0].forward
+}
+
+// last returns the last node for which within is true, or nil if there is none.
+// within must be true for a prefix of the list and false for the rest of it.
+func (zsl *zskiplist) last(within func(n *zskiplistNode) bool) *zskiplistNode {
+	x := zsl.header
+	for i := zsl.level - 1; i >= 0; i-- {
+		for x.level[i].forward != nil && within(x.level[i].forward) {
+			x = x.level[i].forward
+		}
+	}
+	if x == zsl.header {
+		return nil
+	}
+	return x
+}
+
+// ScoreRange is an interval of scores, as given to ZRANGEBYSCORE or ZCOUNT
+type ScoreRange struct {
+	Min, Max     float64
+	MinExclusive bool
+	MaxExclusive bool
+}
+
+func (r ScoreRange) aboveMin(score float64) bool {
+	if r.MinExclusive {
+		return score > r.Min
+	}
+	return score >= r.Min
+}
+
+func (r ScoreRange) belowMax(score float64) bool {
+	if r.MaxExclusive {
+		return score < r.Max
+	}
+	return score <= r.Max
+}
+
+// LexBound is one end of a LexRange. Inf is -1 for "-", the smallest possible
+// member, 1 for "+", the largest one, and 0 when the bound is Value.
+type LexBound struct {
+	Value     string
+	Exclusive bool
+	Inf       int
+}
+
+// LexRange is an interval of members, as given to ZRANGEBYLEX or ZLEXCOUNT. It is
+// only meaningful when all members have the same score.
+type LexRange struct {
+	Min, Max LexBound
+}
+
+func (r LexRange) aboveMin(member string) bool {
+	switch {
+	case r.Min.Inf != 0:
+		return r.Min.Inf < 0
+	case r.Min.Exclusive:
+		return member > r.Min.Value
+	default:
+		return member >= r.Min.Value
+	}
+}
+
+func (r LexRange) belowMax(member string) bool {
+	switch {
+	case r.Max.Inf != 0:
+		return r.Max.Inf > 0
+	case r.Max.Exclusive:
+		return member < r.Max.Value
+	default:
+		return member <= r.Max.Value
+	}
+}
+
+// ZSet is the value stored in the keyspace for sorted set keys. As in Redis, the
+// scores are kept both in a Dict, to look them up by member, and in a skiplist,
+// for everything that depends on the order.
+type ZSet struct {
+	dict *Dict[float64]
+	zsl  *zskiplist
+}
+
+func NewZSet() *ZSet {
+	return &ZSet{dict: NewDict[float64](), zsl: newZskiplist()}
+}
+
+func (z *ZSet) Type() string {
+	return "zset"
+}
+
+func (z *ZSet) Copy() Object {
+	c := NewZSet()
+	z.Range(func(e ZEntry) bool {
+		c.Add(e.Member, e.Score)
+		return true
+	})
+	return c
+}
+
+// Encoding returns the name Redis gives the encoding in use, which is always
+// skiplist
+func (z *ZSet) Encoding() string {
+	return "skiplist"
+}
+
+func (z *ZSet) Len() int {
+	return z.zsl.length
+}
+
+func (z *ZSet) Score(member string) (float64, bool) {
+	return z.dict.Get(member)
+}
+
+// Add sets the score of member and reports whether it was added rather than updated
+func (z *ZSet) Add(member string, score float64) bool {
+	if cur, ok := z.dict.Get(member); ok {
+		if cur != score {
+			z.zsl.delete(cur, member)
+			z.zsl.insert(score, member)
+			z.dict.Set(member, score)
+		}
+		return false
+	}
+	z.zsl.insert(score, member)
+	z.dict.Set(member, score)
+	return true
+}
+
+// Remove removes member and reports whether it was in the set
+func (z *ZSet) Remove(member string) bool {
+	score, ok := z.dict.Get(member)
+	if !ok {
+		return false
+	}
+	z.zsl.delete(score, member)
+	z.dict.Delete(member)
+	return true
+}
+
+// Rank returns the 0-based rank of member, counted from the highest score if
+// reverse is set. It reports whether member is in the set.
+func (z *ZSet) Rank(member string, reverse bool) (int, bool) {
+	score, ok := z.dict.Get(member)
+	if !ok {
+		return 0, false
+	}
+	rank := z.zsl.rank(score, member)
+	if reverse {
+		return z.zsl.length - rank, true
+	}
+	return rank - 1, true
+}
+
+// Range calls fn for every entry in ascending order until it returns false. fn must
+// not modify the set.
+func (z *ZSet) Range(fn func(e ZEntry) bool) {
+	for x := z.zsl.header.level[0].forward; x != nil; x = x.level[0].forward {
+		if !fn(x.ZEntry) {
+			return
+		}
+	}
+}
+
+// collect returns the entries from start on, in the given direction, while within
+// holds, skipping the first offset of them. limit is the most entries returned, or
+// negative for no limit.
+func collect(start *zskiplistNode, reverse bool, offset, limit int, within func(n *zskiplistNode) bool) []ZEntry {
+	entries := []ZEntry{}
+	for x := start; x != nil && limit != 0 && within(x); {
+		if offset > 0 {
+			offset--
+		} else {
+			entries = append(entries, x.ZEntry)
+			limit--
+		}
+		if reverse {
+			x = x.backward
+		} else {
+			x = x.level[0].forward
+		}
+	}
+	return entries
+}
+
+// RangeByRank returns the entries with 0-based ranks from start to end inclusive,
+// which must be valid. Ranks are counted from the highest score if reverse is set.
+func (z *ZSet) RangeByRank(start, end int, reverse bool) []ZEntry {
+	rank := start + 1
+	if reverse {
+		rank = z.zsl.length - start
+	}
+	return collect(z.zsl.byRank(rank), reverse, 0, end-start+1, func(*zskiplistNode) bool { return true })
+}
+
+// RangeByScore returns the entries with a score in r, from the highest score if
+// reverse is set, skipping the first offset of them and returning at most limit
+// entries, or all of them if limit is negative
+func (z *ZSet) RangeByScore(r ScoreRange, reverse bool, offset, limit int) []ZEntry {
+	if reverse {
+		start := z.zsl.last(func(n *zskiplistNode) bool { return r.belowMax(n.Score) })
+		return collect(start, true, offset, limit, func(n *zskiplistNode) bool { return r.aboveMin(n.Score) })
+	}
+	start := z.zsl.first(func(n *zskiplistNode) bool { return !r.aboveMin(n.Score) })
+	return collect(start, false, offset, limit, func(n *zskiplistNode) bool { return r.belowMax(n.Score) })
+}
+
+// RangeByLex is like RangeByScore, for the members in r
+func (z *ZSet) RangeByLex(r LexRange, reverse bool, offset, limit int) []ZEntry {
+	if reverse {
+		start := z.zsl.last(func(n *zskiplistNode) bool { return r.belowMax(n.Member) })
+		return collect(start, true, offset, limit, func(n *zskiplistNode) bool { return r.aboveMin(n.Member) })
+	}
+	start := z.zsl.first(func(n *zskiplistNode) bool { return !r.aboveMin(n.Member) })
+	return collect(start, false, offset, limit, func(n *zskiplistNode) bool { return r.belowMax(n.Member) })
+}
+
+// count returns the number of nodes from the first one for which below is false to
+// the last one for which within is true, using the ranks of both ends
+func (z *ZSet) count(below, within func(n *zskiplistNode) bool) int {
+	first := z.zsl.first(below)
+	if first == nil || !within(first) {
+		return 0
+	}
+	last := z.zsl.last(within)
+	return z.zsl.rank(last.Score, last.Member) - z.zsl.rank(first.Score, first.Member) + 1
+}
+
+// CountByScore returns the number of entries with a score in r
+func (z *ZSet) CountByScore(r ScoreRange) int {
+	return z.count(
+		func(n *zskiplistNode) bool { return !r.aboveMin(n.Score) },
+		func(n *zskiplistNode) bool { return r.belowMax(n.Score) },
+	)
+}
+
+// CountByLex returns the number of members in r
+func (z *ZSet) CountByLex(r LexRange) int {
+	return z.count(
+		func(n *zskiplistNode) bool { return !r.aboveMin(n.Member) },
+		func(n *zskiplistNode) bool { return r.belowMax(n.Member) },
+	)
+}
+
+// Pop removes and returns up to count entries with the lowest scores, or the
+// highest ones if max is set
+func (z *ZSet) Pop(count int, max bool) []ZEntry {
+	entries := []ZEntry{}
+	for len(entries) < count && z.zsl.length > 0 {
+		x := z.zsl.header.level[0].forward
+		if max {
+			x = z.zsl.tail
+		}
+		entries = append(entries, x.ZEntry)
+		z.Remove(x.Member)
+	}
+	return entries
+}
+
+// Scan walks the set with a cursor, like Dict.Scan
+func (z *ZSet) Scan(cursor uint64, fn func(member string, score float64)) uint64 {
+	return z.dict.Scan(cursor, fn)
+}
+
+// Random returns a random member of a non-empty set with its score
+func (z *ZSet) Random() (string, float64) {
+	member, score, _ := z.dict.Random()
+	return member, score
+}
@@ -0,0 +1,136 @@
+package types_test
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/app/types"
+)
+
+// newTestZSet returns a set with members m0 to m<n-1> scored 0 to n-1 in a random
+// insertion order, along with the entries in ascending order
+func newTestZSet(n int) (*types.ZSet, []types.ZEntry) {
+	entries := make([]types.ZEntry, n)
+	for i := range entries {
+		entries[i] = types.ZEntry{Member: "m" + strconv.Itoa(i), Score: float64(i)}
+	}
+	z := types.NewZSet()
+	for _, i := range rand.Perm(n) {
+		z.Add(entries[i].Member, entries[i].Score)
+	}
+	return z, entries
+}
+
+func TestZSetRank(t *testing.T) {
+	z, entries := newTestZSet(1000)
+
+	// Updating scores moves members through the skiplist
+	for i := 0; i < 1000; i += 3 {
+		z.Add(entries[i].Member, -1)
+		z.Add(entries[i].Member, entries[i].Score)
+	}
+	for i := 1; i < 1000; i += 7 {
+		z.Remove(entries[i].Member)
+	}
+	remaining := []types.ZEntry{}
+	for i, e := range entries {
+		if i%7 != 1 {
+			remaining = append(remaining, e)
+		}
+	}
+
+	if z.Len() != len(remaining) {
+		t.Fatalf("Expected %d entries, got %d", len(remaining), z.Len())
+	}
+	for i, e := range remaining {
+		if rank, ok := z.Rank(e.Member, false); !ok || rank != i {
+			t.Fatalf("Expected rank %d for %s, got %d", i, e.Member, rank)
+		}
+		if rank, _ := z.Rank(e.Member, true); rank != len(remaining)-1-i {
+			t.Fatalf("Expected reverse rank %d for %s, got %d", len(remaining)-1-i, e.Member, rank)
+		}
+	}
+	if _, ok := z.Rank("m1", false); ok {
+		t.Fatalf("Unexpected rank for a removed member")
+	}
+
+	res := z.RangeByRank(10, 19, false)
+	if !reflect.DeepEqual(res, remaining[10:20]) {
+		t.Fatalf("Expected %v, got %v", remaining[10:20], res)
+	}
+}
+
+func TestZSetRangeByScore(t *testing.T) {
+	z, entries := newTestZSet(100)
+
+	tests := []struct {
+		testCaseName string
+		r            types.ScoreRange
+		reverse      bool
+		offset       int
+		limit        int
+		expected     []types.ZEntry
+	}{
+		{testCaseName: "Inclusive", r: types.ScoreRange{Min: 10, Max: 20}, limit: -1, expected: entries[10:21]},
+		{testCaseName: "Exclusive", r: types.ScoreRange{Min: 10, Max: 20, MinExclusive: true, MaxExclusive: true}, limit: -1, expected: entries[11:20]},
+		{testCaseName: "Infinite", r: types.ScoreRange{Min: math.Inf(-1), Max: math.Inf(1)}, limit: -1, expected: entries},
+		{testCaseName: "Limit", r: types.ScoreRange{Min: 10, Max: 20}, offset: 2, limit: 3, expected: entries[12:15]},
+		{testCaseName: "Reverse", r: types.ScoreRange{Min: 10, Max: 20}, reverse: true, offset: 1, limit: 2, expected: []types.ZEntry{entries[19], entries[18]}},
+		{testCaseName: "Empty", r: types.ScoreRange{Min: 20, Max: 10}, limit: -1, expected: []types.ZEntry{}},
+		{testCaseName: "Out of range", r: types.ScoreRange{Min: 200, Max: 300}, reverse: true, limit: -1, expected: []types.ZEntry{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			res := z.RangeByScore(tc.r, tc.reverse, tc.offset, tc.limit)
+			if !reflect.DeepEqual(res, tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, res)
+			}
+			if !tc.reverse && tc.limit < 0 && z.CountByScore(tc.r) != len(tc.expected) {
+				t.Fatalf("Expected a count of %d, got %d", len(tc.expected), z.CountByScore(tc.r))
+			}
+		})
+	}
+}
+
+func TestZSetRangeByLex(t *testing.T) {
+	z := types.NewZSet()
+	members := []string{"a", "b", "c", "d", "e"}
+	for _, member := range members {
+		z.Add(member, 0)
+	}
+
+	res := z.RangeByLex(types.LexRange{Min: types.LexBound{Value: "b"}, Max: types.LexBound{Value: "d", Exclusive: true}}, false, 0, -1)
+	if len(res) != 2 || res[0].Member != "b" || res[1].Member != "c" {
+		t.Fatalf("Expected [b c], got %v", res)
+	}
+	res = z.RangeByLex(types.LexRange{Min: types.LexBound{Inf: -1}, Max: types.LexBound{Inf: 1}}, true, 0, -1)
+	got := []string{}
+	for _, e := range res {
+		got = append(got, e.Member)
+	}
+	if !sort.IsSorted(sort.Reverse(sort.StringSlice(got))) || len(got) != len(members) {
+		t.Fatalf("Expected all members in descending order, got %v", got)
+	}
+	if n := z.CountByLex(types.LexRange{Min: types.LexBound{Value: "b", Exclusive: true}, Max: types.LexBound{Inf: 1}}); n != 3 {
+		t.Fatalf("Expected a count of 3, got %d", n)
+	}
+}
+
+func TestZSetPop(t *testing.T) {
+	z, entries := newTestZSet(10)
+
+	if res := z.Pop(2, false); !reflect.DeepEqual(res, entries[:2]) {
+		t.Fatalf("Expected %v, got %v", entries[:2], res)
+	}
+	if res := z.Pop(1, true); !reflect.DeepEqual(res, entries[9:]) {
+		t.Fatalf("Expected %v, got %v", entries[9:], res)
+	}
+	if res := z.Pop(100, false); !reflect.DeepEqual(res, entries[2:9]) || z.Len() != 0 {
+		t.Fatalf("Expected %v, got %v", entries[2:9], res)
+	}
+}
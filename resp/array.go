@@ -1,54 +1,58 @@
 package resp
 
-import (
-	"bytes"
-	"fmt"
-)
-
-type array struct{}
+import "fmt"
+
+// arrayDecoder decodes an array of bulk strings, such as a command. Each string
+// is copied out of the data, so that a stored value does not keep the whole read
+// buffer alive. When the data ends before the whole array, the strings decoded so
+// far are kept, so that the next attempt, given the same data followed by more,
+// resumes after them rather than decoding and copying them again.
+type arrayDecoder struct {
+	started bool
+	n       int      // Number of strings in the array
+	arr     [][]byte // Strings decoded so far
+	off     int      // Bytes of the data decoded so far
+}
 
-func (array) Encode(data []string) ([]byte, error) {
-	var buf bytes.Buffer
-	buf.WriteString(fmt.Sprintf("*%d\r\n", len(data)))
+func (d *arrayDecoder) decode(b []byte) ([][]byte, []byte, error) {
+	rest := b[d.off:]
+	if !d.started {
+		if len(rest) == 0 {
+			return nil, rest, ErrIncomplete
+		}
+		if rest[0] != '*' {
+			return nil, rest, fmt.Errorf("invalid format for array: expected the first byte to be '*', got '%q'", rest[0])
+		}
 
-	bulkString := bulkString{}
-	for _, str := range data {
-		strBytes, err := bulkString.Encode(str)
+		n, r, err := parseLen(rest[1:])
 		if err != nil {
-			return nil, err
+			return nil, r, fmt.Errorf("invalid format for array: %w", err)
+		}
+		r, err = parseCRLF(r)
+		if err != nil {
+			return nil, r, fmt.Errorf("invalid format for array: %w", err)
+		}
+		if n < 0 {
+			return nil, r, nil
 		}
-		buf.Write(strBytes)
-	}
-
-	return buf.Bytes(), nil
-}
-
-func (array) Decode(b []byte) ([]string, []byte, error) {
-	if b[0] != '*' {
-		return nil, b, fmt.Errorf("invalid format for array: expected the first byte to be '*', got '%q'", b[0])
-	}
-
-	n, b, err := parseLen(b[1:])
-	if err != nil {
-		return nil, b, fmt.Errorf("invalid format for array: %v", err)
-	}
 
-	b, err = parseCRLF(b)
-	if err != nil {
-		return nil, b, fmt.Errorf("invalid format for array: %v", err)
+		d.started = true
+		d.n = n
+		d.arr = make([][]byte, 0, min(n, 1024))
+		d.off = len(b) - len(r)
+		rest = r
 	}
 
-	arr := make([]string, n)
 	bulkString := bulkString{}
-
-	str := ""
-	for i := 0; i < n; i++ {
-		str, b, err = bulkString.Decode(b)
+	for len(d.arr) < d.n {
+		str, r, err := bulkString.Decode(rest)
 		if err != nil {
-			return nil, b, fmt.Errorf("invalid format for array: %v", err)
+			return nil, r, fmt.Errorf("invalid format for array: %w", err)
 		}
-		arr[i] = str
+		d.arr = append(d.arr, str)
+		d.off = len(b) - len(r)
+		rest = r
 	}
 
-	return arr, b, nil
-}
\ No newline at end of file
+	return d.arr, rest, nil
+}
@@ -13,43 +13,32 @@ func TestArrayEncode(t *testing.T) {
 		testCaseName string
 		input        []string
 		expected     []byte
-		expectError  bool
 	}{
 		{
 			testCaseName: "Empty Array",
 			input:        []string{},
 			expected:     []byte("*0\r\n"),
-			expectError:  false,
 		},
 		{
 			testCaseName: "Array with 1 element",
 			input:        []string{"PING"},
 			expected:     []byte("*1\r\n$4\r\nPING\r\n"),
-			expectError:  false,
 		},
 		{
 			testCaseName: "Array with 2 elements",
 			input:        []string{"PING", "PONG"},
 			expected:     []byte("*2\r\n$4\r\nPING\r\n$4\r\nPONG\r\n"),
-			expectError:  false,
+		},
+		{
+			testCaseName: "Array with an empty element",
+			input:        []string{"SET", "k", ""},
+			expected:     []byte("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$0\r\n\r\n"),
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.testCaseName, func(t *testing.T) {
-			handlers := resp.RESPHandler{}
-			res, err := handlers.Array.Encode(tc.input)
-
-			if tc.expectError {
-				if err == nil {
-					t.Errorf("Expected error, got nil")
-				}
-				return
-			} else {
-				if err != nil {
-					t.Errorf("Unexpected error: %v", err)
-				}
-			}
+			res := resp.BulkStrings(tc.input...).EncodeRESP2()
 
 			if !bytes.Equal(res, tc.expected) {
 				t.Errorf("Expected %v, got %v", tc.expected, res)
@@ -63,25 +52,25 @@ func TestDecode(t *testing.T) {
 	tests := []struct {
 		testCaseName string
 		input        []byte
-		expected     []string
+		expected     [][]byte
 		expectError  bool
 	}{
 		{
 			testCaseName: "Empty Array",
 			input:        []byte("*0\r\n"),
-			expected:     []string{},
+			expected:     [][]byte{},
 			expectError:  false,
 		},
 		{
 			testCaseName: "Array with 1 element",
 			input:        []byte("*1\r\n$4\r\nPING\r\n"),
-			expected:     []string{"PING"},
+			expected:     [][]byte{[]byte("PING")},
 			expectError:  false,
 		},
 		{
 			testCaseName: "Array with 2 elements",
 			input:        []byte("*2\r\n$4\r\nPING\r\n$4\r\nPONG\r\n"),
-			expected:     []string{"PING", "PONG"},
+			expected:     [][]byte{[]byte("PING"), []byte("PONG")},
 			expectError:  false,
 		},
 		{
@@ -94,8 +83,7 @@ func TestDecode(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.testCaseName, func(t *testing.T) {
-			handler := resp.RESPHandler{}
-			res, _, err := handler.Array.Decode(tc.input)
+			value, _, err := resp.DecodeValue(tc.input)
 
 			if tc.expectError {
 				if err == nil {
@@ -109,6 +97,10 @@ func TestDecode(t *testing.T) {
 				}
 			}
 
+			res := [][]byte{}
+			for _, elem := range value.Elems {
+				res = append(res, elem.Bulk)
+			}
 			if !reflect.DeepEqual(res, tc.expected) {
 				t.Errorf("Expected %v, got %v", tc.expected, res)
 			}
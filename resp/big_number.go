@@ -0,0 +1,29 @@
+package resp
+
+import (
+	"fmt"
+	"math/big"
+)
+
+type bigNumber struct{}
+
+func (bigNumber) Decode(b []byte) (*big.Int, []byte, error) {
+	if len(b) == 0 {
+		return nil, b, ErrIncomplete
+	}
+	if b[0] != '(' {
+		return nil, b, fmt.Errorf("invalid format for big number: expected the first byte to be '(', got '%q'", b[0])
+	}
+
+	line, b, err := parseLine(b[1:])
+	if err != nil {
+		return nil, b, fmt.Errorf("invalid format for big number: %w", err)
+	}
+
+	n, ok := new(big.Int).SetString(line, 10)
+	if !ok {
+		return nil, b, fmt.Errorf("invalid format for big number: cannot parse %q", line)
+	}
+
+	return n, b, nil
+}
@@ -0,0 +1,32 @@
+package resp
+
+import "fmt"
+
+type boolean struct{}
+
+func (boolean) Encode(v bool) ([]byte, error) {
+	if v {
+		return []byte("#t\r\n"), nil
+	}
+	return []byte("#f\r\n"), nil
+}
+
+func (boolean) Decode(b []byte) (bool, []byte, error) {
+	if len(b) < 2 {
+		return false, b, ErrIncomplete
+	}
+	if b[0] != '#' {
+		return false, b, fmt.Errorf("invalid format for boolean: expected the first byte to be '#', got '%q'", b[0])
+	}
+	if b[1] != 't' && b[1] != 'f' {
+		return false, b, fmt.Errorf("invalid format for boolean: expected 't' or 'f', got '%q'", b[1])
+	}
+
+	v := b[1] == 't'
+	b, err := parseCRLF(b[2:])
+	if err != nil {
+		return false, b, fmt.Errorf("invalid format for boolean: %w", err)
+	}
+
+	return v, b, nil
+}
@@ -6,45 +6,67 @@ import (
 	"fmt"
 )
 
+// maxBulkLen mirrors Redis' default proto-max-bulk-len of 512MB
+const maxBulkLen = 512 * 1024 * 1024
+
 type bulkString struct{}
 
-func (bulkString) Encode(s string) ([]byte, error) {
-	if s == "" {
-		return []byte("$-1\r\n"), nil
+// Decode decodes a bulk string. The null bulk string decodes to a nil slice, while
+// the empty bulk string decodes to an empty, non-nil slice.
+func (bulkString) Decode(data []byte) ([]byte, []byte, error) {
+	if len(data) == 0 {
+		return nil, data, ErrIncomplete
 	}
-	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)), nil
-}
 
-func (bulkString) Decode(data []byte) (string, []byte, error) {
-	if bytes.Equal(data, []byte("$-1\r\n")) {
-		return "",data, nil
+	if bytes.HasPrefix(data, []byte("$-1\r\n")) {
+		return nil, data[5:], nil
+	}
+	if bytes.HasPrefix([]byte("$-1\r\n"), data) {
+		return nil, data, ErrIncomplete
 	}
 
 	if !bytes.HasPrefix(data, []byte("$")) {
-		return "",data, errors.New("invalid format: does not start with '$'")
+		return nil, data, errors.New("invalid format: does not start with '$'")
 	}
 
-	n, data, err := parseLen(data[1:])
+	b, data, err := parseBlob(data[1:])
 	if err != nil {
-		return "", data, fmt.Errorf("invalid format for bulk string: %v", err)
+		return nil, data, fmt.Errorf("invalid format for bulk string: %w", err)
+	}
+
+	return b, data, nil
+}
+
+// Parses a length prefixed payload, <len>\r\n<bytes>\r\n, as shared by bulk strings and
+// verbatim strings, and returns the payload and the remaining byte slice
+func parseBlob(data []byte) ([]byte, []byte, error) {
+	n, data, err := parseLen(data)
+	if err != nil {
+		return nil, data, err
+	}
+	if n < 0 || n > maxBulkLen {
+		return nil, data, fmt.Errorf("invalid length %d", n)
 	}
 
 	data, err = parseCRLF(data)
 	if err != nil {
-		return "", data, fmt.Errorf("invalid format for bulk string: %v", err)
+		return nil, data, err
 	}
 
 	if len(data) < n+2 {
-		return "", data, fmt.Errorf("invalid format for bulk string: expected length of string to be atleast %d, got %d", n+2, len(data))
+		if len(data) > n && data[n] != '\r' {
+			return nil, data, fmt.Errorf("expected \\r\\n after %d bytes, got %q", n, data[n:])
+		}
+		return nil, data, fmt.Errorf("expected length of string to be atleast %d, got %d: %w", n+2, len(data), ErrIncomplete)
 	}
 
-	str := string(data[:n])
-	data = data[n:]
-
-	data, err = parseCRLF(data)
+	// Copy the payload, so that it does not keep the whole read buffer alive
+	b := make([]byte, n)
+	copy(b, data[:n])
+	data, err = parseCRLF(data[n:])
 	if err != nil {
-		return "", data, fmt.Errorf("invalid format for bulk string: %v", err)
+		return nil, data, err
 	}
 
-	return str, data, nil
+	return b, data, nil
 }
@@ -1,6 +1,7 @@
 package resp_test
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 
@@ -10,57 +11,44 @@ import (
 func TestBulkStringEncode(t *testing.T) {
 	tests := []struct {
 		testCaseName string
-		input        string
+		input        []byte
 		expected     []byte
-		expectError  bool
 	}{
 		{
 			testCaseName: "Empty string",
-			input:        "",
-			expected:     []byte("$-1\r\n"),
-			expectError:  false,
+			input:        []byte(""),
+			expected:     []byte("$0\r\n\r\n"),
 		},
 		{
 			testCaseName: "Non-empty string",
-			input:        "Hello",
+			input:        []byte("Hello"),
 			expected:     []byte("$5\r\nHello\r\n"),
-			expectError:  false,
 		},
 		{
 			testCaseName: "String with special characters",
-			input:        "!@#$%^&*()",
+			input:        []byte("!@#$%^&*()"),
 			expected:     []byte("$10\r\n!@#$%^&*()\r\n"),
-			expectError:  false,
 		},
 		{
 			testCaseName: "Long string",
-			input:        fmt.Sprintf("%01024d", 1), // Generates a string of 1024 '1's
+			input:        []byte(fmt.Sprintf("%01024d", 1)), // Generates a string of 1024 '1's
 			expected:     []byte("$1024\r\n" + fmt.Sprintf("%01024d", 1) + "\r\n"),
-			expectError:  false,
 		},
 		{
 			testCaseName: "Unicode characters",
-			input:        "こんにちは",                    // "Hello" in Japanese
+			input:        []byte("こんにちは"),            // "Hello" in Japanese
 			expected:     []byte("$15\r\nこんにちは\r\n"), // Length is byte length, not character count
-			expectError:  false,
+		},
+		{
+			testCaseName: "Binary data",
+			input:        []byte("\x00\xff\r\n\x01"),
+			expected:     []byte("$5\r\n\x00\xff\r\n\x01\r\n"),
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.testCaseName, func(t *testing.T) {
-			handler := resp.RESPHandler{}
-			res, err := handler.BulkString.Encode(tc.input)
-
-			if tc.expectError {
-				if err == nil {
-					t.Errorf("Expected error, got nil")
-				}
-				return
-			} else {
-				if err != nil {
-					t.Errorf("Expected nil, got %v", err)
-				}
-			}
+			res := resp.BulkBytes(tc.input).EncodeRESP2()
 
 			if string(res) != string(tc.expected) {
 				t.Errorf("Expected %v, got %v", tc.expected, res)
@@ -73,57 +61,69 @@ func TestBulkStringDecode(t *testing.T) {
 	tests := []struct {
 		testCaseName string
 		input        []byte
-		expected     string
+		expected     []byte
 		expectError  bool
 	}{
 		{
 			testCaseName: "Null bulk string",
 			input:        []byte("$-1\r\n"),
-			expected:     "",
+			expected:     nil,
 			expectError:  false,
 		},
 		{
 			testCaseName: "Valid bulk string",
 			input:        []byte("$5\r\nHello\r\n"),
-			expected:     "Hello",
+			expected:     []byte("Hello"),
 			expectError:  false,
 		},
 		{
 			testCaseName: "Invalid format - no dollar sign",
 			input:        []byte("5\r\nHello\r\n"),
-			expected:     "",
+			expected:     nil,
 			expectError:  true,
 		},
 		{
 			testCaseName: "Invalid format - no CRLF",
 			input:        []byte("$5Hello"),
-			expected:     "",
+			expected:     nil,
 			expectError:  true,
 		},
 		{
 			testCaseName: "Invalid length - not a number",
 			input:        []byte("$x\r\nHello\r\n"),
-			expected:     "",
+			expected:     nil,
 			expectError:  true,
 		},
 		{
 			testCaseName: "Content length does not match specified length",
 			input:        []byte("$5\r\nHell\r\n"),
-			expected:     "",
+			expected:     nil,
 			expectError:  true,
 		},
 		{
 			testCaseName: "Unicode characters",
 			input:        []byte("$15\r\nこんにちは\r\n"),
-			expected:     "こんにちは",
+			expected:     []byte("こんにちは"),
+			expectError:  false,
+		},
+		{
+			testCaseName: "Empty bulk string",
+			input:        []byte("$0\r\n\r\n"),
+			expected:     []byte{},
+			expectError:  false,
+		},
+		{
+			testCaseName: "Binary data",
+			input:        []byte("$5\r\n\x00\xff\r\n\x01\r\n"),
+			expected:     []byte("\x00\xff\r\n\x01"),
 			expectError:  false,
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.testCaseName, func(t *testing.T) {
-			handler := resp.RESPHandler{}
-			res, _, err := handler.BulkString.Decode(tc.input)
+			value, _, err := resp.DecodeValue(tc.input)
+			res := value.Bulk
 
 			if tc.expectError {
 				if err == nil {
@@ -132,8 +132,8 @@ func TestBulkStringDecode(t *testing.T) {
 			} else {
 				if err != nil {
 					t.Errorf("Test '%s' failed: expected no error, got %v", tc.testCaseName, err)
-				} else if res != tc.expected {
-					t.Errorf("Test '%s' failed: expected %s, got %s", tc.testCaseName, tc.expected, res)
+				} else if !bytes.Equal(res, tc.expected) || (res == nil) != (tc.expected == nil) {
+					t.Errorf("Test '%s' failed: expected %q, got %q", tc.testCaseName, tc.expected, res)
 				}
 			}
 		})
@@ -0,0 +1,52 @@
+package resp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+type double struct{}
+
+func (double) Encode(f float64) ([]byte, error) {
+	switch {
+	case math.IsInf(f, 1):
+		return []byte(",inf\r\n"), nil
+	case math.IsInf(f, -1):
+		return []byte(",-inf\r\n"), nil
+	case math.IsNaN(f):
+		return []byte(",nan\r\n"), nil
+	}
+	return []byte("," + FormatDouble(f) + "\r\n"), nil
+}
+
+// FormatDouble returns the shortest representation that parses back to the same
+// value, like Redis 7 does. Exponent notation is only used for very large or very
+// small magnitudes.
+func FormatDouble(f float64) string {
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func (double) Decode(b []byte) (float64, []byte, error) {
+	if len(b) == 0 {
+		return 0, b, ErrIncomplete
+	}
+	if b[0] != ',' {
+		return 0, b, fmt.Errorf("invalid format for double: expected the first byte to be ',', got '%q'", b[0])
+	}
+
+	line, b, err := parseLine(b[1:])
+	if err != nil {
+		return 0, b, fmt.Errorf("invalid format for double: %w", err)
+	}
+
+	f, err := strconv.ParseFloat(line, 64)
+	if err != nil {
+		return 0, b, fmt.Errorf("invalid format for double: %v", err)
+	}
+
+	return f, b, nil
+}
@@ -16,15 +16,12 @@ func TestEncodeError(t *testing.T) {
 		{"Simple error", "ERR simple error", []byte("-ERR simple error\r\n")},
 		{"Empty error", "", []byte("-\r\n")},
 		{"Complex error", "ERR complex error with details", []byte("-ERR complex error with details\r\n")},
+		{"Error with line breaks", "ERR bad\r\nline", []byte("-ERR bad  line\r\n")},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			handler := resp.RESPHandler{}
-			result, err := handler.Error.Encode(tc.errMsg)
-			if err != nil {
-				t.Errorf("EncodeError(%q) returned an error: %v", tc.errMsg, err)
-			}
+			result := resp.Error(tc.errMsg).EncodeRESP2()
 			if !reflect.DeepEqual(result, tc.expected) {
 				t.Errorf("EncodeError(%q) = %v, want %v", tc.errMsg, result, tc.expected)
 			}
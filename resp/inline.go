@@ -0,0 +1,143 @@
+package resp
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+// maxInlineLen mirrors the limit Redis puts on inline requests
+const maxInlineLen = 64 * 1024
+
+// inline decodes commands sent as a single line of space separated arguments, as
+// typed into telnet or netcat, instead of as a RESP array
+type inline struct{}
+
+// Decode parses one line, terminated by "\n" or "\r\n", into its arguments. An
+// empty line decodes to an empty command.
+func (inline) Decode(b []byte) ([][]byte, []byte, error) {
+	end := bytes.IndexByte(b, '\n')
+	if end == -1 {
+		if len(b) > maxInlineLen {
+			return nil, b, errors.New("too big inline request")
+		}
+		return nil, b, ErrIncomplete
+	}
+
+	line := b[:end]
+	line = bytes.TrimSuffix(line, []byte("\r"))
+
+	args, err := SplitArgs(string(line))
+	if err != nil {
+		return nil, b, err
+	}
+
+	arr := make([][]byte, len(args))
+	for i, arg := range args {
+		arr[i] = []byte(arg)
+	}
+	return arr, b[end+1:], nil
+}
+
+// SplitArgs splits a line into arguments following the quoting rules of redis-cli:
+// arguments are separated by spaces, double quoted arguments may contain escape
+// sequences such as \n or \x00, and single quoted arguments are taken literally
+// apart from \'.
+func SplitArgs(line string) ([]string, error) {
+	args := []string{}
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return args, nil
+		}
+
+		var arg []byte
+		inDoubleQuotes, inSingleQuotes := false, false
+		done := false
+		for !done {
+			if i == len(line) {
+				if inDoubleQuotes || inSingleQuotes {
+					return nil, errors.New("unbalanced quotes in request")
+				}
+				break
+			}
+
+			c := line[i]
+			switch {
+			case inDoubleQuotes:
+				switch {
+				case c == '\\' && i+3 < len(line) && line[i+1] == 'x' && isHexDigit(line[i+2]) && isHexDigit(line[i+3]):
+					n, _ := strconv.ParseUint(line[i+2:i+4], 16, 8)
+					arg = append(arg, byte(n))
+					i += 3
+				case c == '\\' && i+1 < len(line):
+					i++
+					arg = append(arg, unescape(line[i]))
+				case c == '"':
+					// The closing quote must be followed by a space or the end of the line
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errors.New("unbalanced quotes in request")
+					}
+					done = true
+				default:
+					arg = append(arg, c)
+				}
+			case inSingleQuotes:
+				switch {
+				case c == '\\' && i+1 < len(line) && line[i+1] == '\'':
+					i++
+					arg = append(arg, '\'')
+				case c == '\'':
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, errors.New("unbalanced quotes in request")
+					}
+					done = true
+				default:
+					arg = append(arg, c)
+				}
+			default:
+				switch {
+				case isSpace(c):
+					done = true
+				case c == '"':
+					inDoubleQuotes = true
+				case c == '\'':
+					inSingleQuotes = true
+				default:
+					arg = append(arg, c)
+				}
+			}
+			i++
+		}
+
+		args = append(args, string(arg))
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	default:
+		return c
+	}
+}
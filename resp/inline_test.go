@@ -0,0 +1,62 @@
+package resp_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		input        string
+		expected     []string
+		expectError  bool
+	}{
+		{"Single argument", "PING", []string{"PING"}, false},
+		{"Several spaces", "  SET   foo  bar ", []string{"SET", "foo", "bar"}, false},
+		{"Double quotes", `SET foo "hello world"`, []string{"SET", "foo", "hello world"}, false},
+		{"Escape sequences", `SET foo "a\nb\x41\"c"`, []string{"SET", "foo", "a\nbA\"c"}, false},
+		{"Single quotes", `SET foo 'it\'s "raw" \n'`, []string{"SET", "foo", `it's "raw" \n`}, false},
+		{"Empty quoted argument", `SET foo ""`, []string{"SET", "foo", ""}, false},
+		{"Empty line", "", []string{}, false},
+		{"Unbalanced quotes", `SET foo "bar`, nil, true},
+		{"Closing quote followed by text", `SET foo "bar"baz`, nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			res, err := resp.SplitArgs(tc.input)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(res, tc.expected) {
+				t.Errorf("Expected %q, got %q", tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestReaderInlineCommands(t *testing.T) {
+	reader := resp.NewReader(bytes.NewReader([]byte("PING\r\nSET foo bar\n*2\r\n$3\r\nGET\r\n$3\r\nfoo\r\n\r\n")))
+
+	expected := [][][]byte{{[]byte("PING")}, {[]byte("SET"), []byte("foo"), []byte("bar")}, {[]byte("GET"), []byte("foo")}, {}}
+	for _, exp := range expected {
+		res, _, err := reader.ReadCommand()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(res, exp) {
+			t.Errorf("Expected %q, got %q", exp, res)
+		}
+	}
+}
@@ -10,7 +10,7 @@ import (
 func TestIntegerEncode(t *testing.T) {
 	tests := []struct {
 		name     string
-		input    int
+		input    int64
 		expected []byte
 	}{
 		{"Positive integer", 123, []byte(":123\r\n")},
@@ -20,11 +20,7 @@ func TestIntegerEncode(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			handler := resp.RESPHandler{}
-			res, err := handler.Integer.Encode(tc.input)
-			if err != nil {
-				t.Errorf("Encode(%d) returned an error: %v", tc.input, err)
-			}
+			res := resp.Integer(tc.input).EncodeRESP2()
 			if !reflect.DeepEqual(res, tc.expected) {
 				t.Errorf("Encode(%d) = %v, want %v", tc.input, res, tc.expected)
 			}
@@ -36,7 +32,7 @@ func TestIntegerDecode(t *testing.T) {
 	tests := []struct {
 		name        string
 		input       []byte
-		expected    int
+		expected    int64
 		expectError bool
 	}{
 		{"Positive integer", []byte(":123\r\n"), 123, false},
@@ -48,8 +44,8 @@ func TestIntegerDecode(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			handler := resp.RESPHandler{}
-			res, _, err := handler.Integer.Decode(tc.input)
+			value, _, err := resp.DecodeValue(tc.input)
+			res := value.Int
 			if tc.expectError {
 				if err == nil {
 					t.Errorf("Decode(%v) expected an error, got nil", tc.input)
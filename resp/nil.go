@@ -1,7 +0,0 @@
-package resp
-
-type nilString struct{}
-
-func (nilString) Encode() []byte {
-	return []byte("$-1\r\n")
-}
\ No newline at end of file
@@ -0,0 +1,21 @@
+package resp
+
+import "fmt"
+
+type null struct{}
+
+func (null) Decode(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return b, ErrIncomplete
+	}
+	if b[0] != '_' {
+		return b, fmt.Errorf("invalid format for null: expected the first byte to be '_', got '%q'", b[0])
+	}
+
+	b, err := parseCRLF(b[1:])
+	if err != nil {
+		return b, fmt.Errorf("invalid format for null: %w", err)
+	}
+
+	return b, nil
+}
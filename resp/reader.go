@@ -0,0 +1,157 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrIncomplete is returned by the decoders when the data ends before a complete
+// RESP element could be parsed. It means "read more and try again", not that the
+// data is malformed.
+var ErrIncomplete = errors.New("incomplete RESP data")
+
+// ErrProtocol wraps every decoding error returned by Reader, so that callers can
+// tell malformed input apart from I/O errors on the underlying connection.
+var ErrProtocol = errors.New("Protocol error")
+
+const minReadSize = 4096
+
+// Reader incrementally decodes RESP elements from a stream. Bytes that belong to
+// an element that has not been fully received yet are kept between reads, so an
+// element may be split across any number of reads and several elements may
+// arrive in the same read.
+type Reader struct {
+	rd  io.Reader
+	buf []byte        // Received but not yet consumed bytes
+	cmd *arrayDecoder // Progress on the command being received, if any
+}
+
+func NewReader(rd io.Reader) *Reader {
+	return &Reader{rd: rd}
+}
+
+// ReadCommand reads the next command, framed either as a RESP array of bulk
+// strings or as an inline command. Along with the decoded arguments it returns
+// the raw bytes the command was received as. An empty inline command decodes to
+// no arguments.
+func (r *Reader) ReadCommand() ([][]byte, []byte, error) {
+	var arr [][]byte
+	raw, err := r.next(func(b []byte) ([]byte, error) {
+		var err error
+		if len(b) > 0 && b[0] != '*' {
+			arr, b, err = inline{}.Decode(b)
+			return b, err
+		}
+		if r.cmd == nil {
+			r.cmd = &arrayDecoder{}
+		}
+		arr, b, err = r.cmd.decode(b)
+		return b, err
+	})
+	r.cmd = nil
+	return arr, raw, err
+}
+
+// ReadValue reads the next element, whatever its type.
+func (r *Reader) ReadValue() (Value, error) {
+	var value Value
+	_, err := r.next(func(b []byte) ([]byte, error) {
+		var err error
+		value, b, err = DecodeValue(b)
+		return b, err
+	})
+	return value, err
+}
+
+// ReadSimpleString reads the next element, which is expected to be a simple string.
+func (r *Reader) ReadSimpleString() (string, error) {
+	var str string
+	_, err := r.next(func(b []byte) ([]byte, error) {
+		var err error
+		str, b, err = simpleString{}.Decode(b)
+		return b, err
+	})
+	return str, err
+}
+
+// ReadFile reads a file transferred as $<len>\r\n<contents>, i.e. a bulk string
+// without the trailing CRLF, as used for the RDB file sent after FULLRESYNC.
+func (r *Reader) ReadFile() ([]byte, error) {
+	var contents []byte
+	_, err := r.next(func(b []byte) ([]byte, error) {
+		if len(b) == 0 {
+			return b, ErrIncomplete
+		}
+		if b[0] != '$' {
+			return b, fmt.Errorf("expected $ at the start of the datafile, got %q", b[0])
+		}
+		n, b, err := parseLen(b[1:])
+		if err != nil {
+			return b, err
+		}
+		if n < 0 || n > maxBulkLen {
+			return b, fmt.Errorf("invalid length for datafile: %d", n)
+		}
+		b, err = parseCRLF(b)
+		if err != nil {
+			return b, err
+		}
+		if len(b) < n {
+			return b, ErrIncomplete
+		}
+		contents = b[:n]
+		return b[n:], nil
+	})
+	return contents, err
+}
+
+// Buffered returns the number of bytes that have been received but not consumed yet.
+func (r *Reader) Buffered() int {
+	return len(r.buf)
+}
+
+// next runs decode over the buffered bytes, reading from the underlying reader
+// for as long as decode reports that the element is incomplete. On success the
+// decoded bytes are consumed and returned.
+func (r *Reader) next(decode func([]byte) ([]byte, error)) ([]byte, error) {
+	for {
+		if len(r.buf) > 0 {
+			rest, err := decode(r.buf)
+			if err == nil {
+				n := len(r.buf) - len(rest)
+				raw := r.buf[:n:n]
+				r.buf = r.buf[n:]
+				return raw, nil
+			}
+			if !errors.Is(err, ErrIncomplete) {
+				return nil, fmt.Errorf("%w: %v", ErrProtocol, err)
+			}
+		}
+
+		if err := r.fill(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// fill reads at least one more byte into the buffer. The buffer is never
+// compacted in place, since slices handed out by next may still point into it;
+// when it runs out of room a new, larger one is allocated instead.
+func (r *Reader) fill() error {
+	if cap(r.buf)-len(r.buf) < minReadSize {
+		buf := make([]byte, len(r.buf), max(2*len(r.buf), minReadSize))
+		copy(buf, r.buf)
+		r.buf = buf
+	}
+
+	n, err := r.rd.Read(r.buf[len(r.buf):cap(r.buf)])
+	r.buf = r.buf[:len(r.buf)+n]
+	if n > 0 {
+		return nil
+	}
+	if err == nil {
+		err = io.ErrNoProgress
+	}
+	return err
+}
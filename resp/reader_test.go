@@ -0,0 +1,124 @@
+package resp_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+func TestReaderReadCommand(t *testing.T) {
+	largeValue := strings.Repeat("x", 500*1024)
+
+	tests := []struct {
+		testCaseName string
+		input        []byte
+		oneByte      bool // Deliver the input one byte per read
+		expected     [][]string
+	}{
+		{
+			testCaseName: "Single command",
+			input:        []byte("*1\r\n$4\r\nPING\r\n"),
+			expected:     [][]string{{"PING"}},
+		},
+		{
+			testCaseName: "Several commands in one read",
+			input:        []byte("*1\r\n$4\r\nPING\r\n*2\r\n$4\r\nECHO\r\n$2\r\nhi\r\n"),
+			expected:     [][]string{{"PING"}, {"ECHO", "hi"}},
+		},
+		{
+			testCaseName: "Commands split across reads",
+			input:        []byte("*3\r\n$3\r\nSET\r\n$3\r\nfoo\r\n$3\r\nbar\r\n*1\r\n$4\r\nPING\r\n"),
+			oneByte:      true,
+			expected:     [][]string{{"SET", "foo", "bar"}, {"PING"}},
+		},
+		{
+			testCaseName: "Command larger than the read buffer",
+			input:        []byte("*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$512000\r\n" + largeValue + "\r\n"),
+			expected:     [][]string{{"SET", "k", largeValue}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			var rd io.Reader = bytes.NewReader(tc.input)
+			if tc.oneByte {
+				rd = iotest.OneByteReader(rd)
+			}
+			reader := resp.NewReader(rd)
+
+			var rawTotal int
+			for _, expected := range tc.expected {
+				args, raw, err := reader.ReadCommand()
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				res := make([]string, len(args))
+				for i, arg := range args {
+					res[i] = string(arg)
+				}
+				if !reflect.DeepEqual(res, expected) {
+					t.Fatalf("Expected %.40q, got %.40q", expected, res)
+				}
+				rawTotal += len(raw)
+			}
+
+			if rawTotal != len(tc.input) {
+				t.Errorf("Expected %d raw bytes, got %d", len(tc.input), rawTotal)
+			}
+			if _, _, err := reader.ReadCommand(); err != io.EOF {
+				t.Errorf("Expected EOF, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReaderProtocolError(t *testing.T) {
+	reader := resp.NewReader(bytes.NewReader([]byte("*1\r\n$4\r\nPINGXX\r\n")))
+
+	_, _, err := reader.ReadCommand()
+	if !errors.Is(err, resp.ErrProtocol) {
+		t.Errorf("Expected a protocol error, got %v", err)
+	}
+}
+
+func TestReaderReadFile(t *testing.T) {
+	reader := resp.NewReader(iotest.OneByteReader(bytes.NewReader([]byte("+FULLRESYNC abc 0\r\n$5\r\nREDIS*1\r\n$4\r\nPING\r\n"))))
+
+	str, err := reader.ReadSimpleString()
+	if err != nil || str != "FULLRESYNC abc 0" {
+		t.Fatalf("Expected FULLRESYNC reply, got %q (%v)", str, err)
+	}
+
+	file, err := reader.ReadFile()
+	if err != nil || string(file) != "REDIS" {
+		t.Fatalf("Expected file contents, got %q (%v)", file, err)
+	}
+
+	res, _, err := reader.ReadCommand()
+	if err != nil || !reflect.DeepEqual(res, [][]byte{[]byte("PING")}) {
+		t.Errorf("Expected the command following the file, got %q (%v)", res, err)
+	}
+}
+
+func TestReaderReadCommandKeepsProgress(t *testing.T) {
+	// Received one byte at a time, a command with many arguments is decoded once:
+	// the arguments completed by earlier reads are not decoded, and copied, again
+	const n = 500
+	input := "*500\r\n" + strings.Repeat("$1\r\nx\r\n", n)
+	allocs := testing.AllocsPerRun(1, func() {
+		reader := resp.NewReader(iotest.OneByteReader(strings.NewReader(input)))
+		if args, _, err := reader.ReadCommand(); err != nil || len(args) != n {
+			t.Fatalf("Expected %d arguments, got %d (%v)", n, len(args), err)
+		}
+	})
+	// Decoding from scratch on every read allocates for each argument on each read
+	if limit := float64(10 * len(input)); allocs > limit {
+		t.Fatalf("Expected at most %v allocations, got %v", limit, allocs)
+	}
+}
@@ -0,0 +1,74 @@
+package resp_test
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+func TestRESP3Encode(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		result       []byte
+		expected     []byte
+	}{
+		{"Null", resp.Null().Encode(), []byte("_\r\n")},
+		{"Boolean true", resp.Boolean(true).Encode(), []byte("#t\r\n")},
+		{"Boolean false", resp.Boolean(false).Encode(), []byte("#f\r\n")},
+		{"Double", resp.Double(1.5).Encode(), []byte(",1.5\r\n")},
+		{"Double with shortest representation", resp.Double(0.1).Encode(), []byte(",0.1\r\n")},
+		{"Double without exponent", resp.Double(123456789).Encode(), []byte(",123456789\r\n")},
+		{"Double with exponent", resp.Double(1e21).Encode(), []byte(",1e+21\r\n")},
+		{"Double infinity", resp.Double(math.Inf(-1)).Encode(), []byte(",-inf\r\n")},
+		{"Big number", resp.BigNumber(big.NewInt(-1234)).Encode(), []byte("(-1234\r\n")},
+		{"Verbatim string", resp.Verbatim("txt", "Some string").Encode(), []byte("=15\r\ntxt:Some string\r\n")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			if !reflect.DeepEqual(tc.result, tc.expected) {
+				t.Errorf("Expected %q, got %q", tc.expected, tc.result)
+			}
+		})
+	}
+}
+
+func TestRESP3Decode(t *testing.T) {
+	t.Run("Boolean", func(t *testing.T) {
+		v, rest, err := resp.DecodeValue([]byte("#t\r\n+OK\r\n"))
+		if err != nil || !v.Bool || string(rest) != "+OK\r\n" {
+			t.Errorf("Expected true, got %v (%v), remaining %q", v, err, rest)
+		}
+	})
+
+	t.Run("Double", func(t *testing.T) {
+		v, _, err := resp.DecodeValue([]byte(",3.25\r\n"))
+		if err != nil || v.Float != 3.25 {
+			t.Errorf("Expected 3.25, got %v (%v)", v, err)
+		}
+	})
+
+	t.Run("Big number", func(t *testing.T) {
+		v, _, err := resp.DecodeValue([]byte("(3492890328409238509324850943850943825024385\r\n"))
+		if err != nil || v.Big.String() != "3492890328409238509324850943850943825024385" {
+			t.Errorf("Expected big number, got %v (%v)", v, err)
+		}
+	})
+
+	t.Run("Verbatim string", func(t *testing.T) {
+		v, _, err := resp.DecodeValue([]byte("=15\r\ntxt:Some string\r\n"))
+		if err != nil || v.Format != "txt" || v.Str != "Some string" {
+			t.Errorf("Expected txt:Some string, got %s:%s (%v)", v.Format, v.Str, err)
+		}
+	})
+
+	t.Run("Null", func(t *testing.T) {
+		v, rest, err := resp.DecodeValue([]byte("_\r\n"))
+		if err != nil || v.Type != resp.TypeNull || len(rest) != 0 {
+			t.Errorf("Expected null, got remaining %q (%v)", rest, err)
+		}
+	})
+}
@@ -10,61 +10,42 @@ func TestSimpleStringEncode(t *testing.T) {
 		testCaseName string
 		input        string
 		expected     []byte
-		expectError   bool
 	}{
 		{
 			testCaseName: "Simple string",
 			input:        "Hello",
 			expected:     []byte("+Hello\r\n"),
-			expectError:   false,
 		},
 		{
 			testCaseName: "Empty string",
 			input:        "",
 			expected:     []byte("+\r\n"),
-			expectError:   false,
 		},
 		{
 			testCaseName: "String with spaces",
 			input:        "Hello World",
 			expected:     []byte("+Hello World\r\n"),
-			expectError:   false,
 		},
 		{
 			testCaseName: "String with CRLF",
 			input:        "Hello\r\nWorld",
-			expected:     nil,
-			expectError:   true,
+			expected:     []byte("+Hello  World\r\n"),
 		},
 		{
 			testCaseName: "String with CR",
 			input:        "Hello\rWorld",
-			expected:     nil,
-			expectError:   true,
+			expected:     []byte("+Hello World\r\n"),
 		},
 		{
 			testCaseName: "String with LF",
 			input:        "Hello\nWorld",
-			expected:     nil,
-			expectError:   true,
+			expected:     []byte("+Hello World\r\n"),
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.testCaseName, func(t *testing.T) {
-			handler := resp.RESPHandler{}
-			res, err := handler.String.Encode(tc.input)
-
-			if tc.expectError {
-				if err == nil {
-					t.Errorf("Expected error, got nil")
-				}
-				return
-			} else {
-				if err != nil {
-					t.Errorf("Expected nil, got %v", err)
-				}
-			}
+			res := resp.SimpleString(tc.input).EncodeRESP2()
 
 			if string(res) != string(tc.expected) {
 				t.Errorf("Expected %v, got %v", tc.expected, res)
@@ -114,8 +95,8 @@ func TestSimpleStringDecode(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.testCaseName, func(t *testing.T) {
-			handler := resp.RESPHandler{}
-			res,_, err := handler.String.Decode(tc.input)
+			value, _, err := resp.DecodeValue(tc.input)
+			res := value.Str
 
 			if tc.expectError {
 				if err == nil {
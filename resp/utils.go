@@ -1,34 +1,60 @@
 package resp
 
 import (
+	"bytes"
 	"fmt"
 	"strconv"
 )
 
 // Parses the length of the string from the byte slice and returns the length and the remaining byte slice
 func parseLen(b []byte) (int, []byte, error) {
-	lenStr := ""
-	for i := 0; i < len(b); i++ {
-		if b[i] == '\r' {
-			break
+	end := bytes.IndexByte(b, '\r')
+	if end == -1 {
+		// The length is still being received as long as everything seen so far could be part of it
+		for i, c := range b {
+			if (c < '0' || c > '9') && !(i == 0 && c == '-') {
+				return 0, nil, fmt.Errorf("cannot parse number from string %q", b)
+			}
 		}
-		lenStr += string(b[i])
+		return 0, nil, ErrIncomplete
 	}
 
+	lenStr := string(b[:end])
 	n, err := strconv.Atoi(lenStr)
 	if err != nil {
 		return 0, nil, fmt.Errorf("cannot parse number from string %s: %v", lenStr, err)
 	}
 
-	return n, b[len(lenStr):], nil
+	return n, b[end:], nil
 }
 
 // Checks if the first two bytes of the byte slice are '\r\n'
 // and returns the remaining byte slice
 func parseCRLF(b []byte) ([]byte, error) {
+	if len(b) < 2 {
+		if len(b) == 0 || b[0] == '\r' {
+			return nil, ErrIncomplete
+		}
+		return nil, fmt.Errorf("expected the next two bytes to be \\r\\n, got %q", b)
+	}
 	if b[0] != '\r' || b[1] != '\n' {
 		return nil, fmt.Errorf("expected the next two bytes to be \\r\\n, got %q", b[0:2])
 	}
 
 	return b[2:], nil
-}
\ No newline at end of file
+}
+
+// Parses a line terminated by '\r\n' and returns its contents and the remaining byte slice
+func parseLine(b []byte) (string, []byte, error) {
+	end := bytes.IndexByte(b, '\r')
+	if end == -1 {
+		return "", b, ErrIncomplete
+	}
+
+	rest, err := parseCRLF(b[end:])
+	if err != nil {
+		return "", b, err
+	}
+
+	return string(b[:end]), rest, nil
+}
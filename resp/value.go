@@ -0,0 +1,358 @@
+package resp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Type identifies the RESP type of a Value by its prefix byte
+type Type byte
+
+const (
+	TypeSimpleString   Type = '+'
+	TypeError          Type = '-'
+	TypeInteger        Type = ':'
+	TypeBulkString     Type = '$'
+	TypeArray          Type = '*'
+	TypeNull           Type = '_'
+	TypeBoolean        Type = '#'
+	TypeDouble         Type = ','
+	TypeBigNumber      Type = '('
+	TypeVerbatimString Type = '='
+	TypeMap            Type = '%'
+	TypeSet            Type = '~'
+	TypePush           Type = '>'
+)
+
+// maxNesting limits how deeply aggregates may be nested when decoding
+const maxNesting = 512
+
+// Value is a single RESP element of any type. Aggregates hold their elements in
+// Elems, which makes it possible to represent nested and mixed replies.
+type Value struct {
+	Type   Type
+	Str    string   // Simple string, error and verbatim string contents
+	Bulk   []byte   // Bulk string contents, binary safe
+	Int    int64    // Integer
+	Float  float64  // Double
+	Bool   bool     // Boolean
+	Big    *big.Int // Big number
+	Format string   // Verbatim string format, such as "txt"
+	Elems  []Value  // Array, set and push elements. Maps hold alternating keys and values
+	Attrs  []Value  // Attribute sent ahead of the value, as alternating keys and values
+	IsNil  bool     // Null bulk string or null array (RESP2)
+}
+
+func SimpleString(s string) Value { return Value{Type: TypeSimpleString, Str: s} }
+func Error(msg string) Value      { return Value{Type: TypeError, Str: msg} }
+func Integer(n int64) Value       { return Value{Type: TypeInteger, Int: n} }
+func BulkString(s string) Value   { return Value{Type: TypeBulkString, Bulk: []byte(s)} }
+func BulkBytes(b []byte) Value    { return Value{Type: TypeBulkString, Bulk: b} }
+func NullBulkString() Value       { return Value{Type: TypeBulkString, IsNil: true} }
+func Array(elems ...Value) Value  { return Value{Type: TypeArray, Elems: elems} }
+func NullArray() Value            { return Value{Type: TypeArray, IsNil: true} }
+func Null() Value                 { return Value{Type: TypeNull} }
+func Boolean(b bool) Value        { return Value{Type: TypeBoolean, Bool: b} }
+func Double(f float64) Value      { return Value{Type: TypeDouble, Float: f} }
+func BigNumber(n *big.Int) Value  { return Value{Type: TypeBigNumber, Big: n} }
+func Set(elems ...Value) Value    { return Value{Type: TypeSet, Elems: elems} }
+func Push(elems ...Value) Value   { return Value{Type: TypePush, Elems: elems} }
+
+func Verbatim(format string, s string) Value {
+	return Value{Type: TypeVerbatimString, Format: format, Str: s}
+}
+
+// Map builds a map from alternating keys and values
+func Map(kvs ...Value) Value {
+	return Value{Type: TypeMap, Elems: kvs}
+}
+
+// BulkStrings builds an array of bulk strings
+func BulkStrings(strs ...string) Value {
+	elems := make([]Value, len(strs))
+	for i, s := range strs {
+		elems[i] = BulkString(s)
+	}
+	return Array(elems...)
+}
+
+// IsNull reports whether the value is any of the null types
+func (v Value) IsNull() bool {
+	return v.Type == TypeNull || v.IsNil
+}
+
+// Encode encodes the value, including the RESP3 types. Null bulk strings and null arrays are encoded as the RESP3 null.
+func (v Value) Encode() []byte {
+	return v.appendTo(nil, 3)
+}
+
+// EncodeRESP2 encodes the value using only RESP2 types, converting the RESP3 types
+// to their closest RESP2 equivalent the same way Redis does for RESP2 clients.
+func (v Value) EncodeRESP2() []byte {
+	return v.appendTo(nil, 2)
+}
+
+func (v Value) appendTo(b []byte, protocol int) []byte {
+	if protocol == 3 && len(v.Attrs) > 0 {
+		b = appendHeader(b, '|', len(v.Attrs)/2)
+		for _, attr := range v.Attrs {
+			b = attr.appendTo(b, protocol)
+		}
+	}
+
+	if v.IsNil {
+		switch {
+		case protocol == 3:
+			return append(b, "_\r\n"...)
+		case v.Type == TypeArray:
+			return append(b, "*-1\r\n"...)
+		default:
+			return append(b, "$-1\r\n"...)
+		}
+	}
+
+	switch v.Type {
+	case TypeSimpleString, TypeError:
+		// A line break would end the line early, so it is sent as a space, as Redis
+		// does for error messages
+		b = append(b, byte(v.Type))
+		b = append(b, lineBreaks.Replace(v.Str)...)
+		return append(b, "\r\n"...)
+	case TypeInteger:
+		b = append(b, ':')
+		b = strconv.AppendInt(b, v.Int, 10)
+		return append(b, "\r\n"...)
+	case TypeBulkString:
+		b = appendHeader(b, '$', len(v.Bulk))
+		b = append(b, v.Bulk...)
+		return append(b, "\r\n"...)
+	case TypeNull:
+		if protocol == 2 {
+			return append(b, "$-1\r\n"...)
+		}
+		return append(b, "_\r\n"...)
+	case TypeBoolean:
+		if protocol == 2 {
+			return append(b, fmt.Sprintf(":%d\r\n", boolToInt(v.Bool))...)
+		}
+		encoded, _ := boolean{}.Encode(v.Bool)
+		return append(b, encoded...)
+	case TypeDouble:
+		encoded, _ := double{}.Encode(v.Float)
+		if protocol == 2 {
+			return appendBlob(b, '$', string(encoded[1:len(encoded)-2]))
+		}
+		return append(b, encoded...)
+	case TypeBigNumber:
+		if protocol == 2 {
+			return appendBlob(b, '$', v.Big.String())
+		}
+		return append(b, "("+v.Big.String()+"\r\n"...)
+	case TypeVerbatimString:
+		if protocol == 2 {
+			return appendBlob(b, '$', v.Str)
+		}
+		return appendBlob(b, '=', v.Format+":"+v.Str)
+	case TypeMap:
+		if protocol == 2 {
+			b = appendHeader(b, '*', len(v.Elems))
+		} else {
+			b = appendHeader(b, '%', len(v.Elems)/2)
+		}
+	case TypeArray, TypeSet, TypePush:
+		prefix := byte(v.Type)
+		if protocol == 2 {
+			prefix = '*'
+		}
+		b = appendHeader(b, prefix, len(v.Elems))
+	default:
+		return append(b, fmt.Sprintf("-ERR cannot encode value of type %q\r\n", byte(v.Type))...)
+	}
+
+	for _, elem := range v.Elems {
+		b = elem.appendTo(b, protocol)
+	}
+	return b
+}
+
+var lineBreaks = strings.NewReplacer("\r", " ", "\n", " ")
+
+func appendHeader(b []byte, prefix byte, n int) []byte {
+	b = append(b, prefix)
+	b = strconv.AppendInt(b, int64(n), 10)
+	return append(b, "\r\n"...)
+}
+
+func appendBlob(b []byte, prefix byte, s string) []byte {
+	b = appendHeader(b, prefix, len(s))
+	b = append(b, s...)
+	return append(b, "\r\n"...)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// DecodeValue decodes a single element of any RESP2 or RESP3 type, including
+// nested aggregates, and returns it along with the remaining byte slice.
+// Attributes are attached to the value that follows them.
+func DecodeValue(b []byte) (Value, []byte, error) {
+	return decodeValue(b, 0)
+}
+
+func decodeValue(b []byte, depth int) (Value, []byte, error) {
+	if depth > maxNesting {
+		return Value{}, b, errors.New("invalid format: too many nested aggregates")
+	}
+	if len(b) == 0 {
+		return Value{}, b, ErrIncomplete
+	}
+
+	switch Type(b[0]) {
+	case TypeArray, TypeSet, TypePush, TypeMap, '|':
+		return decodeAggregate(b, depth)
+	case TypeBulkString:
+		if bytes.HasPrefix(b, []byte("$-1\r\n")) {
+			return NullBulkString(), b[5:], nil
+		}
+		str, rest, err := bulkString{}.Decode(b)
+		return BulkBytes(str), rest, err
+	case TypeSimpleString:
+		str, rest, err := simpleString{}.Decode(b)
+		return SimpleString(str), rest, err
+	case TypeError:
+		line, rest, err := parseLine(b[1:])
+		if err != nil {
+			return Value{}, b, fmt.Errorf("invalid format for error: %w", err)
+		}
+		return Error(line), rest, nil
+	case TypeInteger:
+		line, rest, err := parseLine(b[1:])
+		if err != nil {
+			return Value{}, b, fmt.Errorf("invalid format for integer: %w", err)
+		}
+		n, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			return Value{}, b, fmt.Errorf("invalid format for integer: %v", err)
+		}
+		return Integer(n), rest, nil
+	case TypeNull:
+		rest, err := null{}.Decode(b)
+		return Null(), rest, err
+	case TypeBoolean:
+		v, rest, err := boolean{}.Decode(b)
+		return Boolean(v), rest, err
+	case TypeDouble:
+		v, rest, err := double{}.Decode(b)
+		return Double(v), rest, err
+	case TypeBigNumber:
+		v, rest, err := bigNumber{}.Decode(b)
+		return BigNumber(v), rest, err
+	case TypeVerbatimString:
+		format, text, rest, err := verbatimString{}.Decode(b)
+		return Verbatim(format, text), rest, err
+	default:
+		return Value{}, b, fmt.Errorf("invalid format: unknown type %q", b[0])
+	}
+}
+
+func decodeAggregate(b []byte, depth int) (Value, []byte, error) {
+	prefix := b[0]
+	n, rest, err := parseLen(b[1:])
+	if err != nil {
+		return Value{}, b, fmt.Errorf("invalid format for aggregate: %w", err)
+	}
+	rest, err = parseCRLF(rest)
+	if err != nil {
+		return Value{}, b, fmt.Errorf("invalid format for aggregate: %w", err)
+	}
+	if n < 0 {
+		if prefix != '*' {
+			return Value{}, b, fmt.Errorf("invalid format for aggregate: invalid length %d", n)
+		}
+		return NullArray(), rest, nil
+	}
+
+	count := n
+	if prefix == '%' || prefix == '|' {
+		count = 2 * n
+	}
+
+	elems := make([]Value, 0, min(count, 1024))
+	for i := 0; i < count; i++ {
+		var elem Value
+		elem, rest, err = decodeValue(rest, depth+1)
+		if err != nil {
+			return Value{}, b, err
+		}
+		elems = append(elems, elem)
+	}
+
+	if prefix == '|' {
+		v, rest, err := decodeValue(rest, depth)
+		if err != nil {
+			return Value{}, b, err
+		}
+		v.Attrs = elems
+		return v, rest, nil
+	}
+
+	return Value{Type: Type(prefix), Elems: elems}, rest, nil
+}
+
+// Interface converts the value to plain Go values: strings for simple strings,
+// []byte for bulk strings, int for integers, error for errors, nil for nulls and
+// []interface{} for arrays, sets and pushes. Maps become map[string]interface{}.
+func (v Value) Interface() interface{} {
+	if v.IsNull() {
+		return nil
+	}
+
+	switch v.Type {
+	case TypeSimpleString, TypeVerbatimString:
+		return v.Str
+	case TypeError:
+		return errors.New(v.Str)
+	case TypeInteger:
+		return int(v.Int)
+	case TypeBulkString:
+		return v.Bulk
+	case TypeBoolean:
+		return v.Bool
+	case TypeDouble:
+		return v.Float
+	case TypeBigNumber:
+		return v.Big
+	case TypeMap:
+		m := make(map[string]interface{}, len(v.Elems)/2)
+		for i := 0; i+1 < len(v.Elems); i += 2 {
+			m[v.Elems[i].String()] = v.Elems[i+1].Interface()
+		}
+		return m
+	default:
+		elems := make([]interface{}, 0, len(v.Elems))
+		for _, elem := range v.Elems {
+			elems = append(elems, elem.Interface())
+		}
+		return elems
+	}
+}
+
+// String returns the contents of string values, and a printable representation of any other value
+func (v Value) String() string {
+	switch v.Type {
+	case TypeBulkString:
+		return string(v.Bulk)
+	case TypeSimpleString, TypeError, TypeVerbatimString:
+		return v.Str
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
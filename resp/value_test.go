@@ -0,0 +1,163 @@
+package resp_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/codecrafters-io/redis-starter-go/resp"
+)
+
+func TestValueEncode(t *testing.T) {
+	tests := []struct {
+		testCaseName  string
+		input         resp.Value
+		expectedRESP3 []byte
+		expectedRESP2 []byte
+	}{
+		{
+			testCaseName:  "Nested array",
+			input:         resp.Array(resp.BulkString("1-0"), resp.BulkStrings("field", "value")),
+			expectedRESP3: []byte("*2\r\n$3\r\n1-0\r\n*2\r\n$5\r\nfield\r\n$5\r\nvalue\r\n"),
+			expectedRESP2: []byte("*2\r\n$3\r\n1-0\r\n*2\r\n$5\r\nfield\r\n$5\r\nvalue\r\n"),
+		},
+		{
+			testCaseName:  "Mixed element types",
+			input:         resp.Array(resp.Integer(1), resp.SimpleString("OK"), resp.NullBulkString(), resp.Error("ERR x")),
+			expectedRESP3: []byte("*4\r\n:1\r\n+OK\r\n_\r\n-ERR x\r\n"),
+			expectedRESP2: []byte("*4\r\n:1\r\n+OK\r\n$-1\r\n-ERR x\r\n"),
+		},
+		{
+			testCaseName:  "Map",
+			input:         resp.Map(resp.BulkString("a"), resp.Double(1.5)),
+			expectedRESP3: []byte("%1\r\n$1\r\na\r\n,1.5\r\n"),
+			expectedRESP2: []byte("*2\r\n$1\r\na\r\n$3\r\n1.5\r\n"),
+		},
+		{
+			testCaseName:  "Null array",
+			input:         resp.NullArray(),
+			expectedRESP3: []byte("_\r\n"),
+			expectedRESP2: []byte("*-1\r\n"),
+		},
+		{
+			testCaseName:  "Set of booleans",
+			input:         resp.Set(resp.Boolean(true), resp.Boolean(false)),
+			expectedRESP3: []byte("~2\r\n#t\r\n#f\r\n"),
+			expectedRESP2: []byte("*2\r\n:1\r\n:0\r\n"),
+		},
+		{
+			testCaseName:  "Push of a nested map",
+			input:         resp.Push(resp.BulkString("message"), resp.Map(resp.BulkString("a"), resp.Array(resp.Integer(1)))),
+			expectedRESP3: []byte(">2\r\n$7\r\nmessage\r\n%1\r\n$1\r\na\r\n*1\r\n:1\r\n"),
+			expectedRESP2: []byte("*2\r\n$7\r\nmessage\r\n*2\r\n$1\r\na\r\n*1\r\n:1\r\n"),
+		},
+		{
+			testCaseName:  "Attribute",
+			input:         resp.Value{Type: resp.TypeInteger, Int: 1, Attrs: []resp.Value{resp.BulkString("ttl"), resp.Integer(3600)}},
+			expectedRESP3: []byte("|1\r\n$3\r\nttl\r\n:3600\r\n:1\r\n"),
+			expectedRESP2: []byte(":1\r\n"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			if res := tc.input.Encode(); !reflect.DeepEqual(res, tc.expectedRESP3) {
+				t.Errorf("Expected %q, got %q", tc.expectedRESP3, res)
+			}
+			if res := tc.input.EncodeRESP2(); !reflect.DeepEqual(res, tc.expectedRESP2) {
+				t.Errorf("Expected %q, got %q", tc.expectedRESP2, res)
+			}
+		})
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	tests := []struct {
+		testCaseName string
+		input        []byte
+		expected     resp.Value
+		expectError  bool
+	}{
+		{
+			testCaseName: "Nested arrays",
+			input:        []byte("*2\r\n*2\r\n$3\r\n1-0\r\n*2\r\n$1\r\na\r\n$1\r\nb\r\n:7\r\n"),
+			expected: resp.Array(
+				resp.Array(resp.BulkString("1-0"), resp.BulkStrings("a", "b")),
+				resp.Integer(7),
+			),
+		},
+		{
+			testCaseName: "Map with attribute",
+			input:        []byte("|1\r\n+ttl\r\n:10\r\n%1\r\n+key\r\n_\r\n"),
+			expected: resp.Value{
+				Type:  resp.TypeMap,
+				Elems: []resp.Value{resp.SimpleString("key"), resp.Null()},
+				Attrs: []resp.Value{resp.SimpleString("ttl"), resp.Integer(10)},
+			},
+		},
+		{
+			testCaseName: "Set of mixed elements",
+			input:        []byte("~3\r\n+a\r\n*1\r\n:2\r\n_\r\n"),
+			expected:     resp.Set(resp.SimpleString("a"), resp.Array(resp.Integer(2)), resp.Null()),
+		},
+		{
+			testCaseName: "Push",
+			input:        []byte(">3\r\n$7\r\nmessage\r\n$2\r\nch\r\n$2\r\nhi\r\n"),
+			expected:     resp.Push(resp.BulkString("message"), resp.BulkString("ch"), resp.BulkString("hi")),
+		},
+		{
+			testCaseName: "Incomplete push",
+			input:        []byte(">2\r\n+message\r\n"),
+			expectError:  true,
+		},
+		{
+			testCaseName: "Null array",
+			input:        []byte("*-1\r\n"),
+			expected:     resp.NullArray(),
+		},
+		{
+			testCaseName: "Incomplete nested array",
+			input:        []byte("*2\r\n*1\r\n:1\r\n"),
+			expectError:  true,
+		},
+		{
+			testCaseName: "Unknown type",
+			input:        []byte("?1\r\n"),
+			expectError:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testCaseName, func(t *testing.T) {
+			res, rest, err := resp.DecodeValue(tc.input)
+
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(rest) != 0 {
+				t.Errorf("Unexpected remaining bytes: %q", rest)
+			}
+			if !reflect.DeepEqual(res, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestValueInterfaceNested(t *testing.T) {
+	value, _, err := resp.DecodeValue([]byte("*2\r\n+OK\r\n*2\r\n:1\r\n$3\r\nfoo\r\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	res := value.Interface()
+
+	expected := []interface{}{"OK", []interface{}{1, []byte("foo")}}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v, got %v", expected, res)
+	}
+}
@@ -0,0 +1,27 @@
+package resp
+
+import (
+	"fmt"
+)
+
+type verbatimString struct{}
+
+func (verbatimString) Decode(b []byte) (string, string, []byte, error) {
+	if len(b) == 0 {
+		return "", "", b, ErrIncomplete
+	}
+	if b[0] != '=' {
+		return "", "", b, fmt.Errorf("invalid format for verbatim string: expected the first byte to be '=', got '%q'", b[0])
+	}
+
+	blob, rest, err := parseBlob(b[1:])
+	if err != nil {
+		return "", "", b, fmt.Errorf("invalid format for verbatim string: %w", err)
+	}
+	str := string(blob)
+	if len(str) < 4 || str[3] != ':' {
+		return "", "", b, fmt.Errorf("invalid format for verbatim string: missing format prefix in %q", str)
+	}
+
+	return str[:3], str[4:], rest, nil
+}